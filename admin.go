@@ -0,0 +1,141 @@
+package flagfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// adminDetail is the Origin detail of values changed through AdminHandler
+const adminDetail = "admin"
+
+// AdminOptions configures the handler made by AdminHandler
+type AdminOptions struct {
+	// Authorize decides whether a request may change flags. Changes are refused when it is nil, making the handler
+	// read-only
+	Authorize func(r *http.Request) bool
+}
+
+// adminFlag is a flag as the AdminHandler reports it
+type adminFlag struct {
+	Name       string      `json:"name"`
+	Value      interface{} `json:"value"`
+	Source     string      `json:"source"`
+	Detail     string      `json:"detail,omitempty"`
+	Sensitive  bool        `json:"sensitive,omitempty"`
+	Reloadable bool        `json:"reloadable,omitempty"`
+}
+
+// AdminHandler is an http.Handler for an internal admin mux, which shows the running configuration and, optionally,
+// changes it:
+//
+//	admin := http.NewServeMux()
+//	admin.Handle("/config", flags.AdminHandler(flagfig.AdminOptions{
+//		Authorize: func(r *http.Request) bool {
+//			return r.Header.Get("Authorization") == "Bearer "+adminToken
+//		},
+//	}))
+//
+// A GET lists every flag as JSON, with its value, as of the last Snapshot, and where the value came from. Sensitive
+// values are redacted. A POST of form values, such as limit=20, changes Reloadable flags, when Authorize allows it,
+// and answers as a GET does. The changes are validated and the OnParsed hooks run, as after Parse, and nothing changes
+// if either fails. Changed values count as given on the command line, so they survive Reload, with the Origin detail
+// "admin", and the OnReload hooks are told about them
+func (f *FlagfigSet) AdminHandler(opts AdminOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPost:
+			if opts.Authorize == nil || !opts.Authorize(r) {
+				http.Error(w, "flagfig: not allowed to change the configuration", http.StatusForbidden)
+				return
+			}
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "flagfig: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			values := make(map[string]string)
+			for name, v := range r.PostForm {
+				values[name] = v[len(v)-1]
+			}
+			if err := f.override(values); err != nil {
+				http.Error(w, "flagfig: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			http.Error(w, "flagfig: method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"flags": f.adminFlags()})
+	})
+}
+
+// adminFlags lists the flags of the latest Snapshot, by name, with Sensitive values redacted
+func (f *FlagfigSet) adminFlags() []adminFlag {
+	s := f.Snapshot()
+	flags := make([]adminFlag, 0, len(s.values))
+	for name, v := range s.Settings() {
+		source, detail := s.Origin(name)
+		if f.redacted(name) && v != "" {
+			v = redactedValue
+		}
+		flags = append(flags, adminFlag{Name: name, Value: v, Source: source.String(), Detail: detail,
+			Sensitive: f.sensitive[name], Reloadable: f.reloadable[name]})
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	return flags
+}
+
+// override sets Reloadable flags while the program runs, as AdminHandler describes, then tells the OnReload hooks
+func (f *FlagfigSet) override(values map[string]string) error {
+	before, err := f.setOverrides(values)
+	if err != nil {
+		return err
+	}
+	f.reportChanges(before)
+	return nil
+}
+
+// setOverrides does the work of override, returning the Snapshot from before
+func (f *FlagfigSet) setOverrides(values map[string]string) (before *Snapshot, err error) {
+	f.reloadMu.Lock()
+	defer f.reloadMu.Unlock()
+	names := make([]string, 0, len(values))
+	for name := range values {
+		if f.FlagSet.Lookup(name) == nil {
+			return nil, fmt.Errorf("no such flag -%s", name)
+		}
+		if !f.reloadable[name] {
+			return nil, fmt.Errorf("flag -%s may not be changed while running", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	before = f.Snapshot()
+	restore := f.saveValues()
+	defer func() {
+		if err != nil {
+			restore()
+		}
+	}()
+	for _, name := range names {
+		if err = f.FlagSet.Set(name, values[name]); err != nil {
+			value, err := f.redactValue(name, values[name], err)
+			return nil, fmt.Errorf("invalid value %q for flag -%s: %v", value, name, err)
+		}
+		f.origins[name] = origin{source: SourceFlag, detail: adminDetail}
+	}
+	err = f.validate()
+	if err != nil {
+		return
+	}
+	err = f.runParsedHooks()
+	if err != nil {
+		return
+	}
+	f.publish()
+	return
+}
@@ -0,0 +1,90 @@
+package flagfig
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestAdminHandler(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	limit := f.Int("limit", 10, "", "limit", Reloadable(), Max(100))
+	f.String("password", "", "", "password", Sensitive())
+	f.String("host", "localhost", "", "host")
+	if err := f.Parse([]string{"-password=secret"}); err != nil {
+		t.Fatal(err)
+	}
+	handler := f.AdminHandler(AdminOptions{Authorize: func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer token"
+	}})
+	expectedFlags := `{"flags":[{"name":"host","value":"localhost","source":"default"},` +
+		`{"name":"limit","value":%s,"source":"%s"%s,"reloadable":true},` +
+		`{"name":"password","value":"****","source":"flag","sensitive":true}]}` + "\n"
+
+	cases := map[string]struct {
+		method   string
+		form     url.Values
+		token    string
+		status   int
+		expected string
+	}{
+		"get": {
+			method:   http.MethodGet,
+			status:   http.StatusOK,
+			expected: fmt.Sprintf(expectedFlags, "10", "default", ""),
+		},
+		"unauthorized": {
+			method:   http.MethodPost,
+			form:     url.Values{"limit": {"20"}},
+			status:   http.StatusForbidden,
+			expected: "flagfig: not allowed to change the configuration\n",
+		},
+		"not reloadable": {
+			method:   http.MethodPost,
+			form:     url.Values{"host": {"example.com"}},
+			token:    "token",
+			status:   http.StatusBadRequest,
+			expected: "flagfig: flag -host may not be changed while running\n",
+		},
+		"invalid": {
+			method:   http.MethodPost,
+			form:     url.Values{"limit": {"1000"}},
+			token:    "token",
+			status:   http.StatusBadRequest,
+			expected: "flagfig: -limit is 1000 from the admin handler, but must be at most 100\n",
+		},
+		"changed": {
+			method:   http.MethodPost,
+			form:     url.Values{"limit": {"20"}},
+			token:    "token",
+			status:   http.StatusOK,
+			expected: fmt.Sprintf(expectedFlags, "20", "flag", `,"detail":"admin"`),
+		},
+	}
+	for _, caseName := range []string{"get", "unauthorized", "not reloadable", "invalid", "changed"} {
+		c := cases[caseName]
+		r := httptest.NewRequest(c.method, "/config", strings.NewReader(c.form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if len(c.token) != 0 {
+			r.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != c.status || w.Body.String() != c.expected {
+			t.Errorf("case %s: expected %d %q, got %d %q", caseName, c.status, c.expected, w.Code, w.Body.String())
+		}
+	}
+	if *limit != 20 {
+		t.Error("expected -limit to be changed, got ", *limit)
+	}
+	if source, detail := f.Origin("limit"); source != SourceFlag || detail != "admin" {
+		t.Errorf("expected -limit from admin, got %s %s", source, detail)
+	}
+	if err := f.Reload(); err != nil || *limit != 20 {
+		t.Errorf("expected the change to survive Reload, got %v and %d", err, *limit)
+	}
+}
@@ -0,0 +1,68 @@
+package flagfig
+
+import (
+	"fmt"
+	"sort"
+)
+
+func Alias(existing string, aliases ...string) {
+	CommandLine.Alias(existing, aliases...)
+}
+
+// Alias adds other command line names for an existing flag, which is how a flag is renamed without breaking the
+// scripts that use the old name:
+//
+//	flags.String("listen-addr", ":8080", "MYAPP_LISTEN_ADDR", "address to listen on")
+//	flags.Alias("listen-addr", "httpaddr", "addr")
+//
+// -httpaddr=:9090 then sets -listen-addr. Use Deprecate instead to warn people about the old name.
+// Like defining a flag twice, it panics if a name is already taken or if the flag does not exist
+func (f *FlagfigSet) Alias(existing string, aliases ...string) {
+	if f.FlagSet.Lookup(existing) == nil {
+		panic(fmt.Sprintf("%s cannot alias undefined flag: %s", f.Name(), existing))
+	}
+	for _, alias := range aliases {
+		f.addAlias(existing, alias)
+		f.aliasNames[existing] = append(f.aliasNames[existing], alias)
+	}
+}
+
+func AliasConfigKey(existing string, keys ...string) {
+	CommandLine.AliasConfigKey(existing, keys...)
+}
+
+// AliasConfigKey adds other configuration file keys for an existing flag, so configuration files written for an older
+// version keep working. If a file has both the flag's own key and an alias, the flag's own key wins
+func (f *FlagfigSet) AliasConfigKey(existing string, keys ...string) {
+	if f.FlagSet.Lookup(existing) == nil {
+		panic(fmt.Sprintf("%s cannot alias undefined flag: %s", f.Name(), existing))
+	}
+	for _, key := range keys {
+		f.configAliases[key] = existing
+	}
+}
+
+// applyConfigAliases renames the aliased keys of a configuration document to the names of their flags, recording the
+// keys as written in keys
+func (f *FlagfigSet) applyConfigAliases(doc map[string]interface{}, keys map[string]string) {
+	aliased := make([]string, 0)
+	for key := range doc {
+		if _, ok := f.configAliases[key]; ok {
+			aliased = append(aliased, key)
+		}
+	}
+	// Sorted, so that the same alias wins every time when a file has several aliases for the same flag
+	sort.Strings(aliased)
+	for _, key := range aliased {
+		name := f.configAliases[key]
+		if _, ok := doc[name]; !ok {
+			doc[name] = doc[key]
+			keys[name] = key
+			if written, ok := keys[key]; ok {
+				keys[name] = written
+			}
+		}
+		delete(doc, key)
+		delete(keys, key)
+	}
+}
@@ -0,0 +1,63 @@
+package flagfig
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"testing"
+)
+
+func TestAlias(t *testing.T) {
+	out := &bytes.Buffer{}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetOutput(out)
+	addr := f.String("listen-addr", ":8080", "", "address to listen on")
+	f.Alias("listen-addr", "httpaddr", "addr")
+	if err := f.Parse([]string{"-httpaddr", ":9090"}); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if *addr != ":9090" {
+		t.Error("expected the alias to set -listen-addr, got ", *addr)
+	}
+	if source, _ := f.Origin("listen-addr"); source != SourceFlag {
+		t.Error("expected -listen-addr to come from the command line, got ", source)
+	}
+	f.PrintDefaults()
+	expected := "  -listen-addr, -httpaddr, -addr string\n    \taddress to listen on (default \":8080\")\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestAliasConfigKey(t *testing.T) {
+	cases := map[string]struct {
+		content  string
+		expected string
+	}{
+		"alias": {
+			content:  `{"httpaddr": ":9090"}`,
+			expected: ":9090",
+		},
+		"own key wins": {
+			content:  `{"httpaddr": ":9090", "listen-addr": ":7070"}`,
+			expected: ":7070",
+		},
+	}
+	for caseName, c := range cases {
+		path, cleanup := testTempFile(t)
+		if err := ioutil.WriteFile(path, []byte(c.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.SetStrictConfig(true)
+		f.AddConfigLayer("base", path)
+		addr := f.String("listen-addr", ":8080", "", "address to listen on")
+		f.AliasConfigKey("listen-addr", "httpaddr")
+		if err := f.Parse([]string{}); err != nil {
+			t.Errorf("case %s: unexpected error: %s", caseName, err)
+		} else if *addr != c.expected {
+			t.Errorf("case %s: expected %q, got %q", caseName, c.expected, *addr)
+		}
+		cleanup()
+	}
+}
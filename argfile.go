@@ -0,0 +1,68 @@
+package flagfig
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+func SetArgFiles(enabled bool) {
+	CommandLine.SetArgFiles(enabled)
+}
+
+// SetArgFiles lets arguments be read from files: an argument of @path is replaced by the arguments in the file, one
+// per line, so that command lines too long for the operating system, or generated by other tools, can be passed:
+//
+//	myapp @build.args file.txt
+//
+// Leading and trailing spaces are trimmed, and blank lines and lines starting with # are skipped. Files may name
+// other files the same way. Write @@name for an argument that really starts with @. Arguments after "--" are left as is
+func (f *FlagfigSet) SetArgFiles(enabled bool) {
+	f.argFiles = enabled
+}
+
+// expandArgFiles replaces the @path arguments with the contents of the files
+func (f *FlagfigSet) expandArgFiles(arguments []string, reading []string) (out []string, err error) {
+	out = make([]string, 0, len(arguments))
+	for i, arg := range arguments {
+		if arg == "--" {
+			return append(out, arguments[i:]...), nil
+		}
+		if strings.HasPrefix(arg, "@@") {
+			out = append(out, arg[1:])
+			continue
+		}
+		if len(arg) < 2 || arg[0] != '@' {
+			out = append(out, arg)
+			continue
+		}
+		path := arg[1:]
+		for _, open := range reading {
+			if open == path {
+				return nil, fmt.Errorf("argument file %s includes itself", path)
+			}
+		}
+		dat, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read argument file: %v", err)
+		}
+		expanded, err := f.expandArgFiles(argFileLines(string(dat)), append(reading, path))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, expanded...)
+	}
+	return out, nil
+}
+
+// argFileLines is the arguments in an argument file
+func argFileLines(contents string) (args []string) {
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		args = append(args, line)
+	}
+	return
+}
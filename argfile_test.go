@@ -0,0 +1,80 @@
+package flagfig
+
+import (
+	"flag"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestSetArgFiles(t *testing.T) {
+	inner, removeInner := testTempFile(t)
+	defer removeInner()
+	outer, removeOuter := testTempFile(t)
+	defer removeOuter()
+	_ = ioutil.WriteFile(inner, []byte("-verbose\n"), 0600)
+	_ = ioutil.WriteFile(outer, []byte("# generated\n-port\n  9090  \n\n@"+inner+"\n"), 0600)
+	cases := map[string]struct {
+		enabled       bool
+		args          []string
+		expectedPort  int
+		expectedVerb  bool
+		expectedArgs  []string
+		expectedError string
+	}{
+		"nested files": {
+			enabled:      true,
+			args:         []string{"@" + outer, "@@file", "--", "@" + outer},
+			expectedPort: 9090,
+			expectedVerb: true,
+			expectedArgs: []string{"@file", "--", "@" + outer},
+		},
+		"disabled": {
+			args:         []string{"@" + outer},
+			expectedPort: 8080,
+			expectedArgs: []string{"@" + outer},
+		},
+		"missing file": {
+			enabled:       true,
+			args:          []string{"@" + outer + ".missing"},
+			expectedError: "unable to read argument file: open " + outer + ".missing: no such file or directory",
+		},
+	}
+	for caseName, c := range cases {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.SetOutput(ioutil.Discard)
+		port := f.Int("port", 8080, "", "port")
+		verbose := f.Bool("verbose", false, "", "verbose")
+		f.SetArgFiles(c.enabled)
+		err := f.Parse(c.args)
+		if len(c.expectedError) != 0 {
+			if err == nil || err.Error() != c.expectedError {
+				t.Errorf("case %s: expected %q, got %v", caseName, c.expectedError, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %s: unexpected error: %s", caseName, err)
+			continue
+		}
+		if *port != c.expectedPort || *verbose != c.expectedVerb {
+			t.Errorf("case %s: got port %d and verbose %v", caseName, *port, *verbose)
+		}
+		if !reflect.DeepEqual(f.Args(), c.expectedArgs) {
+			t.Errorf("case %s: expected arguments %q, got %q", caseName, c.expectedArgs, f.Args())
+		}
+	}
+}
+
+func TestSetArgFiles_Cycle(t *testing.T) {
+	path, remove := testTempFile(t)
+	defer remove()
+	_ = ioutil.WriteFile(path, []byte("@"+path+"\n"), 0600)
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	f.SetArgFiles(true)
+	expected := "argument file " + path + " includes itself"
+	if err := f.Parse([]string{"@" + path}); err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}
@@ -0,0 +1,263 @@
+package flagfig
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// tagName is the struct tag read by Bind
+const tagName = "flagfig"
+
+// bindTag is a parsed flagfig struct tag
+type bindTag struct {
+	name       string
+	env        string
+	defaultSet bool
+	defaultVal string
+	usage      string
+	json       bool
+	opts       []FlagOption
+}
+
+// parseBindTag parses a tag such as "http-addr,env=MYAPP_HTTP_ADDR,default=:8080,usage=listen address". The usage is
+// the rest of the tag, whatever it holds. Otherwise, a part that does not start with a known key belongs to the part
+// before it, so defaults may contain commas
+func parseBindTag(tag string) (t bindTag) {
+	if strings.HasPrefix(tag, "usage=") {
+		tag = "," + tag
+	}
+	if dex := strings.Index(tag, ",usage="); dex != -1 {
+		tag, t.usage = tag[:dex], tag[dex+len(",usage="):]
+	}
+	parts := strings.Split(tag, ",")
+	t.name = parts[0]
+	last := ""
+	for _, part := range parts[1:] {
+		key, value := part, ""
+		if eq := strings.Index(part, "="); eq >= 0 {
+			key, value = part[:eq], part[eq+1:]
+		}
+		switch key {
+		case "env":
+			t.env = value
+		case "default":
+			t.defaultSet, t.defaultVal = true, value
+		case "short":
+			t.opts = append(t.opts, Short(value))
+		case "required":
+			t.opts = append(t.opts, Required())
+		case "sensitive":
+			t.opts = append(t.opts, Sensitive())
+		case "json":
+			t.json = true
+		default:
+			switch last {
+			case "env":
+				t.env += "," + part
+			case "default":
+				t.defaultVal += "," + part
+			}
+			continue
+		}
+		last = key
+	}
+	return
+}
+
+// Bind defines a CommandLine flag for each tagged field of the struct ptr points to
+func Bind(ptr interface{}) {
+	CommandLine.Bind(ptr)
+}
+
+// Bind defines a flag for each field of the struct ptr points to that has a flagfig tag, and wires the flag to the
+// field, so Parse sets the field directly:
+//
+//	type config struct {
+//		HTTPAddr string        `flagfig:"http-addr,env=MYAPP_HTTP_ADDR,default=:8080,usage=listen address"`
+//		Timeout  time.Duration `flagfig:"timeout,default=30s,usage=request timeout"`
+//		Token    string        `flagfig:"token,env=MYAPP_TOKEN,required,sensitive,usage=API token"`
+//	}
+//	cfg := &config{}
+//	flags.Bind(cfg)
+//
+// The tag starts with the flag's name, followed by any of env=, default= and short=, the words required and
+// sensitive, and finally usage=, which takes the rest of the tag, commas and all. Since a default may contain commas
+// too, it is best written just before the usage. A name of "-" skips the field.
+//
+// The values the fields already hold are the flags' defaults, so a constructor such as DefaultConfig() is the one
+// place defaults are written: flags.Bind(DefaultConfig()). The default in the tag is only used for fields holding
+// their zero value. Fields of type string, bool, int, int64, uint, uint64, float64, time.Duration, []string and
+// map[string]string become flags of those types, any other type, including time.Time, is read as JSON, like JSONVar,
+// unless AddDecodeHook was used.
+//
+// Fields holding a struct with a flagfig tag, or embedded structs without one, have their own fields bound, named
+// after the outer field: a Host field tagged "host,env=HOST" inside a field tagged "db" becomes the flag db.host,
+// set by the environment variable DB_HOST. The outer field's tag may set env= to change the variable's prefix. See
+// SetBindSeparators to join the names differently. Add the word json to the tag to read a struct field as a JSON
+// document instead.
+//
+// Bind panics if ptr is not a pointer to a struct, or a tagged field cannot be bound, as defining a flag twice does
+func (f *FlagfigSet) Bind(ptr interface{}) {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("flagfig: Bind needs a pointer to a struct, not %T", ptr))
+	}
+	f.bindStruct(v.Elem(), "", "", false)
+}
+
+func RegisterFields(ptr interface{}, prefix string) {
+	CommandLine.RegisterFields(ptr, prefix)
+}
+
+// RegisterFields is Bind for structs without tags, for quick prototyping: every exported field becomes a flag named
+// after the field, in lower case with dashes between the words, so MaxConns is -max-conns. A prefix, if not blank,
+// comes first, as in -db.max-conns. Nested structs are prefixed the same way. Fields that do have a flagfig tag are
+// bound as Bind does, so tags can be added one at a time
+func (f *FlagfigSet) RegisterFields(ptr interface{}, prefix string) {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("flagfig: RegisterFields needs a pointer to a struct, not %T", ptr))
+	}
+	if len(prefix) != 0 {
+		prefix += f.bindFlagSeparator
+	}
+	f.bindStruct(v.Elem(), prefix, "", true)
+}
+
+// bindStruct binds the fields of the struct v, prefixing the flag and environment variable names. With allFields,
+// exported fields without a tag are bound too, named after the field
+func (f *FlagfigSet) bindStruct(v reflect.Value, namePrefix, envPrefix string, allFields bool) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		fv := v.Field(i)
+		tag, ok := field.Tag.Lookup(tagName)
+		if !ok && field.Anonymous && fv.Kind() == reflect.Struct {
+			f.bindStruct(fv, namePrefix, envPrefix, allFields)
+			continue
+		}
+		if !ok && allFields && len(field.PkgPath) == 0 {
+			tag, ok = kebabCase(field.Name), true
+		}
+		if !ok || tag == "-" {
+			continue
+		}
+		if len(field.PkgPath) != 0 {
+			panic(fmt.Sprintf("flagfig: field %s of %s is not exported", field.Name, v.Type()))
+		}
+		t := parseBindTag(tag)
+		if len(t.name) == 0 {
+			panic(fmt.Sprintf("flagfig: field %s of %s has no flag name", field.Name, v.Type()))
+		}
+		name := namePrefix + t.name
+		env := t.env
+		if len(env) != 0 {
+			env = envPrefix + env
+		}
+		if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Time{}) && !t.json {
+			if len(env) == 0 {
+				env = envPrefix + strings.ToUpper(defaultEnvKeyReplacer.Replace(t.name))
+			}
+			f.bindStruct(fv, name+f.bindFlagSeparator, env+f.bindEnvSeparator, allFields)
+			continue
+		}
+		name = f.bindVar(fv.Addr().Interface(), name, envOptFromName(env), t.usage, t.opts...)
+		if t.defaultSet && fv.IsZero() {
+			f.setBoundDefault(name, t.defaultVal)
+		}
+	}
+}
+
+// kebabCase turns a Go name such as HTTPAddr into a flag name such as http-addr
+func kebabCase(name string) string {
+	runes := []rune(name)
+	sb := strings.Builder{}
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			previous := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextIsLower) {
+				sb.WriteRune('-')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
+
+func SetBindSeparators(flagSeparator, envSeparator string) {
+	CommandLine.SetBindSeparators(flagSeparator, envSeparator)
+}
+
+// SetBindSeparators sets what Bind puts between the names of nested structs and their fields: "." for flags, as in
+// db.host, and "_" for environment variables, as in DB_HOST, unless changed. Call it before Bind
+func (f *FlagfigSet) SetBindSeparators(flagSeparator, envSeparator string) {
+	f.bindFlagSeparator, f.bindEnvSeparator = flagSeparator, envSeparator
+}
+
+// bindVar defines a flag stored in p, with the value p already holds as its default, and returns its name
+func (f *FlagfigSet) bindVar(p interface{}, name string, env EnvOpt, usage string, opts ...FlagOption) string {
+	switch ptr := p.(type) {
+	case *string:
+		name = f.register(name, stringType, env)
+		f.FlagSet.StringVar(ptr, name, *ptr, usage)
+	case *bool:
+		name = f.register(name, boolType, env)
+		f.FlagSet.BoolVar(ptr, name, *ptr, usage)
+	case *int:
+		name = f.register(name, intType, env)
+		f.FlagSet.IntVar(ptr, name, *ptr, usage)
+	case *int64:
+		name = f.register(name, int64Type, env)
+		f.FlagSet.Int64Var(ptr, name, *ptr, usage)
+	case *uint:
+		name = f.register(name, uintType, env)
+		f.FlagSet.UintVar(ptr, name, *ptr, usage)
+	case *uint64:
+		name = f.register(name, uint64Type, env)
+		f.FlagSet.Uint64Var(ptr, name, *ptr, usage)
+	case *float64:
+		name = f.register(name, floatType, env)
+		f.FlagSet.Float64Var(ptr, name, *ptr, usage)
+	case *time.Duration:
+		name = f.register(name, durationType, env)
+		f.FlagSet.DurationVar(ptr, name, *ptr, usage)
+	case *[]string:
+		name = f.register(name, stringSliceType, env)
+		f.FlagSet.Var(newStringSliceValue(*ptr, ptr), name, usage)
+	case *map[string]string:
+		name = f.register(name, stringMapType, env)
+		f.FlagSet.Var(newStringMapValue(*ptr, ptr), name, usage)
+	default:
+		if len(f.decodeHooks) != 0 {
+			name = f.register(name, stringType, env)
+			f.FlagSet.Var(&hookValue{f: f, target: reflect.ValueOf(p).Elem()}, name, usage)
+			break
+		}
+		name = f.register(name, jsonType, env)
+		f.FlagSet.Var(&jsonValue{target: p}, name, usage)
+	}
+	f.applyOptions(name, opts)
+	return name
+}
+
+// setBoundDefault changes the default of a bound flag to the value written in its tag
+func (f *FlagfigSet) setBoundDefault(name, value string) {
+	fl := f.FlagSet.Lookup(name)
+	if err := fl.Value.Set(value); err != nil {
+		panic(fmt.Sprintf("flagfig: invalid default %q for flag -%s: %v", value, name, err))
+	}
+	fl.DefValue = fl.Value.String()
+	if m, ok := fl.Value.(defaulter); ok {
+		m.keepAsDefault()
+	}
+}
+
+// defaulter values remember their default, to return to it on reset
+type defaulter interface {
+	flag.Value
+	keepAsDefault()
+}
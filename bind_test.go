@@ -0,0 +1,219 @@
+package flagfig
+
+import (
+	"flag"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testBindConfig struct {
+	HTTPAddr string            `flagfig:"http-addr,env=ENV_BIND_HTTP_ADDR,default=:8080,usage=listen address"`
+	Verbose  bool              `flagfig:"verbose,short=v,usage=more output, for debugging"`
+	Workers  int               `flagfig:"workers,default=4"`
+	Timeout  time.Duration     `flagfig:"timeout,default=30s"`
+	Peers    []string          `flagfig:"peers,default=a,b"`
+	Labels   map[string]string `flagfig:"labels"`
+	Limits   testLimits        `flagfig:"limits,json"`
+	Token    string            `flagfig:"token,required,sensitive"`
+	Skipped  string            `flagfig:"-"`
+	Untagged string
+}
+
+func TestBind(t *testing.T) {
+	_ = os.Setenv("ENV_BIND_HTTP_ADDR", ":9090")
+	defer func() { _ = os.Unsetenv("ENV_BIND_HTTP_ADDR") }()
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	cfg := &testBindConfig{}
+	f.Bind(cfg)
+	err := f.Parse([]string{"-v", "-token=secret", "-peers=c", `-limits={"Rate":1}`, "-labels=x=1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := testBindConfig{
+		HTTPAddr: ":9090",
+		Verbose:  true,
+		Workers:  4,
+		Timeout:  30 * time.Second,
+		Peers:    []string{"c"},
+		Labels:   map[string]string{"x": "1"},
+		Limits:   testLimits{Rate: 1},
+		Token:    "secret",
+	}
+	if !reflect.DeepEqual(*cfg, expected) {
+		t.Errorf("expected %+v, got %+v", expected, *cfg)
+	}
+	if fl := f.Lookup("verbose"); fl == nil || fl.Usage != "more output, for debugging" {
+		t.Error("expected the usage to keep its comma, got ", fl)
+	}
+	if fl := f.Lookup("peers"); fl == nil || fl.DefValue != "a,b" {
+		t.Error("expected the default a,b, got ", fl)
+	}
+	if f.Lookup("Skipped") != nil || f.Lookup("Untagged") != nil || f.Lookup("-") != nil {
+		t.Error("expected fields without a flag name to be skipped")
+	}
+
+	f = NewFlagfigSet("test", flag.ContinueOnError)
+	f.Bind(&testBindConfig{})
+	if err = f.Parse([]string{}); err == nil || err.Error() != "missing required flags: -token" {
+		t.Error("expected -token to be required, got ", err)
+	}
+}
+
+func TestParseBindTag(t *testing.T) {
+	cases := map[string]struct {
+		tag          string
+		expectedEnv  string
+		expectedDef  string
+		expectedUse  string
+		expectedOpts int
+	}{
+		"comma in the usage": {
+			tag:         "host,env=HOST,usage=host, or IP address",
+			expectedEnv: "HOST",
+			expectedUse: "host, or IP address",
+		},
+		"option words in the usage": {
+			tag:         "token,usage=token, required,sensitive,short=t are not options here",
+			expectedUse: "token, required,sensitive,short=t are not options here",
+		},
+		"options before the usage": {
+			tag:          "token,required,sensitive,default=a,b,usage=API token",
+			expectedDef:  "a,b",
+			expectedUse:  "API token",
+			expectedOpts: 2,
+		},
+		"no usage": {
+			tag:         "peers,default=a,b",
+			expectedDef: "a,b",
+		},
+	}
+	for caseName, c := range cases {
+		tag := parseBindTag(c.tag)
+		if tag.env != c.expectedEnv || tag.defaultVal != c.expectedDef || tag.usage != c.expectedUse ||
+			len(tag.opts) != c.expectedOpts {
+			t.Errorf("case %s: got env=%q default=%q usage=%q and %d options", caseName, tag.env, tag.defaultVal,
+				tag.usage, len(tag.opts))
+		}
+	}
+}
+
+func TestBind_Panics(t *testing.T) {
+	cases := map[string]interface{}{
+		"not a pointer": testBindConfig{},
+		"unexported": &struct {
+			name string `flagfig:"name"`
+		}{},
+		"bad default": &struct {
+			Port int `flagfig:"port,default=http"`
+		}{},
+	}
+	for caseName, c := range cases {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("case %s: expected a panic", caseName)
+				}
+			}()
+			NewFlagfigSet("test", flag.ContinueOnError).Bind(c)
+		}()
+	}
+}
+
+func TestBind_FieldDefaults(t *testing.T) {
+	defaults := &testBindConfig{HTTPAddr: ":7070", Workers: 8, Peers: []string{"p"}, Token: "t"}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.Bind(defaults)
+	for name, expected := range map[string]string{"http-addr": ":7070", "workers": "8", "peers": "p", "timeout": "30s"} {
+		if fl := f.Lookup(name); fl.DefValue != expected {
+			t.Errorf("expected -%s to default to %q, got %q", name, expected, fl.DefValue)
+		}
+	}
+	if err := f.Parse([]string{"-peers=q", "-token=t"}); err != nil {
+		t.Fatal(err)
+	}
+	if defaults.HTTPAddr != ":7070" || defaults.Workers != 8 || !reflect.DeepEqual(defaults.Peers, []string{"q"}) {
+		t.Errorf("unexpected values %+v", *defaults)
+	}
+}
+
+func TestBind_Nested(t *testing.T) {
+	type db struct {
+		Host string `flagfig:"host,env=HOST,default=localhost"`
+		Port int    `flagfig:"port,env=PORT,default=5432"`
+	}
+	type common struct {
+		Debug bool `flagfig:"debug"`
+	}
+	type config struct {
+		common
+		Primary db `flagfig:"db"`
+		Replica db `flagfig:"replica,env=ENV_NESTED_RO"`
+	}
+	_ = os.Setenv("DB_HOST", "primary")
+	_ = os.Setenv("ENV_NESTED_RO_PORT", "6432")
+	defer func() {
+		_ = os.Unsetenv("DB_HOST")
+		_ = os.Unsetenv("ENV_NESTED_RO_PORT")
+	}()
+	cfg := &config{}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.Bind(cfg)
+	if err := f.Parse([]string{"-debug", "-replica.host=replica"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := config{
+		common:  common{Debug: true},
+		Primary: db{Host: "primary", Port: 5432},
+		Replica: db{Host: "replica", Port: 6432},
+	}
+	if *cfg != expected {
+		t.Errorf("expected %+v, got %+v", expected, *cfg)
+	}
+
+	f = NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetBindSeparators("-", "__")
+	f.Bind(&config{})
+	if f.Lookup("db-host") == nil || f.envNameFor("db-port") != "DB__PORT" {
+		t.Error("expected the names to be joined with the separators, got env ", f.envNameFor("db-port"))
+	}
+}
+
+func TestRegisterFields(t *testing.T) {
+	type db struct {
+		Host     string
+		MaxConns int
+	}
+	type config struct {
+		HTTPAddr string
+		UserID   string
+		Verbose  bool `flagfig:"v,usage=more output"`
+		Primary  db
+		Skipped  string `flagfig:"-"`
+		internal string
+	}
+	cfg := &config{HTTPAddr: ":8080", Primary: db{MaxConns: 4}}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.RegisterFields(cfg, "app")
+	names := make([]string, 0)
+	f.VisitAll(func(fl *flag.Flag) { names = append(names, fl.Name) })
+	expectedNames := []string{"app.http-addr", "app.primary.host", "app.primary.max-conns", "app.user-id", "app.v"}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("expected %q, got %q", expectedNames, names)
+	}
+	if err := f.Parse([]string{"-app.primary.max-conns=8", "-app.v"}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HTTPAddr != ":8080" || cfg.Primary.MaxConns != 8 || !cfg.Verbose {
+		t.Errorf("unexpected values %+v", *cfg)
+	}
+}
+
+func TestKebabCase(t *testing.T) {
+	for name, expected := range map[string]string{"HTTPAddr": "http-addr", "MaxConns": "max-conns", "ID": "id", "UserID": "user-id", "Port2": "port2", "V2Api": "v2-api"} {
+		if actual := kebabCase(name); actual != expected {
+			t.Errorf("expected %s to be %s, got %s", name, expected, actual)
+		}
+	}
+}
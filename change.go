@@ -0,0 +1,74 @@
+package flagfig
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Change is a flag whose value was changed by Reload, or through AdminHandler
+type Change struct {
+	Name     string
+	Old, New interface{}
+	// Source and SourceDetail are where the new value came from, as Origin reports them
+	Source       Source
+	SourceDetail string
+	Sensitive    bool
+}
+
+// String describes the change for a log, with Sensitive values redacted:
+//
+//	-limit changed from 10 to 20 (file (/etc/myapp/config.json))
+func (c Change) String() string {
+	old, updated := fmt.Sprint(c.Old), fmt.Sprint(c.New)
+	if c.Sensitive {
+		old, updated = redactedValue, redactedValue
+	}
+	return fmt.Sprintf("-%s changed from %s to %s (%s%s)", c.Name, old, updated, c.Source, traceDetail(c.SourceDetail))
+}
+
+func OnReload(hook func(f *FlagfigSet, changes []Change)) {
+	CommandLine.OnReload(hook)
+}
+
+// OnReload adds a hook that is told which flags changed, by name, each time Reload, Watch or AdminHandler changes any,
+// so that operators can audit what changed and the program can act on it:
+//
+//	flags.OnReload(func(f *flagfig.FlagfigSet, changes []flagfig.Change) {
+//		for _, c := range changes {
+//			audit.Printf("configuration: %s", c)
+//		}
+//	})
+//
+// Hooks run in the order they were added, after the OnParsed hooks, once the new values are in place
+func (f *FlagfigSet) OnReload(hook func(f *FlagfigSet, changes []Change)) {
+	f.reloadHooks = append(f.reloadHooks, hook)
+}
+
+// reportChanges tells the OnReload hooks how the configuration differs from before, if it does, and returns the changes
+func (f *FlagfigSet) reportChanges(before *Snapshot) []Change {
+	changes := diffSnapshots(before, f.Snapshot())
+	if len(changes) == 0 {
+		return nil
+	}
+	for _, hook := range f.reloadHooks {
+		hook(f, changes)
+	}
+	return changes
+}
+
+// diffSnapshots lists the flags whose values differ between the snapshots, by name
+func diffSnapshots(before, after *Snapshot) []Change {
+	changes := make([]Change, 0)
+	for name, v := range after.values {
+		old, ok := before.values[name]
+		if ok && reflect.DeepEqual(old, v) {
+			continue
+		}
+		source, detail := after.Origin(name)
+		changes = append(changes, Change{Name: name, Old: old, New: v, Source: source, SourceDetail: detail,
+			Sensitive: after.sensitive[name]})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
@@ -0,0 +1,57 @@
+package flagfig
+
+import (
+	"flag"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestOnReload(t *testing.T) {
+	path, cleanup := testTempFile(t)
+	defer cleanup()
+	write := func(content string) {
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{"limit": 10, "password": "old", "region": "eu"}`)
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.AddConfigLayer("base", path)
+	f.Int("limit", 1, "", "limit", Reloadable())
+	f.String("password", "", "", "password", Sensitive())
+	f.String("region", "", "", "region")
+	var got [][]string
+	f.OnReload(func(f *FlagfigSet, changes []Change) {
+		lines := make([]string, 0, len(changes))
+		for _, c := range changes {
+			lines = append(lines, c.String())
+		}
+		got = append(got, lines)
+	})
+	if err := f.Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	write(`{"limit": 20, "password": "new", "region": "eu"}`)
+	if err := f.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	write(`{"limit": 20, "password": "new", "region": "eu"}`)
+	if err := f.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.override(map[string]string{"limit": "30"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{
+		{
+			"-limit changed from 10 to 20 (file (base (" + path + ")))",
+			"-password changed from **** to **** (file (base (" + path + ")))",
+		},
+		{"-limit changed from 20 to 30 (flag (admin))"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,194 @@
+package flagfig
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// CollectErrors is an error handling for NewFlagfigSet, like flag.ContinueOnError, except that Parse and Collate do
+// not stop at the first problem. Invalid values and unknown flags on the command line, in the configuration files and
+// in the environment, and every failed validation, are gathered and returned together as ParseErrors, so a user can
+// fix them all in one go. They are printed with the usage, as ContinueOnError prints the first one:
+//
+//	flags := flagfig.NewFlagfigSet("myapp", flagfig.CollectErrors)
+//	...
+//	if err := flags.Parse(os.Args[1:]); err != nil {
+//		os.Exit(2)
+//	}
+//
+// The OnParsed hooks only run if there were no problems at all. -h and -help are still reported as flag.ErrHelp
+const CollectErrors = flag.PanicOnError + 1
+
+// ErrorHandling returns the error handling the set was made with, which may be CollectErrors
+func (f *FlagfigSet) ErrorHandling() flag.ErrorHandling {
+	if f.collectErrors {
+		return CollectErrors
+	}
+	return f.FlagSet.ErrorHandling()
+}
+
+// ParseErrors lists every problem found by a FlagfigSet made with CollectErrors, in the order they were found. Without
+// CollectErrors, it lists every invalid value in the configuration files, when there is more than one.
+// errors.Is and errors.As look through each of them
+type ParseErrors []error
+
+func (e ParseErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("%d problems with the configuration:", len(e)))
+	for _, err := range e {
+		sb.WriteString("\n  ")
+		sb.WriteString(err.Error())
+	}
+	return sb.String()
+}
+
+func (e ParseErrors) Is(target error) bool {
+	return joinedIs(e, target)
+}
+
+func (e ParseErrors) As(target interface{}) bool {
+	return joinedAs(e, target)
+}
+
+// problems gathers what goes wrong in Parse and Collate. Unless the set collects errors, the first one stops them
+type problems struct {
+	collect bool
+	errs    ParseErrors
+}
+
+func (f *FlagfigSet) newProblems() *problems {
+	return &problems{collect: f.collectErrors}
+}
+
+// add records err, returning nil to carry on when collecting errors, or err itself to stop
+func (p *problems) add(err error) error {
+	if err == nil || !p.collect {
+		return err
+	}
+	// Lists are flattened, so each problem is on its own line
+	var (
+		parseErrs  ParseErrors
+		validation *ValidationError
+	)
+	switch {
+	case errors.As(err, &parseErrs):
+		p.errs = append(p.errs, parseErrs...)
+	case errors.As(err, &validation):
+		for i, problem := range validation.Problems {
+			one := &ValidationError{Problems: []string{problem}}
+			if i == 0 {
+				// validate lists the missing flags first
+				one.Missing = validation.Missing
+			}
+			p.errs = append(p.errs, one)
+		}
+	default:
+		p.errs = append(p.errs, err)
+	}
+	return nil
+}
+
+// err is what was collected, nil if nothing went wrong
+func (p *problems) err() error {
+	if len(p.errs) == 0 {
+		return nil
+	}
+	return p.errs
+}
+
+// parseCommandLine parses the command line with the FlagSet. When collecting errors, invalid values are recorded
+// instead of stopping the parse, and so are unknown flags, after which the parse carries on with the next argument.
+// The problems are left for Collate to add its own to. Otherwise, only the invalid values of redacted flags are
+// recorded, as the flag package would quote them in its error, and the first one fails the parse
+func (f *FlagfigSet) parseCommandLine(args []string) (err error) {
+	p := f.newProblems()
+	secrets := &problems{collect: true}
+	wrapped := false
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		switch {
+		case p.collect:
+			fl.Value = &collectingValue{Value: fl.Value, f: f, name: fl.Name, problems: p}
+		case f.redacted(fl.Name):
+			fl.Value = &collectingValue{Value: fl.Value, f: f, name: fl.Name, problems: secrets}
+		default:
+			return
+		}
+		wrapped = true
+	})
+	if !wrapped {
+		return f.FlagSet.Parse(args)
+	}
+	unwrap := func() {
+		f.FlagSet.VisitAll(func(fl *flag.Flag) {
+			if c, ok := fl.Value.(*collectingValue); ok {
+				fl.Value = c.Value
+			}
+		})
+	}
+	// The flag package panics with PanicOnError, with the values still wrapped
+	defer unwrap()
+	if !p.collect {
+		err = f.FlagSet.Parse(args)
+		unwrap()
+		if err == nil && len(secrets.errs) != 0 {
+			err = f.failParse(secrets.errs[0])
+		}
+		return
+	}
+	// Usage is shown once, at the end, with the real values in place
+	usage := f.FlagSet.Usage
+	f.FlagSet.Usage = func() {}
+	err = f.FlagSet.Parse(args)
+	for err != nil && err != flag.ErrHelp {
+		_ = p.add(err)
+		err = f.FlagSet.Parse(f.FlagSet.Args())
+	}
+	f.FlagSet.Usage = usage
+	unwrap()
+	// The problems are reported, with the usage, once Collate has added its own
+	if err == flag.ErrHelp {
+		if f.FlagSet.Usage == nil {
+			f.defaultUsage()
+		} else {
+			f.FlagSet.Usage()
+		}
+	}
+	if err == nil {
+		f.collected = p
+	}
+	return err
+}
+
+// collectingValue stands in for a flag's value while the command line is parsed, recording the values it does not
+// accept
+type collectingValue struct {
+	flag.Value
+	f        *FlagfigSet
+	name     string
+	problems *problems
+}
+
+func (c *collectingValue) Set(val string) error {
+	if err := c.Value.Set(val); err != nil {
+		_ = c.problems.add(c.f.invalidValue(c.name, SourceFlag, "", val, err))
+	}
+	return nil
+}
+
+// String is blank for the zero value, which the flag package makes to tell whether a default is worth printing
+func (c *collectingValue) String() string {
+	if c.Value == nil {
+		return ""
+	}
+	return c.Value.String()
+}
+
+func (c *collectingValue) IsBoolFlag() bool {
+	b, ok := c.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
@@ -0,0 +1,95 @@
+package flagfig
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestCollectErrors(t *testing.T) {
+	path, cleanup := testTempFile(t)
+	defer cleanup()
+	if err := ioutil.WriteFile(path, []byte(`{"port": "eighty", "colour": "red"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Setenv("COLLECT_TIMEOUT", "soon")
+	defer func() { _ = os.Unsetenv("COLLECT_TIMEOUT") }()
+	cases := map[string]struct {
+		handling flag.ErrorHandling
+		args     []string
+		expected string
+	}{
+		"stops at the first": {
+			handling: flag.ContinueOnError,
+			args:     []string{"-retries=many"},
+			expected: `invalid value "many" for flag -retries: parse error`,
+		},
+		"collects every one": {
+			handling: CollectErrors,
+			args:     []string{"-retries=many", "-nope", "-debug=maybe"},
+			expected: `7 problems with the configuration:` +
+				"\n  " + `invalid value "many" for flag -retries from the command line: parse error` +
+				"\n  " + `flag provided but not defined: -nope` +
+				"\n  " + `invalid value "maybe" for flag -debug from the command line: parse error` +
+				"\n  " + `unknown keys in configuration file ` + path + `: colour` +
+				"\n  " + `invalid value "eighty" for key "port" in configuration file ` + path + `: parse error` +
+				"\n  " + `invalid value "soon" for flag -timeout from environment variable COLLECT_TIMEOUT: parse error` +
+				"\n  " + `missing required flags: -token`,
+		},
+	}
+	for caseName, c := range cases {
+		f := NewFlagfigSet("test", c.handling)
+		f.SetOutput(ioutil.Discard)
+		f.AddConfigLayer("", path)
+		f.SetStrictConfig(true)
+		f.Int("port", 0, "", "port")
+		f.Int("retries", 0, "", "retries")
+		f.Bool("debug", false, "", "debug")
+		f.Duration("timeout", 0, "COLLECT_TIMEOUT", "timeout")
+		f.String("token", "", "", "token", Required())
+		hookRan := false
+		f.OnParsed(func(*FlagfigSet) error {
+			hookRan = true
+			return nil
+		})
+		err := f.Parse(c.args)
+		if err == nil || err.Error() != c.expected {
+			t.Errorf("case %s: expected %q, got %v", caseName, c.expected, err)
+		}
+		if hookRan {
+			t.Errorf("case %s: expected the OnParsed hooks not to run", caseName)
+		}
+	}
+
+	f := NewFlagfigSet("test", CollectErrors)
+	f.SetOutput(ioutil.Discard)
+	f.String("token", "", "", "token", Required())
+	err := f.Parse([]string{})
+	var parseErrs ParseErrors
+	if !errors.As(err, &parseErrs) || !errors.Is(err, ErrMissingRequired) {
+		t.Errorf("expected ParseErrors that are ErrMissingRequired, got %#v", err)
+	}
+	if f.ErrorHandling() != CollectErrors {
+		t.Error("expected the error handling to be CollectErrors, got ", f.ErrorHandling())
+	}
+	if err = f.Parse([]string{"-token", "secret"}); err != nil {
+		t.Error("expected no problems, got ", err)
+	}
+
+	app := NewCommand("app", "does things", CollectErrors)
+	serve := NewCommand("serve", "runs the server", CollectErrors)
+	serve.Flags.Int("port", 8080, "", "port")
+	serve.Run = func(*Command, []string) error {
+		t.Error("expected serve not to run")
+		return nil
+	}
+	app.AddCommand(serve)
+	app.Flags.SetOutput(ioutil.Discard)
+	serve.Flags.SetOutput(ioutil.Discard)
+	err = app.Execute([]string{"serve", "-port=eighty", "-nope"})
+	if !errors.As(err, &parseErrs) || len(parseErrs) != 2 {
+		t.Errorf("expected both problems with the subcommand, got %v", err)
+	}
+}
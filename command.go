@@ -0,0 +1,208 @@
+package flagfig
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+)
+
+// inheritedGroup is the usage heading for the flags a Command inherits from its parents
+const inheritedGroup = "Global flags"
+
+// Command is one command of a program with subcommands, such as "serve" in "myapp serve -port=80". Each command has
+// its own flags, Nesters and subcommands, and inherits the flags of its parents, which may be given before or after
+// the command's name:
+//
+//	app := flagfig.NewCommand("myapp", "does things", flag.ExitOnError)
+//	verbose := app.Flags.Bool("verbose", false, "MYAPP_VERBOSE", "more output")
+//	serve := flagfig.NewCommand("serve", "runs the server", flag.ExitOnError)
+//	port := serve.Flags.Int("port", 8080, "MYAPP_PORT", "port to listen on")
+//	serve.Run = func(cmd *flagfig.Command, args []string) error {
+//		return listen(*port, *verbose)
+//	}
+//	app.AddCommand(serve)
+//	err := app.Execute(os.Args[1:])
+type Command struct {
+	// Name is what the command is called on the command line
+	Name string
+	// Description is the one line summary listed in the usage of the parent command
+	Description string
+	// Flags holds the command's own flags. Define them before Execute is called
+	Flags *FlagfigSet
+	// Nesters have their RegisterFlags called on Flags before parsing, and AfterParsed and Validate called once it
+	// succeeds
+	Nesters []Nester
+	// FindNested includes the Nesters held in the fields of the Nesters, as it does for ParseNestedWithOptions
+	FindNested bool
+	// Run is called with the positional arguments once the flags are parsed. A command with subcommands may leave it
+	// nil, making the subcommand required
+	Run func(cmd *Command, args []string) error
+
+	parent   *Command
+	commands []*Command
+	// nesters are the Nesters of the command and the ones they hold, once Execute has registered them
+	nesters []Nester
+}
+
+// NewCommand creates a command with an empty set of flags, which handles errors as errorHandling directs
+func NewCommand(name, description string, errorHandling flag.ErrorHandling) *Command {
+	c := &Command{Name: name, Description: description, Flags: NewFlagfigSet(name, errorHandling)}
+	c.Flags.FlagSet.Usage = c.usage
+	return c
+}
+
+// AddCommand adds subcommands to the command
+func (c *Command) AddCommand(commands ...*Command) {
+	for _, child := range commands {
+		child.parent = c
+		c.commands = append(c.commands, child)
+	}
+}
+
+// Parent is the command this one is a subcommand of, or nil
+func (c *Command) Parent() *Command {
+	return c.parent
+}
+
+// Path is the full name of the command, such as "myapp serve"
+func (c *Command) Path() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.Path() + " " + c.Name
+}
+
+// Lookup finds the subcommand with the given name, or returns nil
+func (c *Command) Lookup(name string) *Command {
+	for _, child := range c.commands {
+		if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// Execute parses the command's flags from args, then either runs the subcommand named by the first positional
+// argument, or this command's Run. The flags of a command with subcommands are validated, and its OnParsed hooks and
+// Nesters run, only once the command that runs has parsed, as the subcommand's arguments may still set them
+func (c *Command) Execute(args []string) (err error) {
+	c.nesters = c.Nesters
+	if c.FindNested {
+		c.nesters = expandNesters(c.nesters)
+	}
+	c.nesters, err = orderNesters(c.nesters)
+	if err != nil {
+		return
+	}
+	err = registerNesters(c.Flags, c.nesters)
+	if err != nil {
+		return
+	}
+	if c.parent != nil {
+		c.Flags.FlagSet.Init(c.Path(), c.Flags.FlagSet.ErrorHandling())
+		c.inherit()
+	}
+	c.Flags.deferFinish = len(c.commands) != 0
+	err = c.Flags.Parse(args)
+	if err != nil {
+		return
+	}
+	if c.parent != nil {
+		c.updateParents()
+	}
+	rest := c.Flags.Args()
+	if len(c.commands) != 0 && len(rest) != 0 {
+		if child := c.Lookup(rest[0]); child != nil {
+			return child.Execute(rest[1:])
+		}
+		if c.Run == nil {
+			return c.Flags.failParse(fmt.Errorf("unknown command %q for %s", rest[0], c.Path()))
+		}
+	}
+	err = c.finish()
+	if err != nil {
+		return
+	}
+	if c.Run == nil {
+		return c.Flags.failParse(errors.New("a command is required for " + c.Path()))
+	}
+	return c.Run(c, rest)
+}
+
+// finish validates the flags that Collate left for the command that runs, and runs the OnParsed hooks and Nesters,
+// from the top command down
+func (c *Command) finish() (err error) {
+	if c.parent != nil {
+		err = c.parent.finish()
+		if err != nil {
+			return
+		}
+	}
+	if c.Flags.deferFinish {
+		c.Flags.deferFinish = false
+		err = c.Flags.validate()
+		if err == nil {
+			err = c.Flags.finish()
+		}
+		if err != nil {
+			return c.Flags.failParse(err)
+		}
+	}
+	return afterParsed(c.nesters, false)
+}
+
+// inherit defines the flags of the parent commands in this command's set, sharing their values. The parent's set has
+// already resolved them, so Collate leaves them alone unless they are given on this command's command line
+func (c *Command) inherit() {
+	parent := c.parent.Flags
+	parent.FlagSet.VisitAll(func(fl *flag.Flag) {
+		if c.Flags.FlagSet.Lookup(fl.Name) != nil || parent.internalFlags[fl.Name] {
+			return
+		}
+		c.Flags.FlagSet.Var(fl.Value, fl.Name, fl.Usage)
+		c.Flags.flagTypes[fl.Name] = parent.flagTypes[fl.Name]
+		c.Flags.internalFlags[fl.Name] = true
+		c.Flags.sensitive[fl.Name] = parent.sensitive[fl.Name]
+		c.Flags.inherited[fl.Name] = parent
+		WithGroup(inheritedGroup)(c.Flags, fl.Name)
+	})
+}
+
+// updateParents records the inherited flags that were set on this command's command line in the sets that own them,
+// before they are validated by finish
+func (c *Command) updateParents() {
+	c.Flags.FlagSet.Visit(func(fl *flag.Flag) {
+		if parent, ok := c.Flags.inherited[fl.Name]; ok {
+			parent.origins[fl.Name] = origin{source: SourceFlag}
+		}
+	})
+}
+
+// usage prints the usage of the command: how it is called, its subcommands and its flags
+func (c *Command) usage() {
+	out := c.Flags.Output()
+	synopsis := "Usage: " + c.Path() + " [flags]"
+	if len(c.commands) != 0 {
+		synopsis += " <command> [arguments]"
+	} else if args := c.Flags.argsSynopsis(); len(args) != 0 {
+		synopsis += " " + args
+	}
+	_, _ = fmt.Fprintln(out, synopsis)
+	if len(c.Description) != 0 {
+		_, _ = fmt.Fprintf(out, "\n%s\n", c.Description)
+	}
+	if len(c.commands) != 0 {
+		_, _ = fmt.Fprintln(out, "\nCommands:")
+		commands := append([]*Command(nil), c.commands...)
+		sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+		w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+		for _, child := range commands {
+			_, _ = fmt.Fprintf(w, "  %s\t%s\n", child.Name, child.Description)
+		}
+		_ = w.Flush()
+	}
+	_, _ = fmt.Fprintln(out, "\nFlags:")
+	c.Flags.PrintDefaults()
+}
@@ -0,0 +1,198 @@
+package flagfig
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommand_Execute(t *testing.T) {
+	_ = os.Setenv("ENV_COMMAND_REGION", "eu")
+	defer func() { _ = os.Unsetenv("ENV_COMMAND_REGION") }()
+	cases := map[string]struct {
+		args           []string
+		expectedVerb   bool
+		expectedPort   int
+		expectedArgs   []string
+		expectedOrigin Source
+	}{
+		"parent flag before the command": {
+			args:           []string{"-verbose", "serve", "-port=9090", "extra"},
+			expectedVerb:   true,
+			expectedPort:   9090,
+			expectedArgs:   []string{"extra"},
+			expectedOrigin: SourceFlag,
+		},
+		"parent flag after the command": {
+			args:           []string{"serve", "-verbose"},
+			expectedVerb:   true,
+			expectedPort:   8080,
+			expectedArgs:   []string{},
+			expectedOrigin: SourceFlag,
+		},
+		"parent flag not set": {
+			args:           []string{"serve"},
+			expectedPort:   8080,
+			expectedArgs:   []string{},
+			expectedOrigin: SourceDefault,
+		},
+	}
+	for caseName, c := range cases {
+		app := NewCommand("app", "does things", flag.ContinueOnError)
+		verbose := app.Flags.Bool("verbose", false, "", "more output")
+		region := app.Flags.String("region", "us", "ENV_COMMAND_REGION", "region")
+		serve := NewCommand("serve", "runs the server", flag.ContinueOnError)
+		port := serve.Flags.Int("port", 8080, "", "port")
+		var ran []string
+		serve.Run = func(cmd *Command, args []string) error {
+			ran = args
+			return nil
+		}
+		app.AddCommand(serve)
+		if err := app.Execute(c.args); err != nil {
+			t.Errorf("case %s: unexpected error: %s", caseName, err)
+			continue
+		}
+		if ran == nil {
+			t.Errorf("case %s: serve did not run", caseName)
+			continue
+		}
+		if *verbose != c.expectedVerb || *port != c.expectedPort || *region != "eu" {
+			t.Errorf("case %s: got verbose=%v port=%d region=%s", caseName, *verbose, *port, *region)
+		}
+		if !reflect.DeepEqual(ran, c.expectedArgs) {
+			t.Errorf("case %s: expected args %q, got %q", caseName, c.expectedArgs, ran)
+		}
+		if source, _ := app.Flags.Origin("verbose"); source != c.expectedOrigin {
+			t.Errorf("case %s: expected verbose from %s, got %s", caseName, c.expectedOrigin, source)
+		}
+		if source, detail := serve.Flags.Origin("region"); source != SourceEnv || detail != "ENV_COMMAND_REGION" {
+			t.Errorf("case %s: expected region from the environment in serve, got %s %s", caseName, source, detail)
+		}
+	}
+}
+
+func TestCommand_RequiredParentFlag(t *testing.T) {
+	cases := map[string]struct {
+		args     []string
+		expected string
+	}{
+		"given before the command": {
+			args: []string{"-token=x", "serve"},
+		},
+		"given after the command": {
+			args: []string{"serve", "-token=x"},
+		},
+		"missing": {
+			args:     []string{"serve"},
+			expected: "missing required flags: -token",
+		},
+	}
+	for caseName, c := range cases {
+		app := NewCommand("app", "does things", flag.ContinueOnError)
+		token := app.Flags.String("token", "", "", "token", Required())
+		hookToken := ""
+		app.Flags.OnParsed(func(f *FlagfigSet) error {
+			hookToken = *token
+			return nil
+		})
+		serve := NewCommand("serve", "runs the server", flag.ContinueOnError)
+		ran := false
+		serve.Run = func(cmd *Command, args []string) error {
+			ran = true
+			return nil
+		}
+		app.AddCommand(serve)
+		err := app.Execute(c.args)
+		if len(c.expected) != 0 {
+			if err == nil || err.Error() != c.expected || ran {
+				t.Errorf("case %s: expected %q before serve ran, got %v", caseName, c.expected, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %s: unexpected error: %s", caseName, err)
+			continue
+		}
+		if !ran || hookToken != "x" {
+			t.Errorf("case %s: expected serve to run after the hooks saw -token=x, got ran=%v token=%q", caseName, ran,
+				hookToken)
+		}
+	}
+}
+
+func TestCommand_Nesters(t *testing.T) {
+	app := NewCommand("app", "", flag.ContinueOnError)
+	cfg := newMyConfig()
+	app.Nesters = []Nester{cfg}
+	app.Run = func(cmd *Command, args []string) error { return nil }
+	if err := app.Execute([]string{"-mySecretNumber=3"}); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if cfg.MySecretSquare != 9 {
+		t.Error("expected AfterParsed to run, got ", cfg.MySecretSquare)
+	}
+}
+
+func TestCommand_Errors(t *testing.T) {
+	cases := map[string]struct {
+		args     []string
+		expected string
+	}{
+		"unknown command": {
+			args:     []string{"deploy"},
+			expected: `unknown command "deploy" for app`,
+		},
+		"missing command": {
+			args:     []string{},
+			expected: "a command is required for app",
+		},
+	}
+	for caseName, c := range cases {
+		app := NewCommand("app", "does things", flag.ContinueOnError)
+		app.Flags.Bool("verbose", false, "", "more output")
+		out := &bytes.Buffer{}
+		app.Flags.SetOutput(out)
+		serve := NewCommand("serve", "runs the server", flag.ContinueOnError)
+		app.AddCommand(serve, NewCommand("migrate", "updates the database", flag.ContinueOnError))
+		err := app.Execute(c.args)
+		if err == nil || err.Error() != c.expected {
+			t.Errorf("case %s: expected %q, got %v", caseName, c.expected, err)
+		}
+		if !strings.Contains(out.String(), "Commands:\n  migrate  updates the database\n  serve    runs the server\n") {
+			t.Errorf("case %s: expected the commands in the usage, got:\n%s", caseName, out)
+		}
+	}
+}
+
+func TestCommand_Usage(t *testing.T) {
+	app := NewCommand("app", "", flag.ContinueOnError)
+	app.Flags.Bool("verbose", false, "", "more output")
+	serve := NewCommand("serve", "runs the server", flag.ContinueOnError)
+	serve.Flags.Int("port", 8080, "", "port")
+	out := &bytes.Buffer{}
+	serve.Flags.SetOutput(out)
+	app.AddCommand(serve)
+	err := app.Execute([]string{"serve", "-h"})
+	if err != flag.ErrHelp {
+		t.Fatal("expected ErrHelp, got ", err)
+	}
+	expected := `Usage: app serve [flags]
+
+runs the server
+
+Flags:
+  -port int
+    	port (default 8080)
+
+Global flags:
+  -verbose
+    	more output
+`
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out)
+	}
+}
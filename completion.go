@@ -0,0 +1,384 @@
+package flagfig
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// valueHint tells shell completions what kind of value a flag takes
+type valueHint struct {
+	// kind is "file", "dir" or blank
+	kind     string
+	patterns []string
+}
+
+// FilePath marks the flag as taking a file path, so shell completions complete file names. If patterns, such as
+// "*.json", are given, only the matching files are offered
+func FilePath(patterns ...string) FlagOption {
+	return func(f *FlagfigSet, name string) {
+		f.valueHints[name] = valueHint{kind: "file", patterns: patterns}
+	}
+}
+
+// DirPath marks the flag as taking a directory, so shell completions complete directory names
+func DirPath() FlagOption {
+	return func(f *FlagfigSet, name string) {
+		f.valueHints[name] = valueHint{kind: "dir"}
+	}
+}
+
+// valueHintFor is the flag's valueHint. The flags added by AddConfigFile always take a file
+func (f *FlagfigSet) valueHintFor(name string) valueHint {
+	if hint, ok := f.valueHints[name]; ok {
+		return hint
+	}
+	for _, layer := range f.configLayers {
+		if layer.flagName == name {
+			return valueHint{kind: "file"}
+		}
+	}
+	return valueHint{}
+}
+
+// isBoolFlag is true for flags that do not take a value on the command line, such as Bool
+func isBoolFlag(fl *flag.Flag) bool {
+	b, ok := fl.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// completionName is the program name to complete: the name of the FlagfigSet without any directories
+func (f *FlagfigSet) completionName() string {
+	return filepath.Base(f.Name())
+}
+
+// completionFunc is name turned into a shell function name
+func completionFunc(name string) string {
+	return "_" + strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name) + "_complete"
+}
+
+func GenBashCompletion(w io.Writer) error {
+	return CommandLine.GenBashCompletion(w)
+}
+
+// GenBashCompletion writes a bash completion script for the program, which completes flag names, the values of Enum
+// flags and the paths for FilePath and DirPath flags. Install it by sourcing it from .bashrc, or by saving it in
+// /etc/bash_completion.d. The name of the FlagfigSet is the command that is completed
+func (f *FlagfigSet) GenBashCompletion(w io.Writer) (err error) {
+	name := f.completionName()
+	fn := completionFunc(name)
+	sb := strings.Builder{}
+	sb.WriteString("# bash completion for " + name + "\n")
+	sb.WriteString(fn + "() {\n")
+	sb.WriteString("\tlocal cur prev\n")
+	sb.WriteString("\tcur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	sb.WriteString("\tprev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	sb.WriteString("\t# bash splits -flag=value into -flag, = and value\n")
+	sb.WriteString("\tif [[ \"$cur\" == \"=\" ]]; then\n\t\tcur=\"\"\n")
+	sb.WriteString("\telif [[ \"$prev\" == \"=\" && $COMP_CWORD -ge 2 ]]; then\n\t\tprev=\"${COMP_WORDS[COMP_CWORD-2]}\"\n\tfi\n")
+	sb.WriteString("\tprev=\"${prev#-}\"\n\tprev=\"${prev#-}\"\n")
+	sb.WriteString("\tcase \"$prev\" in\n")
+	names := make([]string, 0)
+	plain := make([]string, 0)
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		if f.hidden[fl.Name] {
+			return
+		}
+		info := f.flagInfo(fl)
+		names = append(names, f.commandLineNames(info)...)
+		if isBoolFlag(fl) {
+			return
+		}
+		// prev has had its dashes taken off
+		arm := strings.Join(bareNames(info), "|")
+		switch {
+		case len(info.Enum) != 0:
+			sb.WriteString("\t" + arm + ")\n")
+			sb.WriteString("\t\tCOMPREPLY=($(compgen -W " + shellQuote(strings.Join(info.Enum, " ")) + " -- \"$cur\"))\n")
+			sb.WriteString("\t\treturn 0\n\t\t;;\n")
+		case info.ValueHint == "dir":
+			sb.WriteString("\t" + arm + ")\n")
+			sb.WriteString("\t\tCOMPREPLY=($(compgen -d -- \"$cur\"))\n")
+			sb.WriteString("\t\treturn 0\n\t\t;;\n")
+		case info.ValueHint == "file":
+			sb.WriteString("\t" + arm + ")\n")
+			if len(info.FilePatterns) == 0 {
+				sb.WriteString("\t\tCOMPREPLY=($(compgen -f -- \"$cur\"))\n")
+			} else {
+				sb.WriteString("\t\tCOMPREPLY=($(compgen -d -- \"$cur\"))\n")
+				for _, pattern := range info.FilePatterns {
+					sb.WriteString("\t\tCOMPREPLY+=($(compgen -f -X " + shellQuote("!"+pattern) + " -- \"$cur\"))\n")
+				}
+			}
+			sb.WriteString("\t\treturn 0\n\t\t;;\n")
+		default:
+			plain = append(plain, arm)
+		}
+	})
+	if len(plain) != 0 {
+		sb.WriteString("\t" + strings.Join(plain, "|") + ")\n")
+		sb.WriteString("\t\t# a value without completions\n")
+		sb.WriteString("\t\treturn 0\n\t\t;;\n")
+	}
+	sb.WriteString("\tesac\n")
+	sb.WriteString("\tCOMPREPLY=($(compgen -W " + shellQuote(strings.Join(names, " ")) + " -- \"$cur\"))\n")
+	sb.WriteString("}\n")
+	sb.WriteString("complete -o default -F " + fn + " " + shellQuote(name) + "\n")
+	_, err = fmt.Fprint(w, sb.String())
+	return
+}
+
+// shellQuote quotes s for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+func GenZshCompletion(w io.Writer) error {
+	return CommandLine.GenZshCompletion(w)
+}
+
+// GenZshCompletion writes a zsh completion script for the program. Besides the flag names, it shows each flag's usage
+// as its description and completes the values of Enum, FilePath and DirPath flags. Save it as _name in a directory on
+// $fpath, or source it after compinit
+func (f *FlagfigSet) GenZshCompletion(w io.Writer) (err error) {
+	name := f.completionName()
+	fn := completionFunc(name)
+	sb := strings.Builder{}
+	sb.WriteString("#compdef " + name + "\n\n")
+	sb.WriteString(fn + "() {\n")
+	sb.WriteString("\t_arguments")
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		if f.hidden[fl.Name] {
+			return
+		}
+		info := f.flagInfo(fl)
+		for _, name := range bareNames(info) {
+			spec := f.dash(name)
+			switch {
+			case isBoolFlag(fl):
+			case f.gnuStyle && len(name) == 1:
+				// -n+ accepts the value in the same word, or as the next word
+				spec += "+"
+			default:
+				// -name= accepts the value after an equals sign, or as the next word
+				spec += "="
+			}
+			spec += "[" + zshEscape(firstLine(info.Usage)) + "]"
+			if !isBoolFlag(fl) {
+				message := info.Type
+				if len(message) == 0 {
+					message = "value"
+				}
+				spec += ":" + zshEscape(message) + ":" + zshAction(info)
+			}
+			sb.WriteString(" \\\n\t\t" + shellQuote(spec))
+		}
+	})
+	sb.WriteString("\n}\n\n")
+	sb.WriteString("compdef " + fn + " " + shellQuote(name) + "\n")
+	_, err = fmt.Fprint(w, sb.String())
+	return
+}
+
+// zshAction is how _arguments completes the flag's value
+func zshAction(info FlagInfo) string {
+	switch {
+	case len(info.Enum) != 0:
+		values := make([]string, len(info.Enum))
+		for i, v := range info.Enum {
+			values[i] = zshEscape(strings.Replace(v, " ", `\ `, -1))
+		}
+		return "(" + strings.Join(values, " ") + ")"
+	case info.ValueHint == "dir":
+		return "_files -/"
+	case info.ValueHint == "file" && len(info.FilePatterns) == 1:
+		return `_files -g "` + info.FilePatterns[0] + `"`
+	case info.ValueHint == "file" && len(info.FilePatterns) > 1:
+		return `_files -g "(` + strings.Join(info.FilePatterns, "|") + `)"`
+	case info.ValueHint == "file":
+		return "_files"
+	}
+	return " "
+}
+
+// zshEscape escapes the characters that _arguments gives a meaning to in descriptions
+func zshEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
+
+// firstLine is the first line of s, as multi-line usage does not fit in a completion menu
+func firstLine(s string) string {
+	if i := strings.Index(s, "\n"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+func GenFishCompletion(w io.Writer) error {
+	return CommandLine.GenFishCompletion(w)
+}
+
+// GenFishCompletion writes a fish completion script for the program, with each flag's usage as its description and
+// completions for the values of Enum, FilePath and DirPath flags. Save it as name.fish in
+// ~/.config/fish/completions
+func (f *FlagfigSet) GenFishCompletion(w io.Writer) (err error) {
+	name := f.completionName()
+	sb := strings.Builder{}
+	sb.WriteString("# fish completion for " + name + "\n")
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		if f.hidden[fl.Name] {
+			return
+		}
+		info := f.flagInfo(fl)
+		sb.WriteString("complete -c " + shellQuote(name))
+		for _, flagName := range bareNames(info) {
+			// -o is a long option with a single dash, like the flag package uses, -l one with two and -s one letter
+			switch {
+			case !f.gnuStyle:
+				sb.WriteString(" -o ")
+			case len(flagName) == 1:
+				sb.WriteString(" -s ")
+			default:
+				sb.WriteString(" -l ")
+			}
+			sb.WriteString(shellQuote(flagName))
+		}
+		if usage := firstLine(info.Usage); len(usage) != 0 {
+			sb.WriteString(" -d " + shellQuote(usage))
+		}
+		if !isBoolFlag(fl) {
+			sb.WriteString(" " + fishValue(info))
+		}
+		sb.WriteString("\n")
+	})
+	_, err = fmt.Fprint(w, sb.String())
+	return
+}
+
+// fishValue is how fish completes the flag's value. -x takes a value and offers no files, -r -F takes a file
+func fishValue(info FlagInfo) string {
+	switch {
+	case len(info.Enum) != 0:
+		return "-x -a " + shellQuote(strings.Join(info.Enum, " "))
+	case info.ValueHint == "dir":
+		return "-x -a '(__fish_complete_directories)'"
+	case info.ValueHint == "file" && len(info.FilePatterns) != 0:
+		suffixes := make([]string, 0, len(info.FilePatterns))
+		for _, pattern := range info.FilePatterns {
+			if !strings.HasPrefix(pattern, "*.") || strings.ContainsAny(pattern[1:], "*?[") {
+				// fish can only filter by suffix, so offer every file
+				return "-r -F"
+			}
+			suffixes = append(suffixes, "(__fish_complete_suffix "+pattern[1:]+")")
+		}
+		return "-x -a " + shellQuote(strings.Join(suffixes, " "))
+	case info.ValueHint == "file":
+		return "-r -F"
+	}
+	return "-x"
+}
+
+func GenPowerShellCompletion(w io.Writer) error {
+	return CommandLine.GenPowerShellCompletion(w)
+}
+
+// GenPowerShellCompletion writes a PowerShell script that registers an argument completer for the program. It
+// completes flag names, with their usage as the tooltip, and the values of Enum, FilePath and DirPath flags.
+// Load it from your PowerShell profile
+func (f *FlagfigSet) GenPowerShellCompletion(w io.Writer) (err error) {
+	name := f.completionName()
+	sb := strings.Builder{}
+	sb.WriteString("# PowerShell completion for " + name + "\n")
+	sb.WriteString("Register-ArgumentCompleter -Native -CommandName " + powerShellQuote(name) + " -ScriptBlock {\n")
+	sb.WriteString("\tparam($wordToComplete, $commandAst, $cursorPosition)\n")
+	sb.WriteString("\t$flags = [ordered]@{\n")
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		if f.hidden[fl.Name] {
+			return
+		}
+		info := f.flagInfo(fl)
+		hint := info.ValueHint
+		if isBoolFlag(fl) {
+			hint = "bool"
+		}
+		// Each name has an entry, so the value of an alias is completed too
+		for _, flagName := range bareNames(info) {
+			sb.WriteString(fmt.Sprintf("\t\t%s = @{ Name = %s; Description = %s; Hint = %s; Values = %s; Patterns = %s }\n",
+				powerShellQuote(flagName), powerShellQuote(f.dash(flagName)), powerShellQuote(firstLine(info.Usage)),
+				powerShellQuote(hint), powerShellArray(info.Enum), powerShellArray(info.FilePatterns)))
+		}
+	})
+	sb.WriteString("\t}\n")
+	sb.WriteString(powerShellCompleterBody)
+	sb.WriteString("}\n")
+	_, err = fmt.Fprint(w, sb.String())
+	return
+}
+
+// powerShellCompleterBody completes the word using the $flags table written by GenPowerShellCompletion
+const powerShellCompleterBody = `	$words = @($commandAst.CommandElements | Where-Object { $_.Extent.EndOffset -lt $cursorPosition } | ForEach-Object { $_.ToString() })
+	$prev = if ($words.Count -gt 1) { $words[-1] } else { '' }
+	$flag = $null
+	$prefix = ''
+	$value = $wordToComplete
+	if ($wordToComplete -match '^(--?[^=]+=)(.*)$') {
+		$prefix = $Matches[1]
+		$value = $Matches[2]
+		$flag = $flags[($prefix -replace '^--?|=$', '')]
+	} elseif ($prev -match '^--?([^=]+)$' -and $flags.Contains($Matches[1]) -and $flags[$Matches[1]].Hint -ne 'bool') {
+		$flag = $flags[$Matches[1]]
+	}
+	if ($flag) {
+		$candidates = @()
+		if ($flag.Values.Count -gt 0) {
+			$candidates = @($flag.Values | Where-Object { $_ -like "$value*" })
+		} elseif ($flag.Hint -eq 'file' -or $flag.Hint -eq 'dir') {
+			$parent = if ($value) { Split-Path -Path $value -Parent } else { '' }
+			foreach ($item in Get-ChildItem -Path "$value*" -ErrorAction SilentlyContinue) {
+				$ok = $item.PSIsContainer
+				if (-not $ok -and $flag.Hint -eq 'file') {
+					$ok = $flag.Patterns.Count -eq 0
+					foreach ($pattern in $flag.Patterns) {
+						if ($item.Name -like $pattern) { $ok = $true }
+					}
+				}
+				if ($ok) {
+					$path = if ($parent) { Join-Path $parent $item.Name } else { $item.Name }
+					$candidates += $path
+				}
+			}
+		}
+		foreach ($candidate in $candidates) {
+			[System.Management.Automation.CompletionResult]::new("$prefix$candidate", $candidate, 'ParameterValue', $candidate)
+		}
+		return
+	}
+	foreach ($flag in $flags.Values) {
+		if ($flag.Name -like "$wordToComplete*") {
+			$description = $flag.Description
+			if (-not $description) { $description = $flag.Name }
+			[System.Management.Automation.CompletionResult]::new($flag.Name, $flag.Name, 'ParameterName', $description)
+		}
+	}
+`
+
+// powerShellQuote quotes s as a PowerShell string literal
+func powerShellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// powerShellArray writes values as a PowerShell array literal
+func powerShellArray(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = powerShellQuote(v)
+	}
+	return "@(" + strings.Join(quoted, ", ") + ")"
+}
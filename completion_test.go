@@ -0,0 +1,193 @@
+package flagfig
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func newCompletionTestSet() *FlagfigSet {
+	f := NewFlagfigSet("/usr/bin/my-app", flag.ContinueOnError)
+	f.AddConfigFile("config", "configuration file")
+	f.String("level", "info", "", "log level", Enum("debug", "info"))
+	f.String("data", "", "", "data directory", DirPath())
+	f.String("cert", "", "", "certificate", FilePath("*.pem"))
+	f.String("host", "", "", "host")
+	f.Bool("v", false, "", "verbose")
+	return f
+}
+
+func TestGenBashCompletion(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := newCompletionTestSet().GenBashCompletion(out)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	expected := `# bash completion for my-app
+_my_app_complete() {
+	local cur prev
+	cur="${COMP_WORDS[COMP_CWORD]}"
+	prev="${COMP_WORDS[COMP_CWORD-1]}"
+	# bash splits -flag=value into -flag, = and value
+	if [[ "$cur" == "=" ]]; then
+		cur=""
+	elif [[ "$prev" == "=" && $COMP_CWORD -ge 2 ]]; then
+		prev="${COMP_WORDS[COMP_CWORD-2]}"
+	fi
+	prev="${prev#-}"
+	prev="${prev#-}"
+	case "$prev" in
+	cert)
+		COMPREPLY=($(compgen -d -- "$cur"))
+		COMPREPLY+=($(compgen -f -X '!*.pem' -- "$cur"))
+		return 0
+		;;
+	config)
+		COMPREPLY=($(compgen -f -- "$cur"))
+		return 0
+		;;
+	data)
+		COMPREPLY=($(compgen -d -- "$cur"))
+		return 0
+		;;
+	level)
+		COMPREPLY=($(compgen -W 'debug info' -- "$cur"))
+		return 0
+		;;
+	host)
+		# a value without completions
+		return 0
+		;;
+	esac
+	COMPREPLY=($(compgen -W '-cert -config -data -host -level -v' -- "$cur"))
+}
+complete -o default -F _my_app_complete 'my-app'
+`
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestGenZshCompletion(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := newCompletionTestSet().GenZshCompletion(out)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	expected := `#compdef my-app
+
+_my_app_complete() {
+	_arguments \
+		'-cert=[certificate]:string:_files -g "*.pem"' \
+		'-config=[configuration file]:string:_files' \
+		'-data=[data directory]:string:_files -/' \
+		'-host=[host]:string: ' \
+		'-level=[log level]:string:(debug info)' \
+		'-v[verbose]'
+}
+
+compdef _my_app_complete 'my-app'
+`
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestGenFishCompletion(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := newCompletionTestSet().GenFishCompletion(out)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	expected := `# fish completion for my-app
+complete -c 'my-app' -o 'cert' -d 'certificate' -x -a '(__fish_complete_suffix .pem)'
+complete -c 'my-app' -o 'config' -d 'configuration file' -r -F
+complete -c 'my-app' -o 'data' -d 'data directory' -x -a '(__fish_complete_directories)'
+complete -c 'my-app' -o 'host' -d 'host' -x
+complete -c 'my-app' -o 'level' -d 'log level' -x -a 'debug info'
+complete -c 'my-app' -o 'v' -d 'verbose'
+`
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestGenPowerShellCompletion(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := newCompletionTestSet().GenPowerShellCompletion(out)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	expected := "# PowerShell completion for my-app\n" +
+		"Register-ArgumentCompleter -Native -CommandName 'my-app' -ScriptBlock {\n" +
+		"\tparam($wordToComplete, $commandAst, $cursorPosition)\n" +
+		"\t$flags = [ordered]@{\n" +
+		"\t\t'cert' = @{ Name = '-cert'; Description = 'certificate'; Hint = 'file'; Values = @(); Patterns = @('*.pem') }\n" +
+		"\t\t'config' = @{ Name = '-config'; Description = 'configuration file'; Hint = 'file'; Values = @(); Patterns = @() }\n" +
+		"\t\t'data' = @{ Name = '-data'; Description = 'data directory'; Hint = 'dir'; Values = @(); Patterns = @() }\n" +
+		"\t\t'host' = @{ Name = '-host'; Description = 'host'; Hint = ''; Values = @(); Patterns = @() }\n" +
+		"\t\t'level' = @{ Name = '-level'; Description = 'log level'; Hint = ''; Values = @('debug', 'info'); Patterns = @() }\n" +
+		"\t\t'v' = @{ Name = '-v'; Description = 'verbose'; Hint = 'bool'; Values = @(); Patterns = @() }\n" +
+		"\t}\n" +
+		powerShellCompleterBody +
+		"}\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestGenCompletionGNUStyle(t *testing.T) {
+	f := NewFlagfigSet("my-app", flag.ContinueOnError)
+	f.SetGNUStyle(true)
+	f.String("level", "info", "", "log level", Enum("debug", "info"), Short("l"))
+	f.Bool("verbose", false, "", "verbose", Short("v"))
+	cases := map[string]struct {
+		gen      func(w *bytes.Buffer) error
+		expected []string
+	}{
+		"bash": {
+			gen: func(w *bytes.Buffer) error { return f.GenBashCompletion(w) },
+			expected: []string{
+				"\tl|level)\n",
+				"compgen -W '-l --level -v --verbose'",
+			},
+		},
+		"zsh": {
+			gen: func(w *bytes.Buffer) error { return f.GenZshCompletion(w) },
+			expected: []string{
+				`'-l+[log level]:string:(debug info)'`,
+				`'--level=[log level]:string:(debug info)'`,
+				`'-v[verbose]'`,
+				`'--verbose[verbose]'`,
+			},
+		},
+		"fish": {
+			gen: func(w *bytes.Buffer) error { return f.GenFishCompletion(w) },
+			expected: []string{
+				"complete -c 'my-app' -s 'l' -l 'level' -d 'log level'",
+				"complete -c 'my-app' -s 'v' -l 'verbose' -d 'verbose'\n",
+			},
+		},
+		"PowerShell": {
+			gen: func(w *bytes.Buffer) error { return f.GenPowerShellCompletion(w) },
+			expected: []string{
+				"'l' = @{ Name = '-l'; Description = 'log level'",
+				"'level' = @{ Name = '--level'; Description = 'log level'",
+				"'v' = @{ Name = '-v'; Description = 'verbose'",
+				"'verbose' = @{ Name = '--verbose'; Description = 'verbose'",
+			},
+		},
+	}
+	for caseName, c := range cases {
+		out := &bytes.Buffer{}
+		if err := c.gen(out); err != nil {
+			t.Fatalf("case %s: unexpected error: %s", caseName, err)
+		}
+		for _, expected := range c.expected {
+			if !strings.Contains(out.String(), expected) {
+				t.Errorf("case %s: expected %q in:\n%s", caseName, expected, out.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package flagfig
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func SetConfigCache(dir string) {
+	CommandLine.SetConfigCache(dir)
+}
+
+// SetConfigCache keeps a copy of each configuration file that is read and decoded without error in dir, and falls back
+// to that copy when the file cannot be read or decoded later, at startup or on Reload. This keeps a service bootable
+// while its configuration is out of reach, say on a network share that is down, or half written by a deployment.
+// Falling back is logged. A file that is missing or empty is only replaced by its copy if its layer requires it, see
+// SetMissingConfig, as one that may be left out may have been removed on purpose. The directory is created if needed,
+// and blank, the default, turns caching off
+func (f *FlagfigSet) SetConfigCache(dir string) {
+	f.configCache = dir
+}
+
+// loadConfigFile reads and decodes the configuration file, falling back to the cached copy as SetConfigCache describes,
+// given what its layer does when it is missing. A file that does not exist is reported as ErrConfigFileNotFound, and
+// one that cannot be read for any other reason as a *configReadError
+func (f *FlagfigSet) loadConfigFile(path string, missing MissingConfig) (doc map[string]interface{}, err error) {
+	dat, readErr := ioutil.ReadFile(path)
+	err = readErr
+	if err == nil && len(bytes.TrimSpace(dat)) == 0 {
+		err = errEmptyConfig
+	}
+	if err == nil {
+		doc, err = decodeConfig(path, dat)
+	}
+	if err == nil {
+		f.saveCachedConfig(path, dat)
+		return doc, nil
+	}
+	// A layer that may do without its file is left without it, rather than brought back from the cache
+	fallBack := missing == RequireFile || !(os.IsNotExist(readErr) || err == errEmptyConfig)
+	if cached, cacheErr := f.cachedConfig(path); fallBack && cacheErr == nil {
+		if doc, cacheErr = decodeConfig(path, cached); cacheErr == nil {
+			f.logf("Using the cached copy of file: '%s' because: %s", path, err)
+			return doc, nil
+		}
+	}
+	if os.IsNotExist(readErr) {
+		return nil, errorOfKind(ErrConfigFileNotFound, "configuration file %s not found", path)
+	}
+	if readErr != nil {
+		return nil, &configReadError{path: path, err: readErr}
+	}
+	return nil, err
+}
+
+// configReadError is a configuration file that is there, but cannot be read, such as for lack of permission
+type configReadError struct {
+	path string
+	err  error
+}
+
+func (e *configReadError) Error() string {
+	return fmt.Sprintf("configuration file %s cannot be read: %v", e.path, e.err)
+}
+
+func (e *configReadError) Unwrap() error {
+	return e.err
+}
+
+// cachePath is where the cached copy of the configuration file is kept
+func (f *FlagfigSet) cachePath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	sum := sha256.Sum256([]byte(path))
+	return filepath.Join(f.configCache, hex.EncodeToString(sum[:16])+filepath.Ext(path))
+}
+
+// cachedConfig is the cached copy of the configuration file
+func (f *FlagfigSet) cachedConfig(path string) ([]byte, error) {
+	if len(f.configCache) == 0 {
+		return nil, os.ErrNotExist
+	}
+	return ioutil.ReadFile(f.cachePath(path))
+}
+
+// saveCachedConfig saves a copy of a configuration file that was decoded without error. Caching is best effort, so
+// failures are only logged
+func (f *FlagfigSet) saveCachedConfig(path string, dat []byte) {
+	if len(f.configCache) == 0 {
+		return
+	}
+	err := os.MkdirAll(f.configCache, 0700)
+	if err == nil {
+		// Written aside and renamed, so a crash never leaves half a copy
+		tmp := f.cachePath(path) + ".tmp"
+		if err = ioutil.WriteFile(tmp, dat, 0600); err == nil {
+			err = os.Rename(tmp, f.cachePath(path))
+		}
+	}
+	if err != nil {
+		f.logf("Unable to cache file: '%s' because: %s", path, err)
+	}
+}
+
+// configReadable is nil if the configuration file, or its cached copy, can be read
+func (f *FlagfigSet) configReadable(path string) error {
+	_, err := os.Stat(path)
+	if err != nil {
+		if _, cacheErr := f.cachedConfig(path); cacheErr == nil {
+			return nil
+		}
+	}
+	return err
+}
@@ -0,0 +1,50 @@
+package flagfig
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestSetConfigCache(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+	dir, err := ioutil.TempDir("", "flagfig-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	path, cleanup := testTempFile(t)
+	defer cleanup()
+	if err = ioutil.WriteFile(path, []byte(`{"host": "good.example.com"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	parse := func(missing MissingConfig) (string, error) {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.SetConfigCache(dir)
+		f.AddConfigLayer("base", path)
+		f.SetMissingConfig("base", missing)
+		host := f.String("host", "localhost", "", "host")
+		err := f.Parse([]string{})
+		return *host, err
+	}
+	if host, err := parse(RequireFile); err != nil || host != "good.example.com" {
+		t.Fatalf("expected the file to be read, got %s and %v", host, err)
+	}
+
+	cases := map[string]func(){
+		"half written": func() { _ = ioutil.WriteFile(path, []byte(`{"host": "bad`), 0600) },
+		"missing":      func() { _ = os.Remove(path) },
+	}
+	for _, caseName := range []string{"half written", "missing"} {
+		cases[caseName]()
+		if host, err := parse(RequireFile); err != nil || host != "good.example.com" {
+			t.Errorf("case %s: expected the cached copy, got %s and %v", caseName, host, err)
+		}
+	}
+	if host, err := parse(IgnoreMissing); err != nil || host != "localhost" {
+		t.Errorf("expected an optional file that was removed to stay removed, got %s and %v", host, err)
+	}
+}
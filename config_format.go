@@ -0,0 +1,66 @@
+package flagfig
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+)
+
+// ConfigFormat is a configuration file format
+type ConfigFormat int
+
+const (
+	// ConfigJSON is JSON. Comments, written as // or /* */, are allowed
+	ConfigJSON ConfigFormat = iota
+	// ConfigYAML is YAML, for files named *.yaml or *.yml. See parseYAML for the parts of YAML that are supported
+	ConfigYAML
+)
+
+// configFormatFor picks the format of a configuration file by its extension. Anything that is not YAML is JSON
+func configFormatFor(path string) ConfigFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return ConfigYAML
+	}
+	return ConfigJSON
+}
+
+// decodeConfig decodes the contents of the configuration file at path
+func decodeConfig(path string, dat []byte) (doc map[string]interface{}, err error) {
+	if configFormatFor(path) == ConfigYAML {
+		return parseYAML(dat)
+	}
+	err = json.Unmarshal(stripJSONComments(dat), &doc)
+	return
+}
+
+// stripJSONComments blanks out // and /* */ comments outside of strings. Newlines are kept, so the line numbers in
+// errors stay right
+func stripJSONComments(dat []byte) []byte {
+	out := make([]byte, len(dat))
+	copy(out, dat)
+	inString := false
+	for i := 0; i < len(out); i++ {
+		switch {
+		case inString && out[i] == '\\':
+			i++
+		case out[i] == '"':
+			inString = !inString
+		case !inString && out[i] == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case !inString && out[i] == '/' && i+1 < len(out) && out[i+1] == '*':
+			for ; i < len(out) && !(out[i] == '*' && i+1 < len(out) && out[i+1] == '/'); i++ {
+				if out[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+			for j := i; j < i+2 && j < len(out); j++ {
+				out[j] = ' '
+			}
+			i++
+		}
+	}
+	return out
+}
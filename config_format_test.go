@@ -0,0 +1,39 @@
+package flagfig
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestStripJSONComments(t *testing.T) {
+	in := "{\n  // comment\n  \"a\": \"http://x\", /* block\n comment */ \"b\": 1\n}"
+	expected := "{\n            \n  \"a\": \"http://x\",         \n            \"b\": 1\n}"
+	if out := string(stripJSONComments([]byte(in))); out != expected {
+		t.Errorf("expected %q, got %q", expected, out)
+	}
+}
+
+func TestYAMLConfigFile(t *testing.T) {
+	name, cleanup := testTempFile(t)
+	defer cleanup()
+	path := name + ".yaml"
+	defer func() { _ = os.Remove(path) }()
+	err := ioutil.WriteFile(path, []byte("host: example.com\nport: 9090\npeers:\n  - a\n  - b\nversion: 1.10\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.AddConfigLayer("base", path)
+	host := f.String("host", "", "", "host")
+	port := f.Int("port", 0, "", "port")
+	peers := f.StringSlice("peers", nil, "", "peers")
+	version := f.String("version", "", "", "version")
+	if err = f.Parse([]string{}); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if *host != "example.com" || *port != 9090 || len(*peers) != 2 || *version != "1.10" {
+		t.Error("unexpected values: ", *host, *port, *peers, *version)
+	}
+}
@@ -1,5 +1,11 @@
 package flagfig
 
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
 // ConfigurableConfig allows for individual configurations to be renamed as needed
 // When creating configurations, structure them with a function to "RegisterFlags" that occurs before the call to Parse, but after the New function is called. This way, you can allow implementors of your configurations to rename the flags that will be used while providing smart defaults
 // Here's a quick example that creates a custom configuration with a value field named "value1" that allows an implementer to change its name
@@ -65,6 +71,14 @@ type ConfigurableConfig struct {
 	FlagName string
 	// EnvName is the environment name for this value
 	EnvName string
+	// Default is the default value, written as it would be on the command line, or blank for the zero value
+	Default string
+	// Usage is the usage message of the flag
+	Usage string
+	// Hidden leaves the flag out of usage, as the Hidden option does
+	Hidden bool
+	// ConfigKey is the configuration file key for this value, when it should differ from FlagName. Blank uses FlagName
+	ConfigKey string
 }
 
 // NewConfigurableConfig convenience method for making ConfigurableConfig's
@@ -74,3 +88,125 @@ func NewConfigurableConfig(flagName, envName string) ConfigurableConfig {
 		EnvName:  envName,
 	}
 }
+
+// configurableConfigType is the type ConfigurableConfigsFromTags fills in
+var configurableConfigType = reflect.TypeOf(ConfigurableConfig{})
+
+// ConfigurableConfigsFromTags fills in the ConfigurableConfig fields of the struct ptr points to from their flagfig
+// tags, which are written as for Bind, so New functions need not spell out every name:
+//
+//	type myConfig struct {
+//		NesterBase
+//		MyCoolString     *string
+//		MyCoolStringConf ConfigurableConfig `flagfig:"myCoolString,env=COOL_STRING"`
+//	}
+//
+//	func newMyConfig() *myConfig {
+//		c := &myConfig{}
+//		flagfig.ConfigurableConfigsFromTags(c)
+//		return c
+//	}
+//
+// Only blank names, defaults and usages are filled in, so those given before the call are kept, and callers may still
+// rename the fields afterwards. It panics if ptr is not a pointer to a struct
+func ConfigurableConfigsFromTags(ptr interface{}) {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("flagfig: ConfigurableConfigsFromTags needs a pointer to a struct, not %T", ptr))
+	}
+	v = v.Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		tag, ok := field.Tag.Lookup(tagName)
+		if !ok || field.Type != configurableConfigType || len(field.PkgPath) != 0 {
+			continue
+		}
+		t := parseBindTag(tag)
+		c := v.Field(i).Addr().Interface().(*ConfigurableConfig)
+		if len(c.FlagName) == 0 {
+			c.FlagName = t.name
+		}
+		if len(c.EnvName) == 0 {
+			c.EnvName = t.env
+		}
+		if len(c.Default) == 0 {
+			c.Default = t.defaultVal
+		}
+		if len(c.Usage) == 0 {
+			c.Usage = t.usage
+		}
+	}
+}
+
+// options are the FlagOptions that apply the rest of the descriptor once the flag is defined, followed by opts
+func (c ConfigurableConfig) options(opts []FlagOption) []FlagOption {
+	apply := func(f *FlagfigSet, name string) {
+		if len(c.Default) != 0 {
+			f.setBoundDefault(name, c.Default)
+		}
+		if c.Hidden {
+			f.hidden[name] = true
+		}
+		if len(c.ConfigKey) != 0 && c.ConfigKey != c.FlagName {
+			f.AliasConfigKey(name, f.definePrefix+c.ConfigKey)
+		}
+	}
+	return append([]FlagOption{apply}, opts...)
+}
+
+// String defines a string flag in flags as the descriptor describes it, so that RegisterFlags can be:
+//
+//	func (c *myConfig) RegisterFlags(flags *FlagfigSet) {
+//		c.MyCoolString = c.MyCoolStringConf.String(flags)
+//	}
+//
+// Like a bad default in a tag given to Bind, it panics if Default is not a valid value
+func (c ConfigurableConfig) String(flags *FlagfigSet, opts ...FlagOption) *string {
+	return flags.String(c.FlagName, "", c.EnvName, c.Usage, c.options(opts)...)
+}
+
+// Bool defines a bool flag in flags as the descriptor describes it
+func (c ConfigurableConfig) Bool(flags *FlagfigSet, opts ...FlagOption) *bool {
+	return flags.Bool(c.FlagName, false, c.EnvName, c.Usage, c.options(opts)...)
+}
+
+// Int defines an int flag in flags as the descriptor describes it
+func (c ConfigurableConfig) Int(flags *FlagfigSet, opts ...FlagOption) *int {
+	return flags.Int(c.FlagName, 0, c.EnvName, c.Usage, c.options(opts)...)
+}
+
+// Int64 defines an int64 flag in flags as the descriptor describes it
+func (c ConfigurableConfig) Int64(flags *FlagfigSet, opts ...FlagOption) *int64 {
+	return flags.Int64(c.FlagName, 0, c.EnvName, c.Usage, c.options(opts)...)
+}
+
+// Uint defines a uint flag in flags as the descriptor describes it
+func (c ConfigurableConfig) Uint(flags *FlagfigSet, opts ...FlagOption) *uint {
+	return flags.Uint(c.FlagName, 0, c.EnvName, c.Usage, c.options(opts)...)
+}
+
+// Uint64 defines a uint64 flag in flags as the descriptor describes it
+func (c ConfigurableConfig) Uint64(flags *FlagfigSet, opts ...FlagOption) *uint64 {
+	return flags.Uint64(c.FlagName, 0, c.EnvName, c.Usage, c.options(opts)...)
+}
+
+// Float64 defines a float64 flag in flags as the descriptor describes it
+func (c ConfigurableConfig) Float64(flags *FlagfigSet, opts ...FlagOption) *float64 {
+	return flags.Float64(c.FlagName, 0, c.EnvName, c.Usage, c.options(opts)...)
+}
+
+// Duration defines a time.Duration flag in flags as the descriptor describes it
+func (c ConfigurableConfig) Duration(flags *FlagfigSet, opts ...FlagOption) *time.Duration {
+	return flags.Duration(c.FlagName, 0, c.EnvName, c.Usage, c.options(opts)...)
+}
+
+// StringSlice defines a string slice flag in flags as the descriptor describes it. Default is comma separated
+func (c ConfigurableConfig) StringSlice(flags *FlagfigSet, opts ...FlagOption) *[]string {
+	return flags.StringSlice(c.FlagName, nil, c.EnvName, c.Usage, c.options(opts)...)
+}
+
+// StringMap defines a string map flag in flags as the descriptor describes it. Default is written as key=value pairs,
+// separated by commas
+func (c ConfigurableConfig) StringMap(flags *FlagfigSet, opts ...FlagOption) *map[string]string {
+	return flags.StringMap(c.FlagName, nil, c.EnvName, c.Usage, c.options(opts)...)
+}
@@ -0,0 +1,89 @@
+package flagfig
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+type taggedConfig struct {
+	NesterBase
+	Host     *string
+	HostConf ConfigurableConfig `flagfig:"host,env=TAGGED_HOST"`
+	Port     *int
+	PortConf ConfigurableConfig `flagfig:"port"`
+	Other    ConfigurableConfig
+}
+
+func (c *taggedConfig) RegisterFlags(flags *FlagfigSet) {
+	c.Host = flags.String(c.HostConf.FlagName, "localhost", c.HostConf.EnvName, "host")
+	c.Port = flags.Int(c.PortConf.FlagName, 80, c.PortConf.EnvName, "port")
+}
+
+func TestConfigurableConfigsFromTags(t *testing.T) {
+	cfg := &taggedConfig{PortConf: ConfigurableConfig{FlagName: "renamed-port"}}
+	ConfigurableConfigsFromTags(cfg)
+	if cfg.HostConf != NewConfigurableConfig("host", "TAGGED_HOST") {
+		t.Error("expected the host names from the tag, got ", cfg.HostConf)
+	}
+	if cfg.PortConf != NewConfigurableConfig("renamed-port", "") {
+		t.Error("expected the port name given before to be kept, got ", cfg.PortConf)
+	}
+	if cfg.Other != (ConfigurableConfig{}) {
+		t.Error("expected the untagged field to be left alone, got ", cfg.Other)
+	}
+	if err := ParseNested(flag.ContinueOnError, []Nester{cfg}, []string{"-host=example.com", "-renamed-port=8080"}); err != nil {
+		t.Fatal(err)
+	}
+	if *cfg.Host != "example.com" || *cfg.Port != 8080 {
+		t.Errorf("got %s and %d", *cfg.Host, *cfg.Port)
+	}
+}
+
+type describedConfig struct {
+	NesterBase
+	Host        *string
+	HostConf    ConfigurableConfig `flagfig:"host,default=localhost,usage=host to connect to"`
+	Timeout     *time.Duration
+	TimeoutConf ConfigurableConfig
+	Debug       *bool
+	DebugConf   ConfigurableConfig
+}
+
+func (c *describedConfig) RegisterFlags(flags *FlagfigSet) {
+	c.Host = c.HostConf.String(flags)
+	c.Timeout = c.TimeoutConf.Duration(flags)
+	c.Debug = c.DebugConf.Bool(flags)
+}
+
+func TestConfigurableConfig_Define(t *testing.T) {
+	path, cleanup := testTempFile(t)
+	defer cleanup()
+	if err := ioutil.WriteFile(path, []byte(`{"db.connect-timeout": "5s"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &describedConfig{
+		TimeoutConf: ConfigurableConfig{FlagName: "timeout", Default: "1s", Usage: "timeout", ConfigKey: "connect-timeout"},
+		DebugConf:   ConfigurableConfig{FlagName: "debug", Hidden: true},
+	}
+	ConfigurableConfigsFromTags(cfg)
+	err := ParseNestedWithOptions(NestedOptions{ErrorHandling: flag.ContinueOnError, ConfigFileFlag: "config"},
+		[]Nester{Prefixed("db", cfg)}, []string{"-config=" + path})
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if *cfg.Host != "localhost" || *cfg.Timeout != 5*time.Second || *cfg.Debug {
+		t.Errorf("expected localhost, 5s and false, got %s, %s and %t", *cfg.Host, *cfg.Timeout, *cfg.Debug)
+	}
+
+	flags := NewFlagfigSet("test", flag.ContinueOnError)
+	cfg.RegisterFlags(flags)
+	info, ok := flags.LookupInfo("host")
+	if !ok || info.Usage != "host to connect to" || info.Default != "localhost" {
+		t.Error("expected the usage and default from the tag, got ", info)
+	}
+	if info, ok = flags.LookupInfo("debug"); !ok || !info.Hidden {
+		t.Error("expected -debug to be hidden")
+	}
+}
@@ -0,0 +1,113 @@
+package flagfig
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// DecodeHook converts data, of type from, into a value for a field of type to, for Unmarshal and Bind. A hook that
+// does not handle the types returns data as is, for the next hook to try
+type DecodeHook func(from, to reflect.Type, data interface{}) (interface{}, error)
+
+func AddDecodeHook(hooks ...DecodeHook) {
+	CommandLine.AddDecodeHook(hooks...)
+}
+
+// AddDecodeHook adds hooks to convert flag values into field types flagfig does not know, such as log levels or enums.
+// The hooks are run in the order they were added, each given the result of the one before.
+//
+// Unmarshal runs them when a flag's value cannot be assigned to its field. Bind runs them on the text of the flag,
+// from the command line, environment or configuration files, for fields of other types than those it defines flags
+// for. Add the hooks before calling Bind. Whatever is still not of the field's type is read as JSON:
+//
+//	flags.AddDecodeHook(flagfig.TextUnmarshalerHook())
+//	flags.Bind(&cfg) // cfg.Level is a slog.Level, set with -level=debug
+func (f *FlagfigSet) AddDecodeHook(hooks ...DecodeHook) {
+	f.decodeHooks = append(f.decodeHooks, hooks...)
+}
+
+// decode runs the hooks on data, then converts the result to the type to if it can
+func (f *FlagfigSet) decode(data interface{}, to reflect.Type) (out reflect.Value, err error) {
+	for _, hook := range f.decodeHooks {
+		data, err = hook(reflect.TypeOf(data), to, data)
+		if err != nil {
+			return
+		}
+	}
+	out = reflect.ValueOf(data)
+	switch {
+	case !out.IsValid():
+		return reflect.Zero(to), nil
+	case out.Type().AssignableTo(to):
+		return out, nil
+	case out.Kind() == to.Kind() && out.Type().ConvertibleTo(to):
+		return out.Convert(to), nil
+	case isNumber(out.Kind()) && isNumber(to.Kind()):
+		return out.Convert(to), nil
+	}
+	return out, fmt.Errorf("cannot decode %v of type %s into %s", data, out.Type(), to)
+}
+
+// TextUnmarshalerHook converts strings into types implementing encoding.TextUnmarshaler, such as log levels
+func TextUnmarshalerHook() DecodeHook {
+	unmarshaler := reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	return func(from, to reflect.Type, data interface{}) (interface{}, error) {
+		s, ok := data.(string)
+		if !ok || !reflect.PtrTo(to).Implements(unmarshaler) {
+			return data, nil
+		}
+		ptr := reflect.New(to)
+		if err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return nil, err
+		}
+		return ptr.Elem().Interface(), nil
+	}
+}
+
+// StringToDurationHook converts strings such as "1m30s" into time.Duration
+func StringToDurationHook() DecodeHook {
+	return func(from, to reflect.Type, data interface{}) (interface{}, error) {
+		s, ok := data.(string)
+		if !ok || to != reflect.TypeOf(time.Duration(0)) {
+			return data, nil
+		}
+		return time.ParseDuration(s)
+	}
+}
+
+// hookValue is a flag.Value for a bound field of a type flagfig has no flag for, set through the decode hooks
+type hookValue struct {
+	f      *FlagfigSet
+	target reflect.Value
+}
+
+func (h *hookValue) Set(val string) error {
+	v, err := h.f.decode(val, h.target.Type())
+	if err != nil {
+		// Fall back to JSON, as for fields bound without hooks
+		ptr := reflect.New(h.target.Type())
+		if json.Unmarshal([]byte(val), ptr.Interface()) != nil {
+			return err
+		}
+		v = ptr.Elem()
+	}
+	h.target.Set(v)
+	return nil
+}
+
+func (h *hookValue) Get() interface{} {
+	return h.target.Interface()
+}
+
+func (h *hookValue) String() string {
+	if h == nil || !h.target.IsValid() {
+		return ""
+	}
+	if s, ok := h.target.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprint(h.target.Interface())
+}
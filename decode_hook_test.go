@@ -0,0 +1,81 @@
+package flagfig
+
+import (
+	"errors"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testLevel int
+
+func (l *testLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "debug":
+		*l = 0
+	case "info":
+		*l = 1
+	default:
+		return errors.New("unknown level " + string(text))
+	}
+	return nil
+}
+
+type testColor string
+
+// upperHook is a hook written by an application, it shouts every string
+func upperHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if s, ok := data.(string); ok && to == reflect.TypeOf(testColor("")) {
+		return strings.ToUpper(s), nil
+	}
+	return data, nil
+}
+
+func TestAddDecodeHook_Bind(t *testing.T) {
+	type config struct {
+		Level  testLevel  `flagfig:"level,default=info"`
+		Color  testColor  `flagfig:"color"`
+		Limits testLimits `flagfig:"limits,json"`
+	}
+	cfg := &config{}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.AddDecodeHook(TextUnmarshalerHook(), upperHook)
+	f.Bind(cfg)
+	if err := f.Parse([]string{"-color=red", `-limits={"Rate":2}`}); err != nil {
+		t.Fatal(err)
+	}
+	expected := config{Level: 1, Color: "RED", Limits: testLimits{Rate: 2}}
+	if *cfg != expected {
+		t.Errorf("expected %+v, got %+v", expected, *cfg)
+	}
+	f = NewFlagfigSet("test", flag.ContinueOnError)
+	f.AddDecodeHook(TextUnmarshalerHook())
+	f.Bind(&config{})
+	if err := f.Parse([]string{"-level=loud"}); err == nil || !strings.Contains(err.Error(), "unknown level loud") {
+		t.Error("expected the hook's error, got ", err)
+	}
+}
+
+func TestAddDecodeHook_Unmarshal(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.String("level", "debug", "", "level")
+	f.String("timeout", "1m30s", "", "timeout")
+	f.String("color", "blue", "", "color")
+	f.AddDecodeHook(TextUnmarshalerHook(), StringToDurationHook())
+	if err := f.Parse([]string{"-level=info"}); err != nil {
+		t.Fatal(err)
+	}
+	var cfg struct {
+		Level   testLevel
+		Timeout time.Duration
+		Color   testColor
+	}
+	if err := f.Unmarshal(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Level != 1 || cfg.Timeout != 90*time.Second || cfg.Color != "blue" {
+		t.Errorf("unexpected values %+v", cfg)
+	}
+}
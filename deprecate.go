@@ -0,0 +1,89 @@
+package flagfig
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// deprecation describes a flag that should no longer be used, see Deprecate
+type deprecation struct {
+	message     string
+	replacement string
+}
+
+func Deprecate(name, message, replacement string) {
+	CommandLine.Deprecate(name, message, replacement)
+}
+
+// Deprecate marks a flag as deprecated. When any source sets it, whether the command line, the environment or a
+// configuration file, Collate writes a warning with the message to the warning output (see SetWarningOutput).
+//
+// If replacement names another flag, the value is forwarded to it, unless a source sets the replacement itself, which
+// makes renaming a flag painless:
+//
+//	flags.String("db-host", "", "MYAPP_DB_HOST", "database host")
+//	flags.String("database-host", "", "MYAPP_DATABASE_HOST", "database host")
+//	flags.Deprecate("db-host", "it will be removed in 2.0", "database-host")
+func (f *FlagfigSet) Deprecate(name, message, replacement string) {
+	f.deprecations[name] = deprecation{message: message, replacement: replacement}
+}
+
+func SetWarningOutput(w io.Writer) {
+	CommandLine.SetWarningOutput(w)
+}
+
+// SetWarningOutput sets where warnings, such as the use of deprecated flags, are written. If w is nil, which is the
+// default, warnings are written to Output()
+func (f *FlagfigSet) SetWarningOutput(w io.Writer) {
+	f.warnOut = w
+}
+
+// warnf writes a warning to the warning output
+func (f *FlagfigSet) warnf(format string, args ...interface{}) {
+	w := f.warnOut
+	if w == nil {
+		w = f.Output()
+	}
+	_, _ = fmt.Fprintf(w, "warning: "+format+"\n", args...)
+}
+
+// applyDeprecations warns about the deprecated flags that were set and forwards their values to their replacements
+func (f *FlagfigSet) applyDeprecations() (err error) {
+	// Sorted, so the warnings come out in the same order every time
+	names := make([]string, 0, len(f.deprecations))
+	for name := range f.deprecations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		d := f.deprecations[name]
+		if !f.IsSet(name) {
+			continue
+		}
+		msg := fmt.Sprintf("flag -%s, set by %s, is deprecated", name, f.describeOrigin(name))
+		if len(d.message) != 0 {
+			msg += ": " + d.message
+		}
+		if len(d.replacement) == 0 {
+			f.warnf("%s", msg)
+			continue
+		}
+		if f.IsSet(d.replacement) {
+			f.warnf("%s; it is ignored because -%s is also set", msg, d.replacement)
+			continue
+		}
+		f.warnf("%s; use -%s instead", msg, d.replacement)
+		fl, to := f.FlagSet.Lookup(name), f.FlagSet.Lookup(d.replacement)
+		if to == nil {
+			return fmt.Errorf("flag -%s is replaced by -%s, which is not defined", name, d.replacement)
+		}
+		err = setValueText(to, fl, valueText(fl))
+		if err != nil {
+			_, err = f.redactValue(name, valueText(fl), err)
+			return fmt.Errorf("flag -%s, set by %s, cannot be forwarded to -%s: %v", name, f.describeOrigin(name), d.replacement, err)
+		}
+		f.origins[d.replacement] = f.origins[name]
+	}
+	return
+}
@@ -0,0 +1,81 @@
+package flagfig
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"testing"
+)
+
+func TestDeprecate(t *testing.T) {
+	cases := map[string]struct {
+		args            []string
+		env             string
+		expectedNew     string
+		expectedWarning string
+	}{
+		"unused": {
+			expectedNew: "new-default",
+		},
+		"forwarded from the command line": {
+			args:            []string{"-db-host=old"},
+			expectedNew:     "old",
+			expectedWarning: "warning: flag -db-host, set by the command line, is deprecated: going away; use -database-host instead\n",
+		},
+		"forwarded from the environment": {
+			env:             "from-env",
+			expectedNew:     "from-env",
+			expectedWarning: "warning: flag -db-host, set by environment variable ENV_DEPRECATED_HOST, is deprecated: going away; use -database-host instead\n",
+		},
+		"replacement wins": {
+			args:            []string{"-db-host=old", "-database-host=new"},
+			expectedNew:     "new",
+			expectedWarning: "warning: flag -db-host, set by the command line, is deprecated: going away; it is ignored because -database-host is also set\n",
+		},
+	}
+	for caseName, c := range cases {
+		t.Run(caseName, func(t *testing.T) {
+			if len(c.env) != 0 {
+				_ = os.Setenv("ENV_DEPRECATED_HOST", c.env)
+				defer func() { _ = os.Unsetenv("ENV_DEPRECATED_HOST") }()
+			}
+			warnings := &bytes.Buffer{}
+			f := NewFlagfigSet("test", flag.ContinueOnError)
+			f.SetWarningOutput(warnings)
+			f.String("db-host", "", "ENV_DEPRECATED_HOST", "database host")
+			newHost := f.String("database-host", "new-default", "", "database host")
+			f.Deprecate("db-host", "going away", "database-host")
+			err := f.Parse(c.args)
+			if err != nil {
+				t.Fatal("unexpected error: ", err)
+			}
+			if *newHost != c.expectedNew {
+				t.Errorf("expected -database-host to be %q, got %q", c.expectedNew, *newHost)
+			}
+			if warnings.String() != c.expectedWarning {
+				t.Errorf("expected warning %q, got %q", c.expectedWarning, warnings.String())
+			}
+		})
+	}
+}
+
+func TestDeprecateWarningOrder(t *testing.T) {
+	expected := "warning: flag -a, set by the command line, is deprecated\n" +
+		"warning: flag -b, set by the command line, is deprecated\n" +
+		"warning: flag -c, set by the command line, is deprecated\n"
+	for i := 0; i < 10; i++ {
+		warnings := &bytes.Buffer{}
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.SetWarningOutput(warnings)
+		for _, name := range []string{"c", "a", "b"} {
+			f.String(name, "", "", name)
+			f.Deprecate(name, "", "")
+		}
+		if err := f.Parse([]string{"-b=1", "-c=1", "-a=1"}); err != nil {
+			t.Fatal(err)
+		}
+		if warnings.String() != expected {
+			t.Fatalf("expected the warnings in order of name, got %q", warnings.String())
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package flagfig
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+func DumpJSON(w io.Writer) error {
+	return CommandLine.DumpJSON(w)
+}
+
+// DumpJSON writes the resolved value of every flag as a JSON object, keyed by flag name, for services to log their
+// effective configuration at startup, or to diff the configuration of two environments:
+//
+//	_ = flags.Parse(os.Args[1:])
+//	_ = flags.DumpJSON(os.Stderr)
+//
+// Values keep their types, so numbers and bools are not quoted, lists are arrays and maps are objects. Durations are
+// written as text, such as "1m30s". Sensitive values are redacted, and flagfig's own flags, such as the configuration
+// file flag, are left out
+func (f *FlagfigSet) DumpJSON(w io.Writer) error {
+	values := make(map[string]interface{})
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		if !f.internalFlags[fl.Name] {
+			values[fl.Name] = f.effectiveValue(fl)
+		}
+	})
+	raw, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", raw)
+	return err
+}
+
+// effectiveValue is the flag's resolved value, as it would be written in a configuration file
+func (f *FlagfigSet) effectiveValue(fl *flag.Flag) interface{} {
+	text := fl.Value.String()
+	if f.redacted(fl.Name) && len(text) != 0 {
+		return redactedValue
+	}
+	switch v := fl.Value.(type) {
+	case *stringSliceValue:
+		if *v.p == nil {
+			return []string{}
+		}
+		return *v.p
+	case *stringMapValue:
+		return *v.p
+	case *jsonValue:
+		if json.Valid([]byte(text)) {
+			return json.RawMessage(text)
+		}
+		return nil
+	}
+	switch f.flagTypes[fl.Name] {
+	case boolType:
+		return text == "true"
+	case intType, int64Type, uintType, uint64Type, floatType:
+		return json.Number(text)
+	}
+	return text
+}
+
+func DumpYAML(w io.Writer) error {
+	return CommandLine.DumpYAML(w)
+}
+
+// DumpYAML writes the resolved value of every flag as a YAML configuration file, which reads back exactly like any
+// other, say to pin down the configuration of a running service:
+//
+//	_ = flags.DumpYAML(file)
+//	...
+//	myapp -config=pinned.yaml
+//
+// Values are written as DumpJSON writes them. Sensitive values are only named in a comment, so they are not written
+// out, and fall to the other sources when the file is read back. Flags that configuration files may not set,
+// deprecated flags and flagfig's own flags are left out
+func (f *FlagfigSet) DumpYAML(w io.Writer) error {
+	return f.writeYAMLConfig(w, false)
+}
+
+// writeYAMLConfig is DumpYAML, leaving out the flags that have their default values if changedOnly
+func (f *FlagfigSet) writeYAMLConfig(w io.Writer, changedOnly bool) (err error) {
+	sb := strings.Builder{}
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		if err != nil || !f.dumpable(fl.Name, SourceFile) || (changedOnly && !changed(fl)) {
+			return
+		}
+		key := fl.Name
+		if !yamlPlainKey.MatchString(key) {
+			quoted, _ := json.Marshal(key)
+			key = string(quoted)
+		}
+		if f.redacted(fl.Name) {
+			sb.WriteString("# " + key + " is sensitive, and left out\n")
+			return
+		}
+		var raw []byte
+		raw, err = json.Marshal(f.effectiveValue(fl))
+		if err != nil {
+			err = fmt.Errorf("flag -%s: %v", fl.Name, err)
+			return
+		}
+		sb.WriteString(key + ": " + string(raw) + "\n")
+	})
+	if err != nil {
+		return
+	}
+	_, err = fmt.Fprint(w, sb.String())
+	return
+}
+
+func DumpEnv(w io.Writer) error {
+	return CommandLine.DumpEnv(w)
+}
+
+// DumpEnv writes the resolved value of every flag as NAME=value lines, in the .env format LoadDotEnv reads and shells
+// source, for handing the configuration to tools that only read environment variables. Each flag is written under its
+// environment variable, or, for flags without one, under the name AutomaticEnv would give it. Lists and maps are
+// written as JSON, which the environment variables of those flags accept.
+// Sensitive values are only named in a comment, and flags that the environment may not set, deprecated flags and
+// flagfig's own flags are left out
+func (f *FlagfigSet) DumpEnv(w io.Writer) error {
+	sb := strings.Builder{}
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		if !f.dumpable(fl.Name, SourceEnv) {
+			return
+		}
+		envName := f.envNameFor(fl.Name)
+		if len(envName) == 0 {
+			envName = f.automaticEnvName(fl.Name)
+		}
+		if f.redacted(fl.Name) {
+			sb.WriteString("# " + envName + " is sensitive, and left out\n")
+			return
+		}
+		text := fl.Value.String()
+		switch fl.Value.(type) {
+		case *stringSliceValue, *stringMapValue:
+			raw, _ := json.Marshal(f.effectiveValue(fl))
+			text = string(raw)
+		}
+		sb.WriteString(envName + "=" + quoteEnvValue(text) + "\n")
+	})
+	_, err := fmt.Fprint(w, sb.String())
+	return err
+}
+
+// changed is true if the flag's value is not its default
+func changed(fl *flag.Flag) bool {
+	return fl.Value.String() != fl.DefValue
+}
+
+// dumpable is true for the flags written by DumpYAML and DumpEnv, which must read back from the source they are
+// written for
+func (f *FlagfigSet) dumpable(name string, source Source) bool {
+	if _, ok := f.deprecations[name]; ok {
+		return false
+	}
+	return !f.internalFlags[name] && f.sourceAllowed(name, source)
+}
+
+// envPlainValue is a value that needs no quoting in a .env file or a shell
+var envPlainValue = regexp.MustCompile(`^[A-Za-z0-9_./:,=@+-]+$`)
+
+// quoteEnvValue quotes the value for a .env file, with single quotes where it can, so shells take it as it is
+func quoteEnvValue(val string) string {
+	switch {
+	case envPlainValue.MatchString(val):
+		return val
+	case !strings.ContainsAny(val, "'\n"):
+		return "'" + val + "'"
+	}
+	return strconv.Quote(val)
+}
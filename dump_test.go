@@ -0,0 +1,153 @@
+package flagfig
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDumpJSON(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.AddConfigFile("config", "configuration file")
+	f.String("host", "localhost", "", "host")
+	f.Int("port", 8080, "", "port")
+	f.Bool("debug", false, "", "debug")
+	f.Float64("ratio", 0.5, "", "ratio")
+	f.Duration("timeout", 30*time.Second, "", "timeout")
+	f.StringSlice("peers", nil, "", "peers")
+	f.StringMap("labels", nil, "", "labels")
+	f.String("token", "", "", "token", Sensitive())
+	if err := f.Parse([]string{"-port=9090", "-peers=a,b", "-labels=env=prod", "-token=secret"}); err != nil {
+		t.Fatal(err)
+	}
+	out := &bytes.Buffer{}
+	if err := f.DumpJSON(out); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  "debug": false,
+  "host": "localhost",
+  "labels": {
+    "env": "prod"
+  },
+  "peers": [
+    "a",
+    "b"
+  ],
+  "port": 9090,
+  "ratio": 0.5,
+  "timeout": "30s",
+  "token": "****"
+}
+`
+	if out.String() != expected {
+		t.Errorf("expected %s, got %s", expected, out.String())
+	}
+}
+
+func TestDumpYAML(t *testing.T) {
+	path, cleanup := testTempFile(t)
+	defer cleanup()
+	define := func() *FlagfigSet {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.AddConfigFile("config", "configuration file")
+		f.String("host", "localhost", "", "host")
+		f.Int("port", 8080, "", "port")
+		f.Bool("debug", false, "", "debug")
+		f.Duration("timeout", 30*time.Second, "", "timeout")
+		f.StringSlice("peers", nil, "", "peers")
+		f.StringMap("labels", nil, "", "labels")
+		f.String("token", "", "", "token", Sensitive())
+		f.String("mode", "", "", "mode", FromSources(SourceFlag))
+		return f
+	}
+	f := define()
+	args := []string{"-port=9090", "-debug", "-timeout=1m", "-peers=a,b", "-labels=env=prod", "-token=secret", "-mode=fast"}
+	if err := f.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	out := &bytes.Buffer{}
+	if err := f.DumpYAML(out); err != nil {
+		t.Fatal(err)
+	}
+	expected := `debug: true
+host: "localhost"
+labels: {"env":"prod"}
+peers: ["a","b"]
+port: 9090
+timeout: "1m0s"
+# token is sensitive, and left out
+`
+	if out.String() != expected {
+		t.Errorf("expected %s, got %s", expected, out.String())
+	}
+
+	if err := ioutil.WriteFile(path+".yaml", out.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Remove(path + ".yaml") }()
+	again := define()
+	if err := again.Parse([]string{"-config", path + ".yaml"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"host", "port", "debug", "timeout", "peers", "labels"} {
+		if again.Lookup(name).Value.String() != f.Lookup(name).Value.String() {
+			t.Errorf("expected -%s to read back as %s, got %s", name, f.Lookup(name).Value, again.Lookup(name).Value)
+		}
+	}
+}
+
+func TestDumpEnv(t *testing.T) {
+	path, cleanup := testTempFile(t)
+	defer cleanup()
+	define := func() *FlagfigSet {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.AutomaticEnv("DUMP")
+		f.String("host", "localhost", "DUMP_ENV_HOST", "host")
+		f.String("greeting", "", "", "greeting")
+		f.Int("port", 8080, "", "port")
+		f.StringSlice("peers", nil, "", "peers")
+		f.StringMap("labels", nil, "", "labels")
+		f.String("token", "", "DUMP_TOKEN", "token", Sensitive())
+		f.String("mode", "", "", "mode", FromSources(SourceFlag))
+		return f
+	}
+	f := define()
+	args := []string{"-port=9090", "-greeting=it's a $5 day", "-peers=a,b", "-labels=env=prod", "-token=secret", "-mode=fast"}
+	if err := f.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	out := &bytes.Buffer{}
+	if err := f.DumpEnv(out); err != nil {
+		t.Fatal(err)
+	}
+	expected := `DUMP_GREETING="it's a $5 day"
+DUMP_ENV_HOST=localhost
+DUMP_LABELS='{"env":"prod"}'
+DUMP_PEERS='["a","b"]'
+DUMP_PORT=9090
+# DUMP_TOKEN is sensitive, and left out
+`
+	if out.String() != expected {
+		t.Errorf("expected %s, got %s", expected, out.String())
+	}
+
+	if err := ioutil.WriteFile(path, out.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	again := define()
+	if err := again.LoadDotEnv(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := again.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"host", "greeting", "port", "peers", "labels"} {
+		if again.Lookup(name).Value.String() != f.Lookup(name).Value.String() {
+			t.Errorf("expected -%s to read back as %s, got %s", name, f.Lookup(name).Value, again.Lookup(name).Value)
+		}
+	}
+}
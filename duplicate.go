@@ -0,0 +1,85 @@
+package flagfig
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// DuplicateFlagError is a flag defined twice, which the flag package only reports as "flag redefined". Defining a
+// flag again panics with it, and ParseNested and Command.Execute return it, as that usually means two Nesters use the
+// same name
+type DuplicateFlagError struct {
+	Name string
+	// First and Second say where the flag was defined: by which Nester, if a Nester was registering its flags, and at
+	// which file and line
+	First, Second string
+}
+
+func (e *DuplicateFlagError) Error() string {
+	return fmt.Sprintf("flag -%s is defined twice, first %s, then %s. Give one of them another name, such as with "+
+		"Prefixed, or the Name of its ConfigurableConfig", e.Name, e.First, e.Second)
+}
+
+// checkDuplicate panics with a DuplicateFlagError if the flag is defined already, or records where it is defined
+func (f *FlagfigSet) checkDuplicate(name string) {
+	site := f.defineSite()
+	if f.FlagSet.Lookup(name) != nil {
+		first, ok := f.definedAt[name]
+		if !ok {
+			first = "by flagfig"
+		}
+		panic(&DuplicateFlagError{Name: name, First: first, Second: site})
+	}
+	f.definedAt[name] = site
+}
+
+// defineSite describes where a flag is being defined
+func (f *FlagfigSet) defineSite() string {
+	site := "at " + callerOutside()
+	if f.defining != nil {
+		site = "by " + describeNester(f.defining) + " " + site
+	}
+	return site
+}
+
+// packageDir is the directory of flagfig's source, to tell its stack frames apart from those of the program
+var packageDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// callerOutside is the file and line of the first caller outside of flagfig
+func callerOutside() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if filepath.Dir(frame.File) != packageDir || strings.HasSuffix(frame.File, "_test.go") {
+			return fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line)
+		}
+		if !more {
+			return "an unknown place"
+		}
+	}
+}
+
+// registerNesters has each Nester register its flags, returning a DuplicateFlagError rather than panicking with it
+func registerNesters(flags *FlagfigSet, nestedConfigs []Nester) (err error) {
+	defer func() {
+		flags.defining = nil
+		if r := recover(); r != nil {
+			dup, ok := r.(*DuplicateFlagError)
+			if !ok {
+				panic(r)
+			}
+			err = dup
+		}
+	}()
+	for _, nc := range nestedConfigs {
+		flags.defining = nc
+		nc.RegisterFlags(flags)
+	}
+	return nil
+}
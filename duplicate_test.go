@@ -0,0 +1,50 @@
+package flagfig
+
+import (
+	"errors"
+	"flag"
+	"regexp"
+	"testing"
+)
+
+func TestDuplicateFlagError(t *testing.T) {
+	cases := map[string]struct {
+		nesters  []Nester
+		expected string
+	}{
+		"unique": {
+			nesters: []Nester{Prefixed("a", &portConfig{}), Prefixed("b", &portConfig{})},
+		},
+		"same nester twice": {
+			nesters: []Nester{&portConfig{}, &portConfig{}},
+			expected: `^flag -port is defined twice, first by \*flagfig.portConfig at nester_test.go:\d+, ` +
+				`then by \*flagfig.portConfig at nester_test.go:\d+. Give one of them another name, such as with ` +
+				`Prefixed, or the Name of its ConfigurableConfig$`,
+		},
+	}
+	for caseName, c := range cases {
+		err := ParseNested(flag.ContinueOnError, c.nesters, []string{})
+		if len(c.expected) == 0 {
+			if err != nil {
+				t.Errorf("case %s: unexpected error: %s", caseName, err)
+			}
+			continue
+		}
+		var dup *DuplicateFlagError
+		if !errors.As(err, &dup) || dup.Name != "port" || !regexp.MustCompile(c.expected).MatchString(err.Error()) {
+			t.Errorf("case %s: expected %s, got %v", caseName, c.expected, err)
+		}
+	}
+
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.String("host", "", "", "host")
+	func() {
+		defer func() {
+			dup, ok := recover().(*DuplicateFlagError)
+			if !ok || !regexp.MustCompile(`^at duplicate_test.go:\d+$`).MatchString(dup.First) {
+				t.Errorf("expected a DuplicateFlagError naming this file, got %#v", dup)
+			}
+		}()
+		f.Int("host", 0, "", "host")
+	}()
+}
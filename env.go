@@ -0,0 +1,258 @@
+package flagfig
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EnvOpt describes how a flag is read from the environment. Pass one to the *Env variants of the flag constructors,
+// such as StringEnv, when a bare environment name is not enough:
+//
+//	peers := flags.StringSliceEnv("peers", nil, flagfig.EnvOpt{Name: "MYAPP_PEERS", Separator: ";"}, "peer addresses")
+type EnvOpt struct {
+	// Name is the environment variable to read. Blank means the environment is not read, unless AutomaticEnv is on
+	Name string
+	// Required makes Collate fail when the variable is not set, unless the flag was given on the command line
+	Required bool
+	// Separator splits the value into items for StringSlice flags. Blank means the default: a comma
+	Separator string
+	// TreatEmptyAsUnset ignores a variable that is set to an empty string, as if it were not set at all.
+	// When false, setting the variable to an empty string sets the flag to an empty value
+	TreatEmptyAsUnset bool
+}
+
+// envOptFromName is the environment handling used by the constructors that take a bare environment name.
+// They have always ignored empty variables
+func envOptFromName(envName string) EnvOpt {
+	return EnvOpt{
+		Name:              envName,
+		TreatEmptyAsUnset: true,
+	}
+}
+
+// register records the flagfig-specific details of a flag that is about to be defined on the embedded FlagSet. It
+// returns the name to define the flag with, which has the prefix of any Prefixed Nester registering it
+func (f *FlagfigSet) register(name string, flagType int, env EnvOpt) string {
+	name = f.definePrefix + name
+	f.checkDuplicate(name)
+	if len(env.Name) != 0 {
+		env.Name = f.defineEnvPrefix + env.Name
+	}
+	f.envOpts[name] = env
+	f.flagTypes[name] = flagType
+	f.order = append(f.order, name)
+	return name
+}
+
+// defaultEnvKeyReplacer maps the characters commonly found in flag names, but not allowed in environment names, to underscores
+var defaultEnvKeyReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// AutomaticEnv turns on automatic environment naming for the CommandLine
+func AutomaticEnv(prefix string) {
+	CommandLine.AutomaticEnv(prefix)
+}
+
+// AutomaticEnv turns on automatic environment naming. Flags registered with a blank envName will no longer skip the
+// environment, instead, they will be looked up using a name generated from the flag name: the flag name is run through
+// the env key replacer, upper-cased, and joined to the prefix with an underscore.
+// For example, with a prefix of "MYAPP", the flag "http-addr" is read from "MYAPP_HTTP_ADDR".
+// If prefix is blank, no prefix is added. Flags registered with an explicit envName always use that name as-is.
+func (f *FlagfigSet) AutomaticEnv(prefix string) {
+	f.automaticEnv = true
+	f.envPrefix = prefix
+}
+
+// SetEnvKeyReplacer sets the replacer for the CommandLine
+func SetEnvKeyReplacer(r *strings.Replacer) {
+	CommandLine.SetEnvKeyReplacer(r)
+}
+
+// SetEnvKeyReplacer sets the replacer used to convert flag names into environment names when AutomaticEnv is on.
+// By default, dots and dashes are converted to underscores. Passing nil restores the default.
+func (f *FlagfigSet) SetEnvKeyReplacer(r *strings.Replacer) {
+	if r == nil {
+		r = defaultEnvKeyReplacer
+	}
+	f.envKeyReplacer = r
+}
+
+// envNameFor returns the environment variable name to read for the flag with the given name, or blank if the
+// environment should not be read for this flag
+func (f *FlagfigSet) envNameFor(name string) string {
+	env, ok := f.envOpts[name]
+	if !ok {
+		return ""
+	}
+	if len(env.Name) != 0 || !f.automaticEnv {
+		return env.Name
+	}
+	return f.automaticEnvName(name)
+}
+
+// automaticEnvName is the environment variable name AutomaticEnv generates for the flag with the given name
+func (f *FlagfigSet) automaticEnvName(name string) string {
+	envName := strings.ToUpper(f.envKeyReplacer.Replace(name))
+	if len(f.envPrefix) != 0 {
+		envName = f.envPrefix + "_" + envName
+	}
+	return envName
+}
+
+// LoadDotEnv loads .env files into the CommandLine's environment layer
+func LoadDotEnv(paths ...string) error {
+	return CommandLine.LoadDotEnv(paths...)
+}
+
+// LoadDotEnv reads KEY=VALUE pairs from each .env file (".env" if no paths are given) into this set's environment
+// layer. The real process environment is never modified, and any variable that is set in the process environment
+// takes precedence over the .env files. If a key appears in more than one file, the last file wins.
+//
+// Lines may be prefixed with "export ", blank lines and lines starting with # are ignored, and values may be wrapped
+// in single quotes (taken literally) or double quotes (which understand escapes such as \n).
+func (f *FlagfigSet) LoadDotEnv(paths ...string) (err error) {
+	if len(paths) == 0 {
+		paths = []string{".env"}
+	}
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		vars, err := parseDotEnv(file)
+		_ = file.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+		for k, v := range vars {
+			f.dotEnv[k] = v
+		}
+	}
+	return nil
+}
+
+// SetEnvCaseInsensitive sets case-insensitive environment matching for the CommandLine
+func SetEnvCaseInsensitive(ignoreCase bool) {
+	CommandLine.SetEnvCaseInsensitive(ignoreCase)
+}
+
+// SetEnvCaseInsensitive controls whether environment variable names must match exactly.
+//
+// On Windows, the process environment is already case-insensitive, so "Path" and "PATH" are the same variable, and
+// this option only changes how the variables loaded with LoadDotEnv are matched. On Linux, macOS and other Unix-like
+// systems, the environment is case-sensitive, so a flag bound to MYAPP_HOST will not see MyApp_Host unless this is
+// turned on. When on, an exact match, in the process environment or in the variables loaded with LoadDotEnv, is always
+// preferred. If only differently-cased matches exist, the process environment is tried before the .env files, and the
+// first matching name in sorted order wins.
+func (f *FlagfigSet) SetEnvCaseInsensitive(ignoreCase bool) {
+	f.envIgnoreCase = ignoreCase
+}
+
+// lookupEnv finds the environment variable in the process environment, then in the values loaded by LoadDotEnv. When
+// matching is case-insensitive, exact matches in either are tried before any differently-cased one
+func (f *FlagfigSet) lookupEnv(name string) (value string, ok bool) {
+	if value, ok = os.LookupEnv(name); ok {
+		return
+	}
+	if value, ok = f.dotEnv[name]; ok || !f.envIgnoreCase {
+		return
+	}
+	environ := f.environ
+	if environ == nil {
+		environ = environMap()
+	}
+	for _, vars := range []map[string]string{environ, f.dotEnv} {
+		if value, ok = lookupFold(vars, name); ok {
+			return
+		}
+	}
+	return
+}
+
+// environMap is the process environment as a map of names to values
+func environMap() map[string]string {
+	environ := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if pair := strings.SplitN(kv, "=", 2); len(pair) == 2 {
+			environ[pair[0]] = pair[1]
+		}
+	}
+	return environ
+}
+
+// lookupFold finds name in vars regardless of case. If several match, the first in sorted order wins
+func lookupFold(vars map[string]string, name string) (value string, ok bool) {
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		if strings.EqualFold(k, name) {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return "", false
+	}
+	sort.Strings(keys)
+	return vars[keys[0]], true
+}
+
+// lookupFlagEnv finds the flag's environment variable, treating empty variables as unset if the flag's EnvOpt says to
+func (f *FlagfigSet) lookupFlagEnv(name, envName string) (value string, ok bool) {
+	value, ok = f.lookupEnv(envName)
+	if ok && len(value) == 0 && f.envOpts[name].TreatEmptyAsUnset {
+		return "", false
+	}
+	return
+}
+
+// lookupIndexedEnv collects the values of NAME_0, NAME_1, ... stopping at the first index that is not set
+func (f *FlagfigSet) lookupIndexedEnv(name string) (items []interface{}, ok bool) {
+	for i := 0; ; i++ {
+		value, found := f.lookupEnv(name + "_" + strconv.Itoa(i))
+		if !found {
+			break
+		}
+		items = append(items, value)
+	}
+	return items, len(items) != 0
+}
+
+// parseDotEnv reads the variables from a .env formatted document
+func parseDotEnv(r io.Reader) (vars map[string]string, err error) {
+	vars = make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		kv := strings.SplitN(line, "=", 2)
+		key := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || len(key) == 0 {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNumber)
+		}
+		value := strings.TrimSpace(kv[1])
+		switch {
+		case len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'"):
+			value = value[1 : len(value)-1]
+		case len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`):
+			value, err = strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", lineNumber, err)
+			}
+		default:
+			// Unquoted values may have a trailing comment
+			if dex := strings.Index(value, " #"); dex != -1 {
+				value = strings.TrimSpace(value[:dex])
+			}
+		}
+		vars[key] = value
+	}
+	return vars, scanner.Err()
+}
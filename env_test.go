@@ -0,0 +1,252 @@
+package flagfig
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestAutomaticEnv(t *testing.T) {
+	cases := map[string]struct {
+		prefix   string
+		replacer *strings.Replacer
+		flagName string
+		envName  string
+		expected string
+	}{
+		"dots and dashes": {
+			prefix:   "MYAPP",
+			flagName: "http.listen-addr",
+			expected: "MYAPP_HTTP_LISTEN_ADDR",
+		},
+		"no prefix": {
+			flagName: "http-addr",
+			expected: "HTTP_ADDR",
+		},
+		"explicit name wins": {
+			prefix:   "MYAPP",
+			flagName: "http-addr",
+			envName:  "LISTEN",
+			expected: "LISTEN",
+		},
+		"custom replacer": {
+			prefix:   "MYAPP",
+			replacer: strings.NewReplacer("-", "__"),
+			flagName: "http-addr",
+			expected: "MYAPP_HTTP__ADDR",
+		},
+	}
+
+	for caseName, c := range cases {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.AutomaticEnv(c.prefix)
+		if c.replacer != nil {
+			f.SetEnvKeyReplacer(c.replacer)
+		}
+		value := f.String(c.flagName, "default", c.envName, "usage")
+		_ = os.Setenv(c.expected, "from-env")
+		err := f.Parse([]string{})
+		_ = os.Unsetenv(c.expected)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *value != "from-env" {
+			t.Errorf("case %s: expected value from %s, got %q", caseName, c.expected, *value)
+		}
+	}
+}
+
+func TestBlankEnvNameSkipsEnvWithoutAutomaticEnv(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	value := f.String("http-addr", "default", "", "usage")
+	_ = os.Setenv("HTTP_ADDR", "from-env")
+	defer func() { _ = os.Unsetenv("HTTP_ADDR") }()
+	if err := f.Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if *value != "default" {
+		t.Error("blank env name should not read the environment, got ", *value)
+	}
+}
+
+func TestLoadDotEnv(t *testing.T) {
+	tmpFileName, tfremove := testTempFile(t)
+	defer tfremove()
+	content := `# local settings
+export DOTENV_HOST=localhost
+DOTENV_NAME="my \"app\""
+DOTENV_RAW='a\nb'
+DOTENV_PORT=8080 # the port
+DOTENV_SHADOWED=from-file
+`
+	if err := ioutil.WriteFile(tmpFileName, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Setenv("DOTENV_SHADOWED", "from-process")
+	defer func() { _ = os.Unsetenv("DOTENV_SHADOWED") }()
+
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	host := f.String("host", "", "DOTENV_HOST", "host")
+	name := f.String("name", "", "DOTENV_NAME", "name")
+	raw := f.String("raw", "", "DOTENV_RAW", "raw")
+	port := f.Int("port", 0, "DOTENV_PORT", "port")
+	shadowed := f.String("shadowed", "", "DOTENV_SHADOWED", "shadowed")
+	if err := f.LoadDotEnv(tmpFileName); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if *host != "localhost" {
+		t.Error("host should be localhost, is ", *host)
+	}
+	if *name != `my "app"` {
+		t.Error(`name should be my "app", is `, *name)
+	}
+	if *raw != `a\nb` {
+		t.Error(`raw should be a\nb, is `, *raw)
+	}
+	if *port != 8080 {
+		t.Error("port should be 8080, is ", *port)
+	}
+	if *shadowed != "from-process" {
+		t.Error("the process environment should win over .env, got ", *shadowed)
+	}
+	if _, ok := os.LookupEnv("DOTENV_HOST"); ok {
+		t.Error("LoadDotEnv must not modify the process environment")
+	}
+}
+
+func TestInvalidEnvValueNamesTheVariable(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.Int("port", 0, "ENV_BAD_PORT", "port")
+	_ = os.Setenv("ENV_BAD_PORT", "eighty")
+	defer func() { _ = os.Unsetenv("ENV_BAD_PORT") }()
+	err := f.Parse([]string{})
+	if err == nil {
+		t.Fatal("expected an error for a non-numeric port")
+	}
+	for _, expected := range []string{"ENV_BAD_PORT", `"eighty"`, "-port"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("error %q should mention %s", err, expected)
+		}
+	}
+}
+
+func TestEnvCaseInsensitive(t *testing.T) {
+	_ = os.Setenv("MyApp_Host", "mixed")
+	defer func() { _ = os.Unsetenv("MyApp_Host") }()
+
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetEnvCaseInsensitive(true)
+	host := f.String("host", "default", "MYAPP_HOST", "host")
+	if err := f.Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if *host != "mixed" {
+		t.Error("host should be read from MyApp_Host, is ", *host)
+	}
+
+	f = NewFlagfigSet("test", flag.ContinueOnError)
+	host = f.String("host", "default", "MYAPP_HOST", "host")
+	if err := f.Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && *host != "default" {
+		t.Error("host should not match differently-cased names by default, is ", *host)
+	}
+}
+
+func TestEnvCaseInsensitivePrefersExactMatch(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the environment is case-insensitive on Windows")
+	}
+	_ = os.Setenv("MyApp_Port", "7070")
+	defer func() { _ = os.Unsetenv("MyApp_Port") }()
+	path, cleanup := testTempFile(t)
+	defer cleanup()
+	if err := ioutil.WriteFile(path, []byte("MYAPP_PORT=8080\nmyapp_limit=2\nMyApp_Limit=1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.SetEnvCaseInsensitive(true)
+		port := f.Int("port", 0, "MYAPP_PORT", "port")
+		limit := f.Int("limit", 0, "MYAPP_LIMIT", "limit")
+		if err := f.LoadDotEnv(path); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Parse([]string{}); err != nil {
+			t.Fatal(err)
+		}
+		if *port != 8080 {
+			t.Fatal("the exact match in the .env file should win, port is ", *port)
+		}
+		if *limit != 1 {
+			t.Fatal("MyApp_Limit sorts first, and should win every time, limit is ", *limit)
+		}
+	}
+}
+
+func TestIndexedEnvForSlices(t *testing.T) {
+	_ = os.Setenv("ENV_PEERS_0", "a,1")
+	_ = os.Setenv("ENV_PEERS_1", "b")
+	_ = os.Setenv("ENV_PEERS_3", "skipped, there is no _2")
+	defer func() {
+		_ = os.Unsetenv("ENV_PEERS_0")
+		_ = os.Unsetenv("ENV_PEERS_1")
+		_ = os.Unsetenv("ENV_PEERS_3")
+	}()
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	peers := f.StringSlice("peers", []string{"default"}, "ENV_PEERS", "peers")
+	if err := f.Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*peers, []string{"a,1", "b"}) {
+		t.Error("peers should be [a,1 b], is ", *peers)
+	}
+}
+
+func TestEnvOpt(t *testing.T) {
+	_ = os.Setenv("ENV_OPT_PEERS", "a,1;b")
+	_ = os.Setenv("ENV_OPT_EMPTY", "")
+	defer func() {
+		_ = os.Unsetenv("ENV_OPT_PEERS")
+		_ = os.Unsetenv("ENV_OPT_EMPTY")
+	}()
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	peers := f.StringSliceEnv("peers", nil, EnvOpt{Name: "ENV_OPT_PEERS", Separator: ";"}, "peers")
+	empty := f.StringEnv("empty", "default", EnvOpt{Name: "ENV_OPT_EMPTY"}, "empty")
+	ignored := f.StringEnv("ignored", "default", EnvOpt{Name: "ENV_OPT_EMPTY", TreatEmptyAsUnset: true}, "ignored")
+	if err := f.Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*peers, []string{"a,1", "b"}) {
+		t.Error("peers should be split on ;, is ", *peers)
+	}
+	if *empty != "" {
+		t.Error("an empty variable should set the flag to empty, is ", *empty)
+	}
+	if *ignored != "default" {
+		t.Error("an empty variable should be ignored with TreatEmptyAsUnset, is ", *ignored)
+	}
+}
+
+func TestEnvOptRequired(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.StringEnv("token", "", EnvOpt{Name: "ENV_OPT_MISSING_TOKEN", Required: true}, "token")
+	err := f.Parse([]string{})
+	if err == nil || !strings.Contains(err.Error(), "ENV_OPT_MISSING_TOKEN") {
+		t.Error("expected a missing environment variable error, got ", err)
+	}
+
+	f = NewFlagfigSet("test", flag.ContinueOnError)
+	f.StringEnv("token", "", EnvOpt{Name: "ENV_OPT_MISSING_TOKEN", Required: true}, "token")
+	if err = f.Parse([]string{"-token=abc"}); err != nil {
+		t.Error("the command line should satisfy a required environment variable, got ", err)
+	}
+}
@@ -0,0 +1,109 @@
+package flagfig
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The kinds of failure Parse and Collate report, to tell them apart with errors.Is:
+//
+//	err := flags.Parse(os.Args[1:])
+//	if errors.Is(err, flagfig.ErrMissingRequired) {
+//		flags.Usage()
+//	}
+//
+// Invalid values are reported as an *ErrInvalidValue instead, see errors.As
+var (
+	// ErrConfigFileNotFound is a configuration file, named by a layer or an AddConfigFile flag, that does not exist
+	ErrConfigFileNotFound = errors.New("configuration file not found")
+	// ErrUnknownKey is a configuration file key, or an environment variable with the AutomaticEnv prefix, that does not
+	// match any flag, when SetStrictConfig or SetStrictEnv is on. It is also a key outside the sections of a
+	// configuration file split into profiles, see SetProfile
+	ErrUnknownKey = errors.New("unknown key")
+	// ErrMissingRequired is a Required flag, or a required environment variable, that was not set
+	ErrMissingRequired = errors.New("missing required flag")
+	// ErrUnknownProfile is a profile, selected by SetProfile or the profile flag, that a configuration file split into
+	// sections has no section for
+	ErrUnknownProfile = errors.New("unknown profile")
+)
+
+// ErrInvalidValue is a value from a configuration file or the environment that its flag does not accept. Errors in
+// the values given on the command line are reported by the flag package, as they always have been, except with
+// CollectErrors
+type ErrInvalidValue struct {
+	// Flag is the name of the flag
+	Flag string
+	// Source is where the value came from, Detail says where exactly, as Origin does, except that it is the path of a
+	// configuration file
+	Source Source
+	Detail string
+	// Key is the key of a value from a configuration file, as written in the file. It differs from Flag for aliases and
+	// profile sections
+	Key string
+	// Value is the value as it was given, JSON encoded unless it is a string
+	Value string
+	Err   error
+}
+
+func (e *ErrInvalidValue) Error() string {
+	if e.Source == SourceFile && len(e.Key) != 0 {
+		key := fmt.Sprintf("key %q", e.Key)
+		if e.Key != e.Flag {
+			key += " (flag -" + e.Flag + ")"
+		}
+		return fmt.Sprintf("invalid value %q for %s in configuration file %s: %v", e.Value, key, e.Detail, e.Err)
+	}
+	from := e.Detail
+	switch e.Source {
+	case SourceFile:
+		from = "configuration file " + e.Detail
+	case SourceEnv:
+		from = "environment variable " + e.Detail
+	case SourceFlag:
+		from = "the command line"
+	}
+	return fmt.Sprintf("invalid value %q for flag -%s from %s: %v", e.Value, e.Flag, from, e.Err)
+}
+
+func (e *ErrInvalidValue) Unwrap() error {
+	return e.Err
+}
+
+// kindError is an error with a message of its own that is still one of the sentinels above to errors.Is
+type kindError struct {
+	kind    error
+	message string
+}
+
+func (e *kindError) Error() string {
+	return e.message
+}
+
+func (e *kindError) Is(target error) bool {
+	return target == e.kind
+}
+
+// errorOfKind formats the message of an error that errors.Is matches with kind
+func errorOfKind(kind error, format string, args ...interface{}) error {
+	return &kindError{kind: kind, message: fmt.Sprintf(format, args...)}
+}
+
+// joinedIs is errors.Is for error lists, true if any error in the list matches target
+func joinedIs(errs []error, target error) bool {
+	for _, err := range errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// joinedAs is errors.As for error lists, using the first error in the list that matches target
+func joinedAs(errs []error, target interface{}) bool {
+	for _, err := range errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package flagfig
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	path, cleanup := testTempFile(t)
+	defer cleanup()
+	cases := map[string]struct {
+		content string
+		setup   func(f *FlagfigSet)
+		kind    error
+	}{
+		"config file not found": {
+			setup: func(f *FlagfigSet) {
+				f.AddConfigLayer("missing", path+".missing")
+			},
+			kind: ErrConfigFileNotFound,
+		},
+		"unknown key": {
+			content: `{"colour": "red"}`,
+			setup: func(f *FlagfigSet) {
+				f.AddConfigLayer("base", path)
+				f.SetStrictConfig(true)
+			},
+			kind: ErrUnknownKey,
+		},
+		"missing required flag": {
+			setup: func(f *FlagfigSet) {
+				f.String("token", "", "", "token", Required())
+			},
+			kind: ErrMissingRequired,
+		},
+		"missing required environment variable": {
+			setup: func(f *FlagfigSet) {
+				f.StringEnv("token", "", EnvOpt{Name: "ERRORS_TOKEN", Required: true}, "token")
+			},
+			kind: ErrMissingRequired,
+		},
+	}
+	for caseName, c := range cases {
+		content := c.content
+		if len(content) == 0 {
+			content = "{}"
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		c.setup(f)
+		err := f.Parse([]string{})
+		if !errors.Is(err, c.kind) {
+			t.Errorf("case %s: expected %v, got %v", caseName, c.kind, err)
+		}
+	}
+}
+
+func TestErrInvalidValue(t *testing.T) {
+	path, cleanup := testTempFile(t)
+	defer cleanup()
+	if err := ioutil.WriteFile(path, []byte(`{"port": "eighty"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Setenv("ERRORS_PORT", "eighty")
+	defer func() { _ = os.Unsetenv("ERRORS_PORT") }()
+	cases := map[string]struct {
+		setup          func(f *FlagfigSet)
+		expectedSource Source
+		expectedDetail string
+		expectedKey    string
+	}{
+		"config file": {
+			setup: func(f *FlagfigSet) {
+				f.AddConfigLayer("", path)
+				f.Int("port", 0, "", "port")
+			},
+			expectedSource: SourceFile,
+			expectedDetail: path,
+			expectedKey:    "port",
+		},
+		"environment": {
+			setup: func(f *FlagfigSet) {
+				f.Int("port", 0, "ERRORS_PORT", "port")
+			},
+			expectedSource: SourceEnv,
+			expectedDetail: "ERRORS_PORT",
+		},
+	}
+	for caseName, c := range cases {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		c.setup(f)
+		err := f.Parse([]string{})
+		invalid, ok := err.(*ErrInvalidValue)
+		if !ok {
+			t.Errorf("case %s: expected an ErrInvalidValue, got %v", caseName, err)
+			continue
+		}
+		if invalid.Flag != "port" || invalid.Source != c.expectedSource || invalid.Detail != c.expectedDetail ||
+			invalid.Key != c.expectedKey || invalid.Value != "eighty" || invalid.Err == nil {
+			t.Errorf("case %s: unexpected %#v", caseName, invalid)
+		}
+	}
+}
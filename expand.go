@@ -0,0 +1,124 @@
+package flagfig
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// SetExpandValues turns variable expansion on or off for the CommandLine
+func SetExpandValues(expand bool) {
+	CommandLine.SetExpandValues(expand)
+}
+
+// SetExpandValues turns on an expansion pass that runs at the end of Collate. Any ${name} reference inside the value
+// of a string, string list or string map flag is replaced with the value of the flag with that name, or, if there is no such flag,
+// with the environment variable of that name. Unknown references expand to an empty string.
+// Referenced flags are expanded first, so references may be chained, but a cycle is reported as an error:
+//
+//	-data-dir=/var/lib/myapp -cache-dir='${data-dir}/cache' -log-file='${HOME}/myapp.log'
+//
+// Expansion is off by default, so values containing ${ are taken literally.
+func (f *FlagfigSet) SetExpandValues(expand bool) {
+	f.expandValues = expand
+}
+
+// expandAll replaces ${...} references in every string, string list and string map flag
+func (f *FlagfigSet) expandAll() (err error) {
+	expanded := make(map[string]string)
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		if err != nil {
+			return
+		}
+		switch f.flagTypes[fl.Name] {
+		case stringType, stringSliceType, stringMapType:
+			_, err = f.expandFlag(fl.Name, expanded, nil)
+		}
+	})
+	return
+}
+
+// expandFlag expands the flag named name, recording its final value in expanded.
+// resolving holds the chain of flags being expanded, which is how cycles are found
+func (f *FlagfigSet) expandFlag(name string, expanded map[string]string, resolving []string) (value string, err error) {
+	if value, ok := expanded[name]; ok {
+		return value, nil
+	}
+	for i, r := range resolving {
+		if r == name {
+			return "", fmt.Errorf("cycle in variable expansion: %s", strings.Join(append(resolving[i:], name), " -> "))
+		}
+	}
+	resolving = append(resolving, name)
+	lookup := func(ref string) (string, error) {
+		if f.FlagSet.Lookup(ref) != nil {
+			return f.expandFlag(ref, expanded, resolving)
+		}
+		v, _ := f.lookupEnv(ref)
+		return v, nil
+	}
+	// Set the Value directly, expanding is not the same as the user setting the flag. Lists and maps are expanded item
+	// by item, as their text would split again on any , or = that an item expands to
+	fl := f.FlagSet.Lookup(name)
+	switch v := fl.Value.(type) {
+	case *stringSliceValue:
+		items := make([]string, len(*v.p))
+		for i, item := range *v.p {
+			items[i], err = f.expandString(item, lookup)
+			if err != nil {
+				return "", err
+			}
+		}
+		*v.p = items
+		value = strings.Join(items, ",")
+	case *stringMapValue:
+		m := make(map[string]string, len(*v.p))
+		for k, item := range *v.p {
+			m[k], err = f.expandString(item, lookup)
+			if err != nil {
+				return "", err
+			}
+		}
+		*v.p = m
+		value = v.String()
+	default:
+		value, err = f.expandString(fl.Value.String(), lookup)
+		if err == nil {
+			err = fl.Value.Set(value)
+		}
+	}
+	if err != nil {
+		_, err = f.redactValue(name, value, err)
+		return "", fmt.Errorf("flag -%s, set by %s, cannot be expanded: %v", name, f.describeOrigin(name), err)
+	}
+	expanded[name] = value
+	return value, nil
+}
+
+// expandString replaces each ${ref} in s with the result of lookup
+func (f *FlagfigSet) expandString(s string, lookup func(ref string) (string, error)) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	sb := strings.Builder{}
+	for {
+		start := strings.Index(s, "${")
+		if start == -1 {
+			break
+		}
+		end := strings.Index(s[start:], "}")
+		if end == -1 {
+			break
+		}
+		end += start
+		sb.WriteString(s[:start])
+		v, err := lookup(s[start+2 : end])
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(v)
+		s = s[end+1:]
+	}
+	sb.WriteString(s)
+	return sb.String(), nil
+}
@@ -0,0 +1,73 @@
+package flagfig
+
+import (
+	"flag"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandValues(t *testing.T) {
+	_ = os.Setenv("EXPAND_HOME", "/home/me")
+	defer func() { _ = os.Unsetenv("EXPAND_HOME") }()
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetExpandValues(true)
+	cacheDir := f.String("cache-dir", "${data-dir}/cache", "", "cache directory")
+	dataDir := f.String("data-dir", "${root}/data", "", "data directory")
+	f.String("root", "/var/lib", "", "root directory")
+	logFile := f.String("log-file", "${EXPAND_HOME}/app.log", "", "log file")
+	paths := f.StringSlice("paths", []string{"${root}", "${MISSING_VARIABLE}x"}, "", "paths")
+	if err := f.Parse([]string{"-root=/srv"}); err != nil {
+		t.Fatal(err)
+	}
+	if *dataDir != "/srv/data" {
+		t.Error("data-dir should be /srv/data, is ", *dataDir)
+	}
+	if *cacheDir != "/srv/data/cache" {
+		t.Error("cache-dir should be /srv/data/cache, is ", *cacheDir)
+	}
+	if *logFile != "/home/me/app.log" {
+		t.Error("log-file should be /home/me/app.log, is ", *logFile)
+	}
+	if !reflect.DeepEqual(*paths, []string{"/srv", "x"}) {
+		t.Error("paths should be [/srv x], is ", *paths)
+	}
+}
+
+func TestExpandValuesMap(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetExpandValues(true)
+	f.String("dsn", "user=me,mode=ro", "", "dsn")
+	labels := f.StringMap("labels", map[string]string{"db": "${dsn}", "a,b": "c=d"}, "", "labels")
+	if err := f.Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"db": "user=me,mode=ro", "a,b": "c=d"}
+	if !reflect.DeepEqual(*labels, expected) {
+		t.Errorf("labels should be %v, is %v", expected, *labels)
+	}
+}
+
+func TestExpandValuesCycle(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetExpandValues(true)
+	f.String("a", "${b}", "", "a")
+	f.String("b", "${a}", "", "b")
+	err := f.Parse([]string{})
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Error("expected a cycle error, got ", err)
+	}
+}
+
+func TestExpandValuesOffByDefault(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.String("root", "/srv", "", "root")
+	dataDir := f.String("data-dir", "${root}/data", "", "data directory")
+	if err := f.Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if *dataDir != "${root}/data" {
+		t.Error("data-dir should not be expanded, is ", *dataDir)
+	}
+}
@@ -0,0 +1,40 @@
+// Package expvarflags publishes the running configuration of a FlagfigSet with expvar. It is kept apart from flagfig,
+// as importing expvar serves /debug/vars on http.DefaultServeMux, which programs should choose to do
+package expvarflags
+
+import (
+	"expvar"
+
+	"github.com/wojnosystems/flagfig"
+)
+
+// Publish publishes the running configuration of flags as the expvar variable name, so it shows up at /debug/vars
+// alongside the program's other variables, as in:
+//
+//	"config": {"checksum": "5e88...", "values": {"limit": 20, "region": "eu"}}
+//
+// values holds the flags named by flagNames, or every flag if there are none. Sensitive flags are always left out.
+// checksum is the Checksum of the Snapshot, so dashboards can tell which processes run the same configuration. Both
+// follow Reload. Like expvar.Publish, it panics if name is already published. Pass flagfig.CommandLine to publish the
+// CommandLine
+func Publish(name string, flags *flagfig.FlagfigSet, flagNames ...string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		s := flags.Snapshot()
+		values := s.Settings()
+		if len(flagNames) != 0 {
+			all := values
+			values = make(map[string]interface{}, len(flagNames))
+			for _, flagName := range flagNames {
+				if v, ok := all[flagName]; ok {
+					values[flagName] = v
+				}
+			}
+		}
+		for flagName := range values {
+			if s.Sensitive(flagName) {
+				delete(values, flagName)
+			}
+		}
+		return map[string]interface{}{"checksum": s.Checksum(), "values": values}
+	}))
+}
@@ -0,0 +1,50 @@
+package expvarflags
+
+import (
+	"encoding/json"
+	"expvar"
+	"flag"
+	"fmt"
+	"testing"
+
+	"github.com/wojnosystems/flagfig"
+)
+
+// publishRuns counts the runs of TestPublish, as an expvar name can only be published once, and go test -count
+// runs it again
+var publishRuns int
+
+func TestPublish(t *testing.T) {
+	publishRuns++
+	all := fmt.Sprintf("flagfig-test-all-%d", publishRuns)
+	some := fmt.Sprintf("flagfig-test-some-%d", publishRuns)
+	f := flagfig.NewFlagfigSet("test", flag.ContinueOnError)
+	f.Int("limit", 10, "", "limit")
+	f.String("region", "eu", "", "region")
+	f.String("password", "", "", "password", flagfig.Sensitive())
+	if err := f.Parse([]string{"-password=secret", "-limit=20"}); err != nil {
+		t.Fatal(err)
+	}
+	Publish(all, f)
+	Publish(some, f, "limit", "password")
+
+	cases := map[string]string{
+		all:  `{"limit":20,"region":"eu"}`,
+		some: `{"limit":20}`,
+	}
+	for name, expected := range cases {
+		var published struct {
+			Checksum string          `json:"checksum"`
+			Values   json.RawMessage `json:"values"`
+		}
+		if err := json.Unmarshal([]byte(expvar.Get(name).String()), &published); err != nil {
+			t.Fatal(err)
+		}
+		if string(published.Values) != expected {
+			t.Errorf("case %s: expected %s, got %s", name, expected, published.Values)
+		}
+		if published.Checksum != f.Snapshot().Checksum() {
+			t.Errorf("case %s: expected checksum %s, got %s", name, f.Snapshot().Checksum(), published.Checksum)
+		}
+	}
+}
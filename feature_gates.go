@@ -0,0 +1,110 @@
+package flagfig
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// FeatureGates are named switches that turn features on and off, all set by one flag, the way Kubernetes components
+// do it:
+//
+//	gates := flagfig.NewFeatureGates(map[string]bool{"NewScheduler": false, "FastPath": true})
+//	gates.AddFlag(flags, "feature-gates", "MYAPP_FEATURE_GATES", "turns features on and off")
+//	...
+//	if gates.Enabled("NewScheduler") {
+//
+// -feature-gates=NewScheduler=true,FastPath=false then turns them around. The environment variable takes the same
+// form, and configuration files may also use an object, as in {"feature-gates": {"NewScheduler": true}}. Enabled is
+// safe to call from any goroutine, and follows Reload
+type FeatureGates struct {
+	defaults map[string]bool
+	given    *map[string]string
+	// enabled holds the map[string]bool of every gate's current state. It is replaced, never changed
+	enabled atomic.Value
+}
+
+// NewFeatureGates creates the gates named in defaults, each on or off by default
+func NewFeatureGates(defaults map[string]bool) *FeatureGates {
+	g := &FeatureGates{defaults: make(map[string]bool, len(defaults))}
+	for name, on := range defaults {
+		g.defaults[name] = on
+	}
+	g.enabled.Store(g.defaults)
+	return g
+}
+
+// AddFlag defines the flag that sets the gates. Its usage lists the gates and their defaults. Parse fails if the flag
+// names a gate that does not exist, or sets one to something other than true or false
+func (g *FeatureGates) AddFlag(flags *FlagfigSet, name, envName, usage string, opts ...FlagOption) {
+	g.given = flags.StringMap(name, nil, envName, usage+g.usage(), opts...)
+	flags.OnParsed(func(*FlagfigSet) error {
+		return g.update()
+	})
+}
+
+// usage lists the gates for the usage of the flag
+func (g *FeatureGates) usage() string {
+	lines := make([]string, 0, len(g.defaults))
+	for _, name := range g.Known() {
+		lines = append(lines, fmt.Sprintf("\n%s=true|false (default %t)", name, g.defaults[name]))
+	}
+	return "\nKnown gates:" + strings.Join(lines, "")
+}
+
+// update works out the state of every gate from the flag
+func (g *FeatureGates) update() error {
+	enabled := make(map[string]bool, len(g.defaults))
+	for name, on := range g.defaults {
+		enabled[name] = on
+	}
+	names := make([]string, 0, len(*g.given))
+	for name := range *g.given {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := g.defaults[name]; !ok {
+			return fmt.Errorf("unknown feature gate %q", name)
+		}
+		on, err := strconv.ParseBool((*g.given)[name])
+		if err != nil {
+			return fmt.Errorf("feature gate %s is %q, but must be true or false", name, (*g.given)[name])
+		}
+		enabled[name] = on
+	}
+	g.enabled.Store(enabled)
+	return nil
+}
+
+// Enabled is whether the gate is on. Like asking for a flag that was never defined, it panics for a gate that does not
+// exist, so typos are caught
+func (g *FeatureGates) Enabled(name string) bool {
+	on, ok := g.enabled.Load().(map[string]bool)[name]
+	if !ok {
+		panic(fmt.Sprintf("flagfig: unknown feature gate %q", name))
+	}
+	return on
+}
+
+// Known lists the names of the gates, sorted
+func (g *FeatureGates) Known() []string {
+	names := make([]string, 0, len(g.defaults))
+	for name := range g.defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String lists every gate's state, as the flag would set them, for logging
+func (g *FeatureGates) String() string {
+	enabled := g.enabled.Load().(map[string]bool)
+	pairs := make([]string, 0, len(enabled))
+	for _, name := range g.Known() {
+		pairs = append(pairs, name+"="+strconv.FormatBool(enabled[name]))
+	}
+	return strings.Join(pairs, ",")
+}
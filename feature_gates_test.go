@@ -0,0 +1,98 @@
+package flagfig
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFeatureGates(t *testing.T) {
+	path, cleanup := testTempFile(t)
+	defer cleanup()
+	if err := ioutil.WriteFile(path, []byte(`{"feature-gates": {"Alpha": true, "GA": false}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Setenv("ENV_FEATURE_GATES", "Alpha=true")
+	defer func() { _ = os.Unsetenv("ENV_FEATURE_GATES") }()
+	cases := map[string]struct {
+		args     []string
+		config   bool
+		expected string
+		err      string
+	}{
+		"defaults": {
+			args:     []string{},
+			expected: "Alpha=false,Beta=true,GA=true",
+		},
+		"command line": {
+			args:     []string{"-feature-gates=Alpha=true,GA=false"},
+			expected: "Alpha=true,Beta=true,GA=false",
+		},
+		"config file": {
+			args:     []string{},
+			config:   true,
+			expected: "Alpha=true,Beta=true,GA=false",
+		},
+		"unknown": {
+			args: []string{"-feature-gates=Gamma=true"},
+			err:  `unknown feature gate "Gamma"`,
+		},
+		"not a bool": {
+			args: []string{"-feature-gates=Alpha=maybe"},
+			err:  `feature gate Alpha is "maybe", but must be true or false`,
+		},
+	}
+	for caseName, c := range cases {
+		gates := NewFeatureGates(map[string]bool{"Alpha": false, "Beta": true, "GA": true})
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		if c.config {
+			f.AddConfigLayer("base", path)
+		}
+		gates.AddFlag(f, "feature-gates", "", "turns features on and off")
+		err := f.Parse(c.args)
+		if len(c.err) != 0 {
+			if err == nil || err.Error() != c.err {
+				t.Errorf("case %s: expected %q, got %v", caseName, c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %s: unexpected error: %s", caseName, err)
+		} else if gates.String() != c.expected {
+			t.Errorf("case %s: expected %s, got %s", caseName, c.expected, gates.String())
+		}
+	}
+
+	gates := NewFeatureGates(map[string]bool{"Alpha": false})
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	gates.AddFlag(f, "feature-gates", "ENV_FEATURE_GATES", "turns features on and off")
+	if err := f.Parse([]string{}); err != nil || !gates.Enabled("Alpha") {
+		t.Errorf("expected the environment to turn Alpha on, got %v and %t", err, gates.Enabled("Alpha"))
+	}
+	out := &bytes.Buffer{}
+	f.SetOutput(out)
+	f.PrintDefaults()
+	if !strings.Contains(out.String(), "Known gates:\n    \tAlpha=true|false (default false)") {
+		t.Error("expected the usage to list the gates, got ", out.String())
+	}
+
+	gates = NewFeatureGates(map[string]bool{"Beta": false})
+	f = NewFlagfigSet("test", flag.ContinueOnError)
+	f.AddConfigLayer("base", path)
+	gates.AddFlag(f, "feature-gates", "", "turns features on and off")
+	if err := ioutil.WriteFile(path, []byte(`{}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Parse([]string{}); err != nil || gates.Enabled("Beta") {
+		t.Errorf("expected Beta to start off, got %v and %t", err, gates.Enabled("Beta"))
+	}
+	if err := ioutil.WriteFile(path, []byte(`{"feature-gates": {"Beta": true}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Reload(); err != nil || !gates.Enabled("Beta") {
+		t.Errorf("expected Reload to turn Beta on, got %v and %t", err, gates.Enabled("Beta"))
+	}
+}
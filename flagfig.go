@@ -19,6 +19,7 @@ See LICENSE file for the full license
 	Define flags using:
 		flagfig.String(), flagfig.Int(), flagfig.Float64(), flagfig.Duration(), flagfig.Uint(), flagfig.Uint64()
 		flagfig.Bool(),flagfig.Int64()
+		flagfig.StringSlice(), flagfig.StringMap(), flagfig.JSONVar()
 	then follow that with:
 		flagfig.Parse()
 
@@ -63,7 +64,7 @@ See LICENSE file for the full license
         duration: 10000000000
 	}
 
-	But you cannot use a file like this:
+	But you cannot use a file like this, unless flag1 was defined with StringMap or JSONVar:
 
 	{
 		flag1: {
@@ -71,6 +72,12 @@ See LICENSE file for the full license
 		}
 	}
 
+	Likewise, arrays are only accepted for StringSlice flags. Environment variables for these complex flags may also be
+	given as JSON documents, such as: LABELS='{"a":"b"}'
+
+	JSON files may contain // line and block comments. Files named *.yaml or *.yml are read as YAML instead, with the same
+	structure. Use WriteSampleConfig to write a commented configuration file with every flag's default.
+
 
 	Hack Alert
 
@@ -83,6 +90,13 @@ See LICENSE file for the full license
 	If you do not define an environment variable name, it will not be parsed. This allows you to not include parsing
 	an environment variable if you do not with to use it. If you don't want to parse it, just toss in an empty string ("")
 
+	If you'd rather not name every variable, call AutomaticEnv("MYAPP") and flags with an empty environment name will be
+	read from MYAPP_FLAG_NAME. Use SetEnvKeyReplacer to control how flag names are turned into environment names.
+
+	The empty-string convention is easy to get wrong, so each constructor also has an *Env variant, such as StringEnv,
+	which takes an EnvOpt describing the variable name, whether it is required, the list separator, and whether an
+	empty variable counts as unset.
+
 	That's a stupid name...
 
 	flagfig is a portmanteau of flag and config... If you have to explain it, I guess...
@@ -91,13 +105,17 @@ package flagfig
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"io/ioutil"
-	"log"
+	"io"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
 )
 
@@ -112,22 +130,189 @@ const (
 	uintType
 	uint64Type
 	durationType
+	stringSliceType
+	stringMapType
+	jsonType
 )
 
 // FlagurationSet
 type FlagfigSet struct {
 	flag.FlagSet
-	configFilePaths []*string
-	flagTypes       map[string]int
-	envNames        map[string]string
+	configLayers   []configLayer
+	flagTypes      map[string]int
+	envOpts        map[string]EnvOpt
+	automaticEnv   bool
+	envPrefix      string
+	envKeyReplacer *strings.Replacer
+	dotEnv         map[string]string
+	envIgnoreCase  bool
+	// environ is the process environment, read once by Collate for case-insensitive lookups, and nil otherwise
+	environ         map[string]string
+	expandValues    bool
+	allowedSources  map[string][]Source
+	origins         map[string]origin
+	trace           io.Writer
+	traceLog        map[string][]traceCandidate
+	mergeStrategies map[string]MergeStrategy
+	defaultFrom     map[string]string
+	profilesOn      bool
+	profile         string
+	profileFlagName string
+	profileEnvName  string
+	required        map[string]bool
+	minimums        map[string]float64
+	maximums        map[string]float64
+	patterns        map[string]pattern
+	notEmpty        map[string]bool
+	groups          []flagGroup
+	strictConfig    bool
+	onUnknownKey    func(path, key string)
+	strictEnv       bool
+	onUnknownEnv    func(name string)
+	sensitive       map[string]bool
+	validateOnly    bool
+	validateFlag    *bool
+	deprecations    map[string]deprecation
+	warnOut         io.Writer
+	parsedHooks     []func(*FlagfigSet) error
+	usageTemplate   *template.Template
+	flagGroups      map[string]string
+	groupOrder      []string
+	groupSeen       map[string]bool
+	enums           map[string][]string
+	valueHints      map[string]valueHint
+	// internalFlags control flagfig itself, such as AddConfigFile flags, so they cannot be set by configuration files
+	internalFlags map[string]bool
+	listKeysFlag  *bool
+	// usageWidthSetting is the width given to SetUsageWidth
+	usageWidthSetting int
+	usageColor        ColorMode
+	// order lists the flags in the order they were defined
+	order    []string
+	unsorted bool
+	// aliases maps other command line names to the real flag names, see Short
+	aliases       map[string]string
+	shorts        map[string]string
+	combinedShort bool
+	gnuStyle      bool
+	aliasNames    map[string][]string
+	configAliases map[string]string
+	// keyValueOverrides takes name=value positional arguments as flags, see SetKeyValueOverrides
+	keyValueOverrides bool
+	// interspersed keeps scanning for flags after positional arguments, see SetInterspersed
+	interspersed bool
+	// ignoreUnknown skips undefined flags, collecting them in unknownFlags, see SetIgnoreUnknownFlags
+	ignoreUnknown bool
+	unknownFlags  []string
+	// passthrough is the arguments after "--", see PassthroughArgs
+	passthrough []string
+	// promptMissing asks for missing required flags on promptIn, or standard input if nil, see PromptMissing
+	promptMissing bool
+	promptIn      io.Reader
+	// argFiles expands @path arguments, see SetArgFiles
+	argFiles bool
+	// inherited flags belong to the FlagfigSet of a parent Command, see Command
+	inherited map[string]*FlagfigSet
+	// positionals are the arguments declared with Positional
+	positionals []*PositionalArg
+	// minArgs and maxArgs limit the number of positional arguments, maxArgs is negative when there is no limit
+	minArgs int
+	maxArgs int
+	// bindFlagSeparator and bindEnvSeparator join the names of nested structs, see SetBindSeparators
+	bindFlagSeparator string
+	bindEnvSeparator  string
+	// decodeHooks convert values for Unmarshal and Bind, see AddDecodeHook
+	decodeHooks []DecodeHook
+	// hidden flags are left out of usage and documentation, see Hidden
+	hidden map[string]bool
+	// definePrefix and defineEnvPrefix are put in front of the names of the flags being defined, see Prefixed
+	definePrefix    string
+	defineEnvPrefix string
+	// mergeCommandLine holds the command line values of MergeAppend flags, which hold every source after Collate, so
+	// that Reload can apply them again
+	mergeCommandLine map[string]string
+	// reloadFlags, while Reload runs, are the flags that were given on the command line. The FlagSet counts every
+	// flag that was set as visited, whatever the source
+	reloadFlags map[string]bool
+	// snapshot holds the *Snapshot made by the last successful Collate
+	snapshot atomic.Value
+	// watchInterval and watchJitter are how often Watch looks for changes, see SetWatchInterval
+	watchInterval time.Duration
+	watchJitter   time.Duration
+	// hooksRun is how many of the OnParsed hooks have run, so Recollate can run those added since
+	hooksRun int
+	// reloadMu keeps Reload and the changes made through AdminHandler from running at the same time
+	reloadMu sync.Mutex
+	// reloadable flags may be changed through AdminHandler, see Reloadable
+	reloadable map[string]bool
+	// reloadHooks are told what changed, see OnReload
+	reloadHooks []func(f *FlagfigSet, changes []Change)
+	// history holds the Snapshots of past configurations, oldest first, up to historySize of them, see History
+	history     []*Snapshot
+	historySize int
+	historyMu   sync.Mutex
+	// configCache is the directory keeping copies of the configuration files, see SetConfigCache
+	configCache string
+	// collectErrors keeps Parse and Collate going after a problem, see CollectErrors
+	collectErrors bool
+	// collected holds the problems found on the command line, for Collate to add its own to
+	collected *problems
+	// logger receives the warnings about configuration files, see SetLogger
+	logger Logger
+	// warnInvalidConfig logs invalid configuration file values rather than failing, see SetWarnInvalidConfig
+	warnInvalidConfig bool
+	// definedAt says where each flag was defined, and defining is the Nester registering its flags, if any, for
+	// DuplicateFlagError
+	definedAt map[string]string
+	defining  Nester
+	// revealSensitive shows the values of Sensitive flags, see UnsafeRevealSensitive
+	revealSensitive bool
+	// deferFinish makes Collate leave validation and the OnParsed hooks to finish, for commands with subcommands,
+	// whose flags may still be given after the subcommand's name
+	deferFinish bool
 }
 
 func NewFlagfigSet(name string, errorHandling flag.ErrorHandling) *FlagfigSet {
 	fs := &FlagfigSet{}
+	if errorHandling == CollectErrors {
+		// The flag package does not know this one, and would carry on silently after an error
+		fs.collectErrors = true
+		errorHandling = flag.ContinueOnError
+	}
 	fs.FlagSet = *flag.NewFlagSet(name, errorHandling)
-	fs.configFilePaths = make([]*string, 0, 1)
-	fs.envNames = make(map[string]string)
+	fs.configLayers = make([]configLayer, 0, 1)
+	fs.envOpts = make(map[string]EnvOpt)
 	fs.flagTypes = make(map[string]int)
+	fs.envKeyReplacer = defaultEnvKeyReplacer
+	fs.dotEnv = make(map[string]string)
+	fs.allowedSources = make(map[string][]Source)
+	fs.origins = make(map[string]origin)
+	fs.mergeStrategies = make(map[string]MergeStrategy)
+	fs.defaultFrom = make(map[string]string)
+	fs.required = make(map[string]bool)
+	fs.minimums = make(map[string]float64)
+	fs.maximums = make(map[string]float64)
+	fs.patterns = make(map[string]pattern)
+	fs.notEmpty = make(map[string]bool)
+	fs.sensitive = make(map[string]bool)
+	fs.deprecations = make(map[string]deprecation)
+	fs.flagGroups = make(map[string]string)
+	fs.groupSeen = make(map[string]bool)
+	fs.enums = make(map[string][]string)
+	fs.valueHints = make(map[string]valueHint)
+	fs.internalFlags = make(map[string]bool)
+	fs.aliases = make(map[string]string)
+	fs.shorts = make(map[string]string)
+	fs.aliasNames = make(map[string][]string)
+	fs.configAliases = make(map[string]string)
+	fs.inherited = make(map[string]*FlagfigSet)
+	fs.maxArgs = -1
+	fs.mergeCommandLine = make(map[string]string)
+	fs.definedAt = make(map[string]string)
+	fs.reloadable = make(map[string]bool)
+	fs.hidden = make(map[string]bool)
+	fs.bindFlagSeparator, fs.bindEnvSeparator = ".", "_"
+	fs.FlagSet.Usage = fs.defaultUsage
 	return fs
 }
 
@@ -136,9 +321,35 @@ func Parse() {
 }
 
 func (f *FlagfigSet) Parse(arguments []string) (err error) {
-	err = f.FlagSet.Parse(arguments)
+	if f.argFiles {
+		arguments, err = f.expandArgFiles(arguments, nil)
+		if err != nil {
+			return f.failParse(err)
+		}
+	}
+	args, err := f.scanArgs(arguments)
+	if err != nil {
+		return f.failParse(err)
+	}
+	f.repeatable(true)
+	err = f.parseCommandLine(args)
+	f.repeatable(false)
+	if err != nil {
+		// The flag package has already reported it
+		return
+	}
+	err = f.Collate()
+	if f.listKeysFlag != nil && *f.listKeysFlag {
+		return f.finishListKeys(err)
+	}
+	if err != nil {
+		return f.failParse(err)
+	}
+	if f.validateOnlyRequested() {
+		err = f.finishValidateOnly()
+	}
 	if err == nil {
-		err = f.Collate()
+		err = f.parsePositionals()
 	}
 	return
 }
@@ -151,7 +362,10 @@ func AddConfigFile(name, usage string) *string {
 }
 func (f *FlagfigSet) AddConfigFile(name, usage string) *string {
 	p := new(string)
-	f.configFilePaths = append(f.configFilePaths, p)
+	f.checkDuplicate(name)
+	f.configLayers = append(f.configLayers, configLayer{path: p, flagName: name})
+	f.internalFlags[name] = true
+	f.order = append(f.order, name)
 	f.FlagSet.StringVar(p, name, "", usage)
 	return p
 }
@@ -159,194 +373,558 @@ func (f *FlagfigSet) AddConfigFile(name, usage string) *string {
 // Collate combines the values from config files, environment variables, and flags as a single value.
 // Assumes that the command flags are already parsed
 func (f *FlagfigSet) Collate() (err error) {
+	p := f.collected
+	f.collected = nil
+	if p == nil {
+		p = f.newProblems()
+	}
+	if f.trace != nil {
+		f.traceLog = make(map[string][]traceCandidate)
+		defer f.writeTrace()
+	}
+	if f.envIgnoreCase {
+		f.environ = environMap()
+		defer func() { f.environ = nil }()
+	}
 	unVisitedFlags := make(map[string]*flag.Flag)
 	allFlags := make(map[string]bool)
 	f.FlagSet.VisitAll(func(fl *flag.Flag) {
 		allFlags[fl.Name] = false
+		f.origins[fl.Name] = origin{source: SourceDefault}
 	})
 	f.FlagSet.Visit(func(fl *flag.Flag) {
+		if f.reloadFlags != nil && !f.reloadFlags[fl.Name] {
+			return
+		}
 		allFlags[fl.Name] = true
+		f.origins[fl.Name] = origin{source: SourceFlag}
+		f.traceCandidate(fl.Name, SourceFlag, "", fl.Value.String())
+		if err == nil && !f.sourceAllowed(fl.Name, SourceFlag) {
+			err = p.add(fmt.Errorf("flag -%s may not be set on the command line", fl.Name))
+		}
 	})
+	if err != nil {
+		return
+	}
+	// Flags that merge need every source, so the command line value is set aside and applied again, last
+	commandLine, err := f.prepareMerges(allFlags)
+	if err = p.add(err); err != nil {
+		return
+	}
 	for name, visited := range allFlags {
-		if !visited {
+		_, merging := commandLine[name]
+		if parent, ok := f.inherited[name]; ok && !visited {
+			// The parent command resolved these already
+			f.origins[name] = parent.origins[name]
+		} else if !visited || merging {
 			unVisitedFlags[name] = f.FlagSet.Lookup(name)
 		}
 	}
 
-	err = f.readConfigurationFiles(unVisitedFlags)
+	err = f.readConfigurationFiles(unVisitedFlags, p)
 	if err != nil {
 		return
 	}
 
-	for _, fl := range unVisitedFlags {
-		// Find the Env value
-		envVal := ""
-		// Blank envName means skip ENV lookup, for safety
-		if envName, ok := f.envNames[fl.Name]; ok {
-			envVal = os.Getenv(envName)
-			if len(envVal) != 0 {
-				err = f.FlagSet.Set(fl.Name, envVal)
+	err = f.readEnvironment(unVisitedFlags, allFlags, p)
+	if err != nil {
+		return
+	}
+	err = p.add(f.checkUnknownEnv())
+	if err != nil {
+		return
+	}
+	if f.trace != nil {
+		// The command line won for these, but the environment was still a candidate
+		for name, visited := range allFlags {
+			if _, merging := commandLine[name]; merging {
+				continue
+			}
+			if envName := f.envNameFor(name); visited && len(envName) != 0 {
+				if envVal, found := f.lookupFlagEnv(name, envName); found {
+					f.traceCandidate(name, SourceEnv, envName, envVal)
+				}
 			}
 		}
+	}
+
+	f.finishMerges(commandLine)
+
+	err = p.add(f.applyDeprecations())
+	if err != nil {
+		return
+	}
+
+	err = p.add(f.applyDefaultFrom())
+	if err != nil {
+		return
+	}
+
+	if f.expandValues {
+		err = p.add(f.expandAll())
+		if err != nil {
+			return
+		}
+	}
+	// Reload may run long after startup, with no one at the terminal to answer
+	if f.promptMissing && f.reloadFlags == nil {
+		err = p.add(f.promptRequired())
+		if err != nil {
+			return
+		}
+	}
+	if f.deferFinish {
+		return p.err()
+	}
+	err = p.add(f.validate())
+	if err == nil {
+		err = p.err()
+	}
+	if err != nil {
+		return
+	}
+	return f.finish()
+}
 
+// finish runs the OnParsed hooks once the flags are valid, and publishes their values
+func (f *FlagfigSet) finish() (err error) {
+	err = f.runParsedHooks()
+	if err != nil {
+		return
 	}
+	f.hooksRun = len(f.parsedHooks)
+	f.publish()
 	return
 }
 
-func Bool(name string, defaultValue bool, envName, usage string) *bool {
-	return CommandLine.Bool(name, defaultValue, envName, usage)
+func Bool(name string, defaultValue bool, envName, usage string, opts ...FlagOption) *bool {
+	return CommandLine.Bool(name, defaultValue, envName, usage, opts...)
+}
+
+func (f *FlagfigSet) Bool(name string, defaultValue bool, envName, usage string, opts ...FlagOption) *bool {
+	return f.BoolEnv(name, defaultValue, envOptFromName(envName), usage, opts...)
+}
+
+func BoolEnv(name string, defaultValue bool, env EnvOpt, usage string, opts ...FlagOption) *bool {
+	return CommandLine.BoolEnv(name, defaultValue, env, usage, opts...)
 }
 
-func (f *FlagfigSet) Bool(name string, defaultValue bool, envName, usage string) *bool {
+// BoolEnv is the same as Bool, but the environment handling is described with an EnvOpt
+func (f *FlagfigSet) BoolEnv(name string, defaultValue bool, env EnvOpt, usage string, opts ...FlagOption) *bool {
 	p := new(bool)
-	f.envNames[name] = envName
-	f.flagTypes[name] = boolType
+	name = f.register(name, boolType, env)
 	f.FlagSet.BoolVar(p, name, defaultValue, usage)
+	f.applyOptions(name, opts)
 	return p
 }
 
-func String(name, defaultValue, envName, usage string) *string {
-	return CommandLine.String(name, defaultValue, envName, usage)
+func String(name, defaultValue, envName, usage string, opts ...FlagOption) *string {
+	return CommandLine.String(name, defaultValue, envName, usage, opts...)
 }
 
-func (f *FlagfigSet) String(name, defaultValue, envName, usage string) *string {
+func (f *FlagfigSet) String(name, defaultValue, envName, usage string, opts ...FlagOption) *string {
+	return f.StringEnv(name, defaultValue, envOptFromName(envName), usage, opts...)
+}
+
+func StringEnv(name, defaultValue string, env EnvOpt, usage string, opts ...FlagOption) *string {
+	return CommandLine.StringEnv(name, defaultValue, env, usage, opts...)
+}
+
+// StringEnv is the same as String, but the environment handling is described with an EnvOpt
+func (f *FlagfigSet) StringEnv(name, defaultValue string, env EnvOpt, usage string, opts ...FlagOption) *string {
 	p := new(string)
-	f.envNames[name] = envName
-	f.flagTypes[name] = stringType
+	name = f.register(name, stringType, env)
 	f.FlagSet.StringVar(p, name, defaultValue, usage)
+	f.applyOptions(name, opts)
 	return p
 }
 
-func Int(name string, defaultValue int, envName, usage string) *int {
-	return CommandLine.Int(name, defaultValue, envName, usage)
+func Int(name string, defaultValue int, envName, usage string, opts ...FlagOption) *int {
+	return CommandLine.Int(name, defaultValue, envName, usage, opts...)
 }
-func (f *FlagfigSet) Int(name string, defaultValue int, envName, usage string) *int {
+func (f *FlagfigSet) Int(name string, defaultValue int, envName, usage string, opts ...FlagOption) *int {
+	return f.IntEnv(name, defaultValue, envOptFromName(envName), usage, opts...)
+}
+
+func IntEnv(name string, defaultValue int, env EnvOpt, usage string, opts ...FlagOption) *int {
+	return CommandLine.IntEnv(name, defaultValue, env, usage, opts...)
+}
+
+// IntEnv is the same as Int, but the environment handling is described with an EnvOpt
+func (f *FlagfigSet) IntEnv(name string, defaultValue int, env EnvOpt, usage string, opts ...FlagOption) *int {
 	p := new(int)
-	f.envNames[name] = envName
-	f.flagTypes[name] = intType
+	name = f.register(name, intType, env)
 	f.FlagSet.IntVar(p, name, defaultValue, usage)
+	f.applyOptions(name, opts)
 	return p
 }
 
-func Float64(name string, defaultValue float64, envName, usage string) *float64 {
-	return CommandLine.Float64(name, defaultValue, envName, usage)
+func Float64(name string, defaultValue float64, envName, usage string, opts ...FlagOption) *float64 {
+	return CommandLine.Float64(name, defaultValue, envName, usage, opts...)
 }
-func (f *FlagfigSet) Float64(name string, defaultValue float64, envName, usage string) *float64 {
+func (f *FlagfigSet) Float64(name string, defaultValue float64, envName, usage string, opts ...FlagOption) *float64 {
+	return f.Float64Env(name, defaultValue, envOptFromName(envName), usage, opts...)
+}
+
+func Float64Env(name string, defaultValue float64, env EnvOpt, usage string, opts ...FlagOption) *float64 {
+	return CommandLine.Float64Env(name, defaultValue, env, usage, opts...)
+}
+
+// Float64Env is the same as Float64, but the environment handling is described with an EnvOpt
+func (f *FlagfigSet) Float64Env(name string, defaultValue float64, env EnvOpt, usage string, opts ...FlagOption) *float64 {
 	p := new(float64)
-	f.envNames[name] = envName
-	f.flagTypes[name] = floatType
+	name = f.register(name, floatType, env)
 	f.FlagSet.Float64Var(p, name, defaultValue, usage)
+	f.applyOptions(name, opts)
 	return p
 }
 
-func Int64(name string, defaultValue int64, envName, usage string) *int64 {
-	return CommandLine.Int64(name, defaultValue, envName, usage)
+func Int64(name string, defaultValue int64, envName, usage string, opts ...FlagOption) *int64 {
+	return CommandLine.Int64(name, defaultValue, envName, usage, opts...)
 }
 
-func (f *FlagfigSet) Int64(name string, defaultValue int64, envName, usage string) *int64 {
+func (f *FlagfigSet) Int64(name string, defaultValue int64, envName, usage string, opts ...FlagOption) *int64 {
+	return f.Int64Env(name, defaultValue, envOptFromName(envName), usage, opts...)
+}
+
+func Int64Env(name string, defaultValue int64, env EnvOpt, usage string, opts ...FlagOption) *int64 {
+	return CommandLine.Int64Env(name, defaultValue, env, usage, opts...)
+}
+
+// Int64Env is the same as Int64, but the environment handling is described with an EnvOpt
+func (f *FlagfigSet) Int64Env(name string, defaultValue int64, env EnvOpt, usage string, opts ...FlagOption) *int64 {
 	p := new(int64)
-	f.envNames[name] = envName
-	f.flagTypes[name] = int64Type
+	name = f.register(name, int64Type, env)
 	f.FlagSet.Int64Var(p, name, defaultValue, usage)
+	f.applyOptions(name, opts)
 	return p
 }
 
-func Uint(name string, defaultValue uint, envName, usage string) *uint {
-	return CommandLine.Uint(name, defaultValue, envName, usage)
+func Uint(name string, defaultValue uint, envName, usage string, opts ...FlagOption) *uint {
+	return CommandLine.Uint(name, defaultValue, envName, usage, opts...)
+}
+
+func (f *FlagfigSet) Uint(name string, defaultValue uint, envName, usage string, opts ...FlagOption) *uint {
+	return f.UintEnv(name, defaultValue, envOptFromName(envName), usage, opts...)
+}
+
+func UintEnv(name string, defaultValue uint, env EnvOpt, usage string, opts ...FlagOption) *uint {
+	return CommandLine.UintEnv(name, defaultValue, env, usage, opts...)
 }
 
-func (f *FlagfigSet) Uint(name string, defaultValue uint, envName, usage string) *uint {
+// UintEnv is the same as Uint, but the environment handling is described with an EnvOpt
+func (f *FlagfigSet) UintEnv(name string, defaultValue uint, env EnvOpt, usage string, opts ...FlagOption) *uint {
 	p := new(uint)
-	f.envNames[name] = envName
-	f.flagTypes[name] = uintType
+	name = f.register(name, uintType, env)
 	f.FlagSet.UintVar(p, name, defaultValue, usage)
+	f.applyOptions(name, opts)
 	return p
 }
 
-func Uint64(name string, defaultValue uint64, envName, usage string) *uint64 {
-	return CommandLine.Uint64(name, defaultValue, envName, usage)
+func Uint64(name string, defaultValue uint64, envName, usage string, opts ...FlagOption) *uint64 {
+	return CommandLine.Uint64(name, defaultValue, envName, usage, opts...)
+}
+
+func (f *FlagfigSet) Uint64(name string, defaultValue uint64, envName, usage string, opts ...FlagOption) *uint64 {
+	return f.Uint64Env(name, defaultValue, envOptFromName(envName), usage, opts...)
 }
 
-func (f *FlagfigSet) Uint64(name string, defaultValue uint64, envName, usage string) *uint64 {
+func Uint64Env(name string, defaultValue uint64, env EnvOpt, usage string, opts ...FlagOption) *uint64 {
+	return CommandLine.Uint64Env(name, defaultValue, env, usage, opts...)
+}
+
+// Uint64Env is the same as Uint64, but the environment handling is described with an EnvOpt
+func (f *FlagfigSet) Uint64Env(name string, defaultValue uint64, env EnvOpt, usage string, opts ...FlagOption) *uint64 {
 	p := new(uint64)
-	f.envNames[name] = envName
-	f.flagTypes[name] = uint64Type
+	name = f.register(name, uint64Type, env)
 	f.FlagSet.Uint64Var(p, name, defaultValue, usage)
+	f.applyOptions(name, opts)
 	return p
 }
 
-func Duration(name string, defaultValue time.Duration, envName, usage string) *time.Duration {
-	return CommandLine.Duration(name, defaultValue, envName, usage)
+func Duration(name string, defaultValue time.Duration, envName, usage string, opts ...FlagOption) *time.Duration {
+	return CommandLine.Duration(name, defaultValue, envName, usage, opts...)
+}
+
+func (f *FlagfigSet) Duration(name string, defaultValue time.Duration, envName, usage string, opts ...FlagOption) *time.Duration {
+	return f.DurationEnv(name, defaultValue, envOptFromName(envName), usage, opts...)
 }
 
-func (f *FlagfigSet) Duration(name string, defaultValue time.Duration, envName, usage string) *time.Duration {
+func DurationEnv(name string, defaultValue time.Duration, env EnvOpt, usage string, opts ...FlagOption) *time.Duration {
+	return CommandLine.DurationEnv(name, defaultValue, env, usage, opts...)
+}
+
+// DurationEnv is the same as Duration, but the environment handling is described with an EnvOpt
+func (f *FlagfigSet) DurationEnv(name string, defaultValue time.Duration, env EnvOpt, usage string, opts ...FlagOption) *time.Duration {
 	p := new(time.Duration)
-	f.envNames[name] = envName
-	f.flagTypes[name] = durationType
+	name = f.register(name, durationType, env)
 	f.FlagSet.DurationVar(p, name, defaultValue, usage)
+	f.applyOptions(name, opts)
 	return p
 }
 
 // readConfigurationFiles in order and records the values, overriding each in turn
 // Files are read just once and only the final value is stored
-func (f *FlagfigSet) readConfigurationFiles(unvisitedFlags map[string]*flag.Flag) (err error) {
-	for _, filePath := range f.configFilePaths {
+func (f *FlagfigSet) readConfigurationFiles(unvisitedFlags map[string]*flag.Flag, p *problems) (err error) {
+	var failures ParseErrors
+	for _, layer := range f.configLayers {
+		filePath := layer.path
 		if filePath != nil && len(*filePath) != 0 {
-			dat, err := ioutil.ReadFile(*filePath)
-			if err != nil {
-				panic(err)
+			jsonDat, err := f.loadConfigFile(*filePath, layer.missing)
+			if f.skipMissing(layer, err) {
+				continue
+			}
+			var readErr *configReadError
+			if errors.Is(err, ErrConfigFileNotFound) || errors.As(err, &readErr) {
+				if err = p.add(err); err != nil {
+					return err
+				}
+				continue
 			}
-			var jsonDat map[string]interface{}
-			err = json.Unmarshal(dat, &jsonDat)
 			if err != nil {
 				// Skip this file
-				log.Printf("Unable to JSON Decode file: '%s' because: %s", *filePath, err)
+				f.logf("Unable to decode file: '%s' because: %s", *filePath, err)
 			} else {
 				// Process file's contents
-				for key, val := range jsonDat {
+				// keys are the keys as written, where they differ from the names of the flags
+				keys := make(map[string]string)
+				jsonDat, err = f.applyProfile(layer, jsonDat, keys)
+				if err != nil {
+					if err = p.add(err); err != nil {
+						return err
+					}
+					continue
+				}
+				f.applyConfigAliases(jsonDat, keys)
+				err = p.add(f.checkUnknownKeys(layer, jsonDat))
+				if err != nil {
+					return err
+				}
+				names := make([]string, 0, len(jsonDat))
+				for key := range jsonDat {
+					names = append(names, key)
+				}
+				sort.Strings(names)
+				for _, key := range names {
+					val := jsonDat[key]
+					written, ok := keys[key]
+					if !ok {
+						written = key
+					}
+					if f.FlagSet.Lookup(key) != nil {
+						f.traceCandidate(key, SourceFile, layer.describe(), traceValue(val))
+					}
 					if _, ok := unvisitedFlags[key]; ok {
-						switch v := val.(type) {
-						case bool:
-							if v {
-								_ = f.FlagSet.Set(key, "true")
-							} else {
-								_ = f.FlagSet.Set(key, "false")
+						// Flagfig's own flags, such as the validate flag, would change how it runs from inside a file
+						if f.internalFlags[key] || !f.sourceAllowed(key, SourceFile) {
+							err = p.add(fmt.Errorf("flag -%s may not be set by configuration file '%s'", key, *filePath))
+							if err != nil {
+								return err
 							}
-						case string:
-							_ = f.FlagSet.Set(key, v)
-						case int:
-							_ = f.FlagSet.Set(key, strconv.Itoa(v))
-						case int64:
-							_ = f.FlagSet.Set(key, strconv.FormatInt(v, 10))
-						case uint:
-							_ = f.FlagSet.Set(key, strconv.FormatUint(uint64(v), 10))
-						case uint64:
-							_ = f.FlagSet.Set(key, strconv.FormatUint(v, 10))
-						case float64:
-							// So, every number in JSON is actually a float64...
-							switch f.flagTypes[key] {
-							case intType:
-								_ = f.FlagSet.Set(key, fmt.Sprintf("%.0f", v))
-							case uintType:
-								_ = f.FlagSet.Set(key, fmt.Sprintf("%.0f", v))
-							case int64Type:
-								_ = f.FlagSet.Set(key, fmt.Sprintf("%.0f", v))
-							case uint64Type:
-								_ = f.FlagSet.Set(key, fmt.Sprintf("%.0f", v))
-							case floatType:
-								_ = f.FlagSet.Set(key, fmt.Sprintf("%f", v))
-							case durationType:
-								s := strings.TrimSpace(fmt.Sprintf("%18.0fns", v))
-								//fmt.Println(key, ":",s)
-								_ = f.FlagSet.Set(key, s)
+							continue
+						}
+						setErr := f.setFromConfigValue(key, val)
+						if setErr != nil {
+							failure := f.invalidValue(key, SourceFile, *filePath, traceValue(val), setErr)
+							failure.Key = written
+							if f.warnInvalidConfig {
+								f.logf("Ignoring %s", failure)
+								continue
 							}
-						default:
-							log.Fatalf("Unsupported Config file type %t", v)
+							// Keep going, so every bad value is reported at once
+							failures = append(failures, failure)
+							continue
 						}
+						f.origins[key] = origin{source: SourceFile, detail: layer.describe(), key: written}
 					}
 				}
 			}
 		}
 	}
+	switch len(failures) {
+	case 0:
+		return nil
+	case 1:
+		return p.add(failures[0])
+	}
+	return p.add(failures)
+}
+
+// readEnvironment sets the unvisited flags that have an environment variable from it. onCommandLine lists the flags
+// given on the command line, which need no Required environment variable, even when they merge with it
+func (f *FlagfigSet) readEnvironment(unVisitedFlags map[string]*flag.Flag, onCommandLine map[string]bool, p *problems) (err error) {
+	names := make([]string, 0, len(unVisitedFlags))
+	for name := range unVisitedFlags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		err = p.add(f.readFlagEnv(unVisitedFlags[name], onCommandLine[name]))
+		if err != nil {
+			return
+		}
+	}
 	return
 }
+
+// readFlagEnv sets the flag from its environment variable, if it has one. given is true if the flag was given on the
+// command line, so its environment variable may be missing even if Required
+func (f *FlagfigSet) readFlagEnv(fl *flag.Flag, given bool) (err error) {
+	// Blank envName means skip ENV lookup, for safety, unless AutomaticEnv is on
+	envName := f.envNameFor(fl.Name)
+	if len(envName) == 0 {
+		return nil
+	}
+	env := f.envOpts[fl.Name]
+	envVal, found := f.lookupFlagEnv(fl.Name, envName)
+	if found && !f.sourceAllowed(fl.Name, SourceEnv) {
+		return fmt.Errorf("flag -%s may not be set by environment variable %s", fl.Name, envName)
+	}
+	if found {
+		err = f.setFromEnv(fl.Name, envVal)
+		if err != nil {
+			return f.invalidValue(fl.Name, SourceEnv, envName, envVal, err)
+		}
+		f.origins[fl.Name] = origin{source: SourceEnv, detail: envName}
+		f.traceCandidate(fl.Name, SourceEnv, envName, envVal)
+	} else if f.flagTypes[fl.Name] == stringSliceType {
+		// Lists may also be spelled out one item per variable: PEERS_0, PEERS_1, ...
+		var items []interface{}
+		if items, found = f.lookupIndexedEnv(envName); found {
+			detail := fmt.Sprintf("%s_0..%s_%d", envName, envName, len(items)-1)
+			err = f.setFromConfigValue(fl.Name, items)
+			if err != nil {
+				return f.invalidValue(fl.Name, SourceEnv, detail, traceValue(items), err)
+			}
+			f.origins[fl.Name] = origin{source: SourceEnv, detail: detail}
+			f.traceCandidate(fl.Name, SourceEnv, detail, traceValue(items))
+		}
+	}
+	if !found && env.Required && !given {
+		return errorOfKind(ErrMissingRequired, "environment variable %s is required (flag -%s)", envName, fl.Name)
+	}
+	return nil
+}
+
+// setFromConfigValue sets the flag named key from a value decoded out of a JSON document
+func (f *FlagfigSet) setFromConfigValue(key string, val interface{}) (err error) {
+	switch v := val.(type) {
+	case bool:
+		if v {
+			return f.FlagSet.Set(key, "true")
+		} else {
+			return f.FlagSet.Set(key, "false")
+		}
+	case string:
+		return f.FlagSet.Set(key, v)
+	case int:
+		return f.FlagSet.Set(key, strconv.Itoa(v))
+	case int64:
+		return f.FlagSet.Set(key, strconv.FormatInt(v, 10))
+	case uint:
+		return f.FlagSet.Set(key, strconv.FormatUint(uint64(v), 10))
+	case uint64:
+		return f.FlagSet.Set(key, strconv.FormatUint(v, 10))
+	case float64:
+		// So, every number in JSON is actually a float64...
+		switch f.flagTypes[key] {
+		case intType:
+			return f.FlagSet.Set(key, fmt.Sprintf("%.0f", v))
+		case uintType:
+			return f.FlagSet.Set(key, fmt.Sprintf("%.0f", v))
+		case int64Type:
+			return f.FlagSet.Set(key, fmt.Sprintf("%.0f", v))
+		case uint64Type:
+			return f.FlagSet.Set(key, fmt.Sprintf("%.0f", v))
+		case floatType:
+			return f.FlagSet.Set(key, fmt.Sprintf("%f", v))
+		case durationType:
+			s := strings.TrimSpace(fmt.Sprintf("%18.0fns", v))
+			//fmt.Println(key, ":",s)
+			return f.FlagSet.Set(key, s)
+		case stringType:
+			return f.FlagSet.Set(key, strconv.FormatFloat(v, 'f', -1, 64))
+		}
+		return f.wrongConfigType(key, val)
+	case json.Number:
+		// YAML numbers keep their text, so that string flags get exactly what was written
+		if f.flagTypes[key] == stringType {
+			return f.FlagSet.Set(key, v.String())
+		}
+		n, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		return f.setFromConfigValue(key, n)
+	case []interface{}, map[string]interface{}:
+		// Complex flags accept JSON documents, so hand them the original JSON text
+		if f.isComplex(key) {
+			raw, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			return f.setJSON(key, string(raw))
+		}
+		return f.wrongConfigType(key, val)
+	case nil:
+		return f.wrongConfigType(key, val)
+	default:
+		return f.wrongConfigType(key, val)
+	}
+}
+
+// wrongConfigType describes a value of a configuration file that is of the wrong type for its flag
+func (f *FlagfigSet) wrongConfigType(key string, val interface{}) error {
+	typeName := "bool"
+	if f.flagTypes[key] != boolType {
+		typeName, _ = f.unquoteUsage(f.FlagSet.Lookup(key))
+	}
+	return fmt.Errorf("a JSON %s cannot set a flag of type %s", jsonKind(val), typeName)
+}
+
+// jsonKind names the JSON type of a value decoded out of a configuration file
+func jsonKind(val interface{}) string {
+	switch val.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64, json.Number, int, int64, uint, uint64:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", val)
+}
+
+// setJSON sets the complex flag named key from a JSON document
+func (f *FlagfigSet) setJSON(key, raw string) error {
+	expectJSON(f.FlagSet.Lookup(key).Value)
+	return f.FlagSet.Set(key, raw)
+}
+
+// setFromEnv sets the flag named key from an environment value. Complex flags may be given a JSON document, which is
+// decoded and applied exactly as if it were read from a configuration file
+func (f *FlagfigSet) setFromEnv(key, envVal string) (err error) {
+	sep := f.envOpts[key].Separator
+	if f.flagTypes[key] == stringSliceType && len(sep) != 0 && !looksLikeJSON(envVal) {
+		items := make([]interface{}, 0)
+		for _, item := range splitList(envVal, sep) {
+			items = append(items, item)
+		}
+		return f.setFromConfigValue(key, items)
+	}
+	if f.isComplex(key) && looksLikeJSON(envVal) {
+		var decoded interface{}
+		err = json.Unmarshal([]byte(envVal), &decoded)
+		if err != nil {
+			return err
+		}
+		return f.setFromConfigValue(key, decoded)
+	}
+	return f.FlagSet.Set(key, envVal)
+}
@@ -0,0 +1,102 @@
+package flagfig
+
+import (
+	"fmt"
+)
+
+// defaultHistorySize is how many configurations History keeps, unless SetHistorySize says otherwise
+const defaultHistorySize = 10
+
+func SetHistorySize(size int) {
+	CommandLine.SetHistorySize(size)
+}
+
+// SetHistorySize sets how many configurations History keeps, including the current one. It is 10 by default. A size
+// below 1 keeps just the current one
+func (f *FlagfigSet) SetHistorySize(size int) {
+	f.historyMu.Lock()
+	defer f.historyMu.Unlock()
+	if size < 1 {
+		size = 1
+	}
+	f.historySize = size
+	f.trimHistory()
+}
+
+func History() []*Snapshot {
+	return CommandLine.History()
+}
+
+// History lists the Snapshots of the configurations the program has run with, newest first, so the current one comes
+// first. A snapshot is added each time Parse, Reload, Recollate, AdminHandler or Rollback changes a value. Use each
+// snapshot's Time to tell when it took effect
+func (f *FlagfigSet) History() []*Snapshot {
+	f.historyMu.Lock()
+	defer f.historyMu.Unlock()
+	history := make([]*Snapshot, len(f.history))
+	for i, s := range f.history {
+		history[len(f.history)-1-i] = s
+	}
+	return history
+}
+
+// addHistory adds the snapshot to the history, unless no value changed since the last one, which it then replaces
+func (f *FlagfigSet) addHistory(s *Snapshot) {
+	f.historyMu.Lock()
+	defer f.historyMu.Unlock()
+	if n := len(f.history); n != 0 && len(diffSnapshots(f.history[n-1], s)) == 0 {
+		s.taken = f.history[n-1].taken
+		f.history[n-1] = s
+		return
+	}
+	f.history = append(f.history, s)
+	f.trimHistory()
+}
+
+// trimHistory drops the oldest snapshots beyond the history size
+func (f *FlagfigSet) trimHistory() {
+	size := f.historySize
+	if size == 0 {
+		size = defaultHistorySize
+	}
+	if len(f.history) > size {
+		f.history = append([]*Snapshot(nil), f.history[len(f.history)-size:]...)
+	}
+}
+
+func Rollback(n int) error {
+	return CommandLine.Rollback(n)
+}
+
+// Rollback puts back the configuration from n changes ago, which is History()[n], to undo a bad reload without
+// restarting. The values and their Origins are restored, the OnParsed hooks run, and the OnReload hooks are told what
+// changed. The rollback is itself added to the History. It lasts until the next Reload, which Watch only does once a
+// configuration file changes again, so fix the file before then
+func (f *FlagfigSet) Rollback(n int) error {
+	before, err := f.rollback(n)
+	if err != nil {
+		return err
+	}
+	f.reportChanges(before)
+	return nil
+}
+
+// rollback does the work of Rollback, returning the Snapshot from before
+func (f *FlagfigSet) rollback(n int) (before *Snapshot, err error) {
+	history := f.History()
+	if n < 1 || n >= len(history) {
+		return nil, fmt.Errorf("cannot roll back %d changes, the history holds %d", n, len(history)-1)
+	}
+	target := history[n]
+	f.reloadMu.Lock()
+	defer f.reloadMu.Unlock()
+	before = f.Snapshot()
+	restore := f.saveValues()
+	defer func() {
+		if err != nil {
+			restore()
+		}
+	}()
+	err = f.applySnapshot(target)
+	return
+}
@@ -0,0 +1,58 @@
+package flagfig
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+)
+
+func TestRollback(t *testing.T) {
+	path, cleanup := testTempFile(t)
+	defer cleanup()
+	write := func(content string) {
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{"limit": 10, "peers": ["a", "b"]}`)
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetHistorySize(3)
+	f.AddConfigLayer("base", path)
+	limit := f.Int("limit", 1, "", "limit")
+	peers := f.StringSlice("peers", nil, "", "peers")
+	if err := f.Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range []string{`{"limit": 20, "peers": ["c"]}`, `{"limit": 20, "peers": ["c"]}`, `{"limit": 30}`} {
+		write(content)
+		if err := f.Reload(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	history := f.History()
+	if len(history) != 3 {
+		t.Fatal("expected a change per distinct configuration, got ", len(history))
+	}
+	if n, _ := history[0].GetInt("limit"); n != 30 {
+		t.Error("expected the current configuration first, got ", n)
+	}
+
+	var changes []Change
+	f.OnReload(func(f *FlagfigSet, c []Change) { changes = c })
+	if err := f.Rollback(1); err != nil {
+		t.Fatal(err)
+	}
+	if *limit != 20 || len(*peers) != 1 || (*peers)[0] != "c" || len(changes) != 2 {
+		t.Errorf("expected 20 and [c] with 2 changes, got %d and %v with %v", *limit, *peers, changes)
+	}
+	if source, _ := f.Origin("peers"); source != SourceFile {
+		t.Error("expected the origin to be restored too, got ", source)
+	}
+	if len(f.History()) != 3 {
+		t.Error("expected the history to stay at its size, got ", len(f.History()))
+	}
+	expected := "cannot roll back 3 changes, the history holds 2"
+	if err := f.Rollback(3); err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}
@@ -0,0 +1,31 @@
+package flagfig
+
+func OnParsed(hook func(*FlagfigSet) error) {
+	CommandLine.OnParsed(hook)
+}
+
+// OnParsed adds a hook that Collate runs once every flag is resolved and validated. Hooks run in the order they were
+// added, and the first error stops Collate and is returned by it. This lets a library that registers its own flags
+// also normalize them, without needing the caller to use a Nester:
+//
+//	func RegisterFlags(flags *flagfig.FlagfigSet) {
+//		dir := flags.String("cache-dir", "~/.cache/mylib", "MYLIB_CACHE_DIR", "cache directory")
+//		flags.OnParsed(func(*flagfig.FlagfigSet) (err error) {
+//			*dir, err = expandHome(*dir)
+//			return
+//		})
+//	}
+func (f *FlagfigSet) OnParsed(hook func(*FlagfigSet) error) {
+	f.parsedHooks = append(f.parsedHooks, hook)
+}
+
+// runParsedHooks runs the OnParsed hooks, stopping at the first error
+func (f *FlagfigSet) runParsedHooks() (err error) {
+	for _, hook := range f.parsedHooks {
+		err = hook(f)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,63 @@
+package flagfig
+
+import (
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestOnParsed(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	name := f.String("name", "", "", "name")
+	calls := make([]string, 0)
+	f.OnParsed(func(fs *FlagfigSet) error {
+		calls = append(calls, "first")
+		*name = strings.ToLower(*name)
+		return nil
+	})
+	f.OnParsed(func(fs *FlagfigSet) error {
+		calls = append(calls, "second:"+*name)
+		return nil
+	})
+	err := f.Parse([]string{"-name=MyApp"})
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if strings.Join(calls, ",") != "first,second:myapp" {
+		t.Error("expected the hooks to run in order, got ", calls)
+	}
+}
+
+func TestOnParsedError(t *testing.T) {
+	expected := errors.New("bad name")
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.String("name", "", "", "name", NotEmpty())
+	ran := false
+	f.OnParsed(func(fs *FlagfigSet) error {
+		return expected
+	})
+	f.OnParsed(func(fs *FlagfigSet) error {
+		ran = true
+		return nil
+	})
+	if err := f.Parse([]string{"-name=x"}); err != expected {
+		t.Error("expected the hook's error, got ", err)
+	}
+	if ran {
+		t.Error("hooks after a failing hook should not run")
+	}
+
+	f = NewFlagfigSet("test", flag.ContinueOnError)
+	f.String("name", "", "", "name", NotEmpty())
+	f.OnParsed(func(fs *FlagfigSet) error {
+		ran = true
+		return nil
+	})
+	if err := f.Parse([]string{}); err == nil {
+		t.Error("expected a validation error")
+	}
+	if ran {
+		t.Error("hooks should not run when validation fails")
+	}
+}
@@ -0,0 +1,111 @@
+package flagfig
+
+import (
+	"errors"
+	"fmt"
+)
+
+// configLayer is one configuration file to read. Layers are read in the order they were added, so later layers
+// override earlier ones
+type configLayer struct {
+	// label names the layer in Origin and traces. Layers added with AddConfigFile have no label
+	label string
+	// path is the file to read. For AddConfigFile, this points at the flag's value, so it may be blank
+	path *string
+	// flagName is the name of the AddConfigFile flag that sets path, blank for AddConfigLayer
+	flagName string
+	// missing is what to do when the file is missing or empty, see SetMissingConfig
+	missing MissingConfig
+}
+
+// describe is how the layer appears in Origin and traces: the path, prefixed by the label if there is one
+func (l configLayer) describe() string {
+	if len(l.label) == 0 {
+		return *l.path
+	}
+	return l.label + " (" + *l.path + ")"
+}
+
+// AddConfigLayer adds a fixed configuration file layer to the CommandLine
+func AddConfigLayer(label, path string) {
+	CommandLine.AddConfigLayer(label, path)
+}
+
+// AddConfigLayer adds a configuration file that is always read, identified by label. Layers and the files named by
+// AddConfigFile flags are read in the order they were added, each overriding the values of the ones before it, so
+// declare the base first and the most specific overrides last:
+//
+//	flags.AddConfigLayer("base", "/etc/myapp/base.json")
+//	flags.AddConfigLayer("region", "/etc/myapp/region-eu.json")
+//	flags.AddConfigLayer("local", "local-overrides.json")
+//	flags.AddConfigFile("config", "an extra configuration file, applied on top of the others")
+//
+// The label appears in Origin, as "label (path)", so you can tell which layer provided a value.
+// Environment variables and command-line flags still take precedence over every layer.
+func (f *FlagfigSet) AddConfigLayer(label, path string) {
+	f.configLayers = append(f.configLayers, configLayer{label: label, path: &path})
+}
+
+// MissingConfig is what happens when a configuration file does not exist, or is empty, see SetMissingConfig
+type MissingConfig int
+
+const (
+	// RequireFile makes Collate fail with ErrConfigFileNotFound when the file does not exist. An empty file is
+	// logged and skipped, like any other file that cannot be decoded. This is the default
+	RequireFile MissingConfig = iota
+	// WarnMissing logs that the file is missing or empty, and carries on without it
+	WarnMissing
+	// IgnoreMissing quietly carries on without a missing or empty file
+	IgnoreMissing
+)
+
+// SetMissingConfig sets what happens when a CommandLine configuration file is missing or empty
+func SetMissingConfig(name string, missing MissingConfig) {
+	CommandLine.SetMissingConfig(name, missing)
+}
+
+// SetMissingConfig sets what happens when a configuration file is missing or empty. The file is the one of the layer
+// with the given label, or of the AddConfigFile flag with the given name. This way, the main configuration can still
+// be required, while an optional override file may or may not be there:
+//
+//	flags.AddConfigLayer("base", "/etc/myapp/config.json")
+//	flags.AddConfigLayer("local", "local-overrides.json")
+//	flags.SetMissingConfig("local", flagfig.IgnoreMissing)
+//
+// It panics if there is no such layer or flag
+func (f *FlagfigSet) SetMissingConfig(name string, missing MissingConfig) {
+	for i := range f.configLayers {
+		layer := &f.configLayers[i]
+		if (len(layer.label) != 0 && layer.label == name) || (len(layer.flagName) != 0 && layer.flagName == name) {
+			layer.missing = missing
+			return
+		}
+	}
+	panic(fmt.Sprintf("flagfig: no configuration file layer or flag named %q", name))
+}
+
+// errEmptyConfig is a configuration file that holds nothing but space
+var errEmptyConfig = errors.New("the file is empty")
+
+// skipMissing is true if the layer's file may be left out, given the error reading it. It logs this, if asked to
+func (f *FlagfigSet) skipMissing(l configLayer, err error) bool {
+	if l.missing == RequireFile || !(errors.Is(err, ErrConfigFileNotFound) || errors.Is(err, errEmptyConfig)) {
+		return false
+	}
+	if l.missing == WarnMissing {
+		f.logf("Skipping configuration file: '%s' because: %s", *l.path, err)
+	}
+	return true
+}
+
+// SetWarnInvalidConfig sets whether invalid CommandLine configuration file values are only warned about
+func SetWarnInvalidConfig(warn bool) {
+	CommandLine.SetWarnInvalidConfig(warn)
+}
+
+// SetWarnInvalidConfig makes values in the configuration files that their flags do not accept, such as an object
+// where a string belongs, a warning written to the Logger rather than an *ErrInvalidValue. The flag is left to the
+// other sources, as if the file did not set it
+func (f *FlagfigSet) SetWarnInvalidConfig(warn bool) {
+	f.warnInvalidConfig = warn
+}
@@ -0,0 +1,174 @@
+package flagfig
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestAddConfigLayer(t *testing.T) {
+	base, baseRemove := testTempFile(t)
+	defer baseRemove()
+	region, regionRemove := testTempFile(t)
+	defer regionRemove()
+	extra, extraRemove := testTempFile(t)
+	defer extraRemove()
+	files := map[string]string{
+		base:   `{"a":"base","b":"base","c":"base"}`,
+		region: `{"b":"region","c":"region"}`,
+		extra:  `{"c":"extra"}`,
+	}
+	for path, content := range files {
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.AddConfigLayer("base", base)
+	f.AddConfigLayer("region", region)
+	f.AddConfigFile("config", "config file")
+	a := f.String("a", "", "", "a")
+	b := f.String("b", "", "", "b")
+	c := f.String("c", "", "", "c")
+	if err := f.Parse([]string{"-config", extra}); err != nil {
+		t.Fatal(err)
+	}
+	if *a != "base" || *b != "region" || *c != "extra" {
+		t.Errorf("expected base region extra, got %s %s %s", *a, *b, *c)
+	}
+	if _, detail := f.Origin("b"); detail != "region ("+region+")" {
+		t.Error("b should name the region layer as its origin, got ", detail)
+	}
+	if _, detail := f.Origin("c"); detail != extra {
+		t.Error("c should name the file as its origin, got ", detail)
+	}
+}
+
+func TestInvalidConfigValues(t *testing.T) {
+	path, cleanup := testTempFile(t)
+	defer cleanup()
+	cases := map[string]struct {
+		content  string
+		expected string
+	}{
+		"valid": {
+			content: `{"port": 80, "name": 5, "debug": true}`,
+		},
+		"one": {
+			content:  `{"port": "eighty"}`,
+			expected: `invalid value "eighty" for key "port" in configuration file ` + path + `: parse error`,
+		},
+		"every one": {
+			content: `{"port": "eighty", "debug": 1, "name": ["a"]}`,
+			expected: `3 problems with the configuration:` +
+				"\n  " + `invalid value "1" for key "debug" in configuration file ` + path + `: a JSON number cannot set a flag of type bool` +
+				"\n  " + `invalid value "[\"a\"]" for key "name" in configuration file ` + path + `: a JSON array cannot set a flag of type string` +
+				"\n  " + `invalid value "eighty" for key "port" in configuration file ` + path + `: parse error`,
+		},
+	}
+	for caseName, c := range cases {
+		if err := ioutil.WriteFile(path, []byte(c.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.AddConfigLayer("base", path)
+		f.Int("port", 0, "", "port")
+		f.String("name", "", "", "name")
+		f.Bool("debug", false, "", "debug")
+		err := f.Parse([]string{})
+		if len(c.expected) == 0 {
+			if err != nil {
+				t.Errorf("case %s: unexpected error: %s", caseName, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != c.expected {
+			t.Errorf("case %s: expected %q, got %v", caseName, c.expected, err)
+		}
+		var invalid *ErrInvalidValue
+		if !errors.As(err, &invalid) || invalid.Detail != path || invalid.Key != invalid.Flag {
+			t.Errorf("case %s: expected an ErrInvalidValue, got %#v", caseName, err)
+		}
+	}
+
+	if err := ioutil.WriteFile(path, []byte(`{"port": {"number": 80}, "name": "web"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	logged := &bytes.Buffer{}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetLogger(log.New(logged, "", 0))
+	f.SetWarnInvalidConfig(true)
+	f.AddConfigLayer("base", path)
+	port := f.Int("port", 8080, "", "port")
+	name := f.String("name", "", "", "name")
+	if err := f.Parse([]string{}); err != nil {
+		t.Fatal("expected invalid values to be warned about, got ", err)
+	}
+	expected := `Ignoring invalid value "{\"number\":80}" for key "port" in configuration file ` + path + `: a JSON object cannot set a flag of type int` + "\n"
+	if logged.String() != expected || *port != 8080 || *name != "web" {
+		t.Errorf("expected %q, 8080 and web, got %q, %d and %s", expected, logged.String(), *port, *name)
+	}
+}
+
+func TestSetMissingConfig(t *testing.T) {
+	empty, cleanup := testTempFile(t)
+	defer cleanup()
+	missing := empty + ".missing"
+	cases := map[string]struct {
+		path    string
+		missing MissingConfig
+		err     error
+		logged  bool
+	}{
+		"required and missing": {
+			path:    missing,
+			missing: RequireFile,
+			err:     ErrConfigFileNotFound,
+		},
+		"warn when missing": {
+			path:    missing,
+			missing: WarnMissing,
+			logged:  true,
+		},
+		"warn when empty": {
+			path:    empty,
+			missing: WarnMissing,
+			logged:  true,
+		},
+		"ignore when missing": {
+			path:    missing,
+			missing: IgnoreMissing,
+		},
+		"ignore when empty": {
+			path:    empty,
+			missing: IgnoreMissing,
+		},
+	}
+	logged := &bytes.Buffer{}
+	log.SetOutput(logged)
+	defer log.SetOutput(os.Stderr)
+	for caseName, c := range cases {
+		logged.Reset()
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.AddConfigLayer("base", c.path)
+		f.SetMissingConfig("base", c.missing)
+		err := f.Parse([]string{})
+		if !errors.Is(err, c.err) || (c.err == nil && err != nil) {
+			t.Errorf("case %s: expected %v, got %v", caseName, c.err, err)
+		}
+		if c.logged != (logged.Len() != 0) {
+			t.Errorf("case %s: expected logging to be %t, got %q", caseName, c.logged, logged.String())
+		}
+	}
+
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.AddConfigFile("config", "configuration file")
+	f.SetMissingConfig("config", IgnoreMissing)
+	if err := f.Parse([]string{"-config", missing}); err != nil {
+		t.Error("expected the missing -config file to be ignored, got ", err)
+	}
+}
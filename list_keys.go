@@ -0,0 +1,61 @@
+package flagfig
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"text/tabwriter"
+)
+
+// ErrKeysListed is returned by Parse, with ContinueOnError, after the flag added by AddListKeysFlag printed the keys
+var ErrKeysListed = errors.New("flagfig: configuration keys listed")
+
+// AddListKeysFlag adds a key listing flag to the CommandLine
+func AddListKeysFlag(name, usage string) *bool {
+	return CommandLine.AddListKeysFlag(name, usage)
+}
+
+// AddListKeysFlag adds a boolean flag, such as -list-config-keys, that prints a table of every flag with its
+// environment variable, configuration file key, resolved value and where that value came from, then stops the
+// program, which is handy when exploring an unfamiliar binary:
+//
+//	FLAG   ENV         CONFIG  VALUE      SOURCE
+//	-host  MYAPP_HOST  host    localhost  default
+//	-port  MYAPP_PORT  port    9090       env (MYAPP_PORT)
+//
+// The table is printed even if the configuration is not valid, such as when a required flag is missing. Sensitive
+// values are redacted. Like SetValidateOnly, Parse then exits with ExitOnError, panics with ErrKeysListed with
+// PanicOnError, and returns ErrKeysListed with ContinueOnError
+func (f *FlagfigSet) AddListKeysFlag(name, usage string) *bool {
+	p := new(bool)
+	f.listKeysFlag = p
+	f.internalFlags[name] = true
+	f.order = append(f.order, name)
+	f.FlagSet.BoolVar(p, name, false, usage)
+	return p
+}
+
+// finishListKeys prints the keys table and stops, unless err is something other than a validation problem
+func (f *FlagfigSet) finishListKeys(err error) error {
+	var invalid *ValidationError
+	if err != nil && !errors.As(err, &invalid) {
+		return f.failParse(err)
+	}
+	w := tabwriter.NewWriter(f.Output(), 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "FLAG\tENV\tCONFIG\tVALUE\tSOURCE")
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		source, detail := f.Origin(fl.Name)
+		_, _ = fmt.Fprintf(w, "-%s\t%s\t%s\t%s\t%s%s\n", fl.Name, orDash(f.envKey(fl.Name)), orDash(f.configKey(fl.Name)),
+			orDash(f.displayValue(fl)), source, traceDetail(detail))
+	})
+	_ = w.Flush()
+	return f.stop(ErrKeysListed)
+}
+
+// orDash is s, or a dash if s is blank, so that table cells are never empty
+func orDash(s string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+	return s
+}
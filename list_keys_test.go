@@ -0,0 +1,34 @@
+package flagfig
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"testing"
+)
+
+func TestAddListKeysFlag(t *testing.T) {
+	_ = os.Setenv("ENV_LIST_PORT", "9090")
+	defer func() { _ = os.Unsetenv("ENV_LIST_PORT") }()
+	out := &bytes.Buffer{}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetOutput(out)
+	f.AddListKeysFlag("list-config-keys", "list the configuration keys and exit")
+	f.AddConfigFile("config", "configuration file")
+	f.String("host", "localhost", "ENV_LIST_HOST", "host")
+	f.Int("port", 8080, "ENV_LIST_PORT", "port")
+	f.String("token", "", "", "token", Sensitive(), Required())
+	err := f.Parse([]string{"-list-config-keys"})
+	if err != ErrKeysListed {
+		t.Fatal("expected ErrKeysListed, got ", err)
+	}
+	expected := "FLAG               ENV            CONFIG  VALUE      SOURCE\n" +
+		"-config            -              -       -          default\n" +
+		"-host              ENV_LIST_HOST  host    localhost  default\n" +
+		"-list-config-keys  -              -       true       flag\n" +
+		"-port              ENV_LIST_PORT  port    9090       env (ENV_LIST_PORT)\n" +
+		"-token             -              token   -          default\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
@@ -0,0 +1,32 @@
+package flagfig
+
+import "log"
+
+// Logger is where flagfig writes its warnings about configuration files, such as a file that cannot be decoded, and
+// what Watch reloads. A *log.Logger is one
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+func SetLogger(logger Logger) {
+	CommandLine.SetLogger(logger)
+}
+
+// SetLogger sends the warnings about configuration files, and the changes Watch makes, to logger, rather than to the standard logger of the log
+// package, so programs with a logger of their own can keep them together:
+//
+//	flags.SetLogger(log.New(os.Stderr, "config: ", log.LstdFlags))
+//
+// nil goes back to the standard logger
+func (f *FlagfigSet) SetLogger(logger Logger) {
+	f.logger = logger
+}
+
+// logf writes a warning to the Logger
+func (f *FlagfigSet) logf(format string, v ...interface{}) {
+	if f.logger == nil {
+		log.Printf(format, v...)
+		return
+	}
+	f.logger.Printf(format, v...)
+}
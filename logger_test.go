@@ -0,0 +1,49 @@
+package flagfig
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSetLogger(t *testing.T) {
+	path, cleanup := testTempFile(t)
+	defer cleanup()
+	if err := ioutil.WriteFile(path, []byte(`{"broken"`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	standard := &bytes.Buffer{}
+	log.SetOutput(standard)
+	defer log.SetOutput(os.Stderr)
+	cases := map[string]struct {
+		own bool
+	}{
+		"standard logger": {},
+		"own logger": {
+			own: true,
+		},
+	}
+	for caseName, c := range cases {
+		standard.Reset()
+		own := &bytes.Buffer{}
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.AddConfigLayer("base", path)
+		if c.own {
+			f.SetLogger(log.New(own, "config: ", 0))
+		}
+		if err := f.Parse([]string{}); err != nil {
+			t.Errorf("case %s: unexpected error: %s", caseName, err)
+		}
+		expected, other := standard, own
+		if c.own {
+			expected, other = own, standard
+		}
+		if !strings.Contains(expected.String(), "Unable to decode file: '"+path+"'") || other.Len() != 0 {
+			t.Errorf("case %s: expected the warning in the right logger, got %q and %q", caseName, expected.String(), other.String())
+		}
+	}
+}
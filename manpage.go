@@ -0,0 +1,127 @@
+package flagfig
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// AppInfo describes the program for a generated man page
+type AppInfo struct {
+	// Name is the program name. If blank, the name of the FlagfigSet is used
+	Name string
+	// Title is the one line summary that follows the name, as in "myapp - serves things"
+	Title string
+	// Description is the body of the DESCRIPTION section. Blank lines separate paragraphs
+	Description string
+	// Version is shown in the footer, such as "myapp 1.2.0"
+	Version string
+	// Date is shown in the footer. It is left blank unless set, so that builds are reproducible
+	Date string
+	// Manual is the title of the manual the page belongs to, such as "User Commands"
+	Manual string
+}
+
+func GenManPage(section int, app AppInfo, w io.Writer) error {
+	return CommandLine.GenManPage(section, app, w)
+}
+
+// GenManPage writes a man page in roff for the program, listing every flag with its default, environment variable
+// and configuration file key. Generate it at build time so packages can ship it:
+//
+//	err := flags.GenManPage(1, flagfig.AppInfo{Title: "serves things", Version: version}, file)
+func (f *FlagfigSet) GenManPage(section int, app AppInfo, w io.Writer) (err error) {
+	name := app.Name
+	if len(name) == 0 {
+		name = f.Name()
+	}
+	out := bufio.NewWriter(w)
+	_, _ = fmt.Fprintf(out, ".TH %s %d %s %s %s\n", roffQuote(strings.ToUpper(name)), section, roffQuote(app.Date),
+		roffQuote(strings.TrimSpace(name+" "+app.Version)), roffQuote(app.Manual))
+	_, _ = fmt.Fprintf(out, ".SH NAME\n%s", roffEscape(name))
+	if len(app.Title) != 0 {
+		_, _ = fmt.Fprintf(out, " \\- %s", roffEscape(app.Title))
+	}
+	_, _ = fmt.Fprintf(out, "\n.SH SYNOPSIS\n.B %s\n[\\fIoptions\\fR]\n", roffEscape(name))
+	if len(app.Description) != 0 {
+		_, _ = fmt.Fprint(out, ".SH DESCRIPTION\n")
+		for i, paragraph := range strings.Split(strings.TrimSpace(app.Description), "\n\n") {
+			if i != 0 {
+				_, _ = fmt.Fprint(out, ".PP\n")
+			}
+			_, _ = fmt.Fprintln(out, roffEscape(paragraph))
+		}
+	}
+	_, _ = fmt.Fprint(out, ".SH OPTIONS\n")
+	envs := make([]FlagInfo, 0)
+	for _, group := range f.usageGroups() {
+		if len(group.Name) != 0 {
+			_, _ = fmt.Fprintf(out, ".SS %s\n", roffEscape(group.Name))
+		}
+		for _, info := range group.Flags {
+			f.writeManOption(out, info)
+			if len(info.Env) != 0 {
+				envs = append(envs, info)
+			}
+		}
+	}
+	if len(envs) != 0 {
+		_, _ = fmt.Fprint(out, ".SH ENVIRONMENT\n")
+		for _, info := range envs {
+			_, _ = fmt.Fprintf(out, ".TP\n.B %s\nSets \\fB%s\\fR.\n", roffEscape(info.Env), roffEscape(f.dash(info.Name)))
+		}
+	}
+	return out.Flush()
+}
+
+// writeManOption writes the flag's entry in the OPTIONS section
+func (f *FlagfigSet) writeManOption(out io.Writer, info FlagInfo) {
+	names := f.commandLineNames(info)
+	for i, name := range names {
+		names[i] = "\\fB" + roffEscape(name) + "\\fR"
+	}
+	_, _ = fmt.Fprintf(out, ".TP\n%s", strings.Join(names, ", "))
+	if len(info.Type) != 0 {
+		_, _ = fmt.Fprintf(out, " \\fI%s\\fR", roffEscape(info.Type))
+	}
+	_, _ = fmt.Fprintf(out, "\n%s\n", roffEscape(info.Usage))
+	if len(info.Deprecated) != 0 {
+		_, _ = fmt.Fprintf(out, ".br\nDeprecated: %s", roffEscape(info.Deprecated))
+		if len(info.Replacement) != 0 {
+			_, _ = fmt.Fprintf(out, ", use \\fB%s\\fR", roffEscape(f.dash(info.Replacement)))
+		}
+		_, _ = fmt.Fprintln(out)
+	}
+	if info.Required {
+		_, _ = fmt.Fprint(out, ".br\nRequired.\n")
+	}
+	if len(info.Default) != 0 {
+		_, _ = fmt.Fprintf(out, ".br\nDefault: %s\n", roffEscape(info.Default))
+	}
+	if len(info.Env) != 0 {
+		_, _ = fmt.Fprintf(out, ".br\nEnvironment: \\fB%s\\fR\n", roffEscape(info.Env))
+	}
+	if len(info.ConfigKey) != 0 {
+		_, _ = fmt.Fprintf(out, ".br\nConfiguration key: \\fB%s\\fR\n", roffEscape(info.ConfigKey))
+	}
+}
+
+// roffEscape makes s safe to use as roff text: backslashes and dashes are escaped, and lines that would otherwise be
+// taken as requests are protected
+func roffEscape(s string) string {
+	s = strings.Replace(s, "\\", "\\e", -1)
+	s = strings.Replace(s, "-", "\\-", -1)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = "\\&" + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// roffQuote makes s a single argument to a roff request
+func roffQuote(s string) string {
+	return `"` + strings.Replace(roffEscape(s), `"`, `\(dq`, -1) + `"`
+}
@@ -0,0 +1,79 @@
+package flagfig
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestGenManPage(t *testing.T) {
+	f := NewFlagfigSet("myapp", flag.ContinueOnError)
+	f.String("host", "localhost", "MYAPP_HOST", "host to connect to")
+	f.Bool("v", false, "", ".verbose output")
+	out := &bytes.Buffer{}
+	err := f.GenManPage(1, AppInfo{Title: "serves things", Version: "1.0", Description: "Serves things.\n\nQuickly."}, out)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	expected := `.TH "MYAPP" 1 "" "myapp 1.0" ""
+.SH NAME
+myapp \- serves things
+.SH SYNOPSIS
+.B myapp
+[\fIoptions\fR]
+.SH DESCRIPTION
+Serves things.
+.PP
+Quickly.
+.SH OPTIONS
+.TP
+\fB\-host\fR \fIstring\fR
+host to connect to
+.br
+Default: localhost
+.br
+Environment: \fBMYAPP_HOST\fR
+.TP
+\fB\-v\fR
+\&.verbose output
+.SH ENVIRONMENT
+.TP
+.B MYAPP_HOST
+Sets \fB\-host\fR.
+`
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestGenManPageGNUStyle(t *testing.T) {
+	f := NewFlagfigSet("myapp", flag.ContinueOnError)
+	f.SetGNUStyle(true)
+	f.String("host", "localhost", "MYAPP_HOST", "host to connect to", Short("H"))
+	f.Bool("v", false, "", "verbose output")
+	out := &bytes.Buffer{}
+	err := f.GenManPage(1, AppInfo{}, out)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	expected := `.SH OPTIONS
+.TP
+\fB\-H\fR, \fB\-\-host\fR \fIstring\fR
+host to connect to
+.br
+Default: localhost
+.br
+Environment: \fBMYAPP_HOST\fR
+.TP
+\fB\-v\fR
+verbose output
+.SH ENVIRONMENT
+.TP
+.B MYAPP_HOST
+Sets \fB\-\-host\fR.
+`
+	if !strings.HasSuffix(out.String(), expected) {
+		t.Errorf("expected it to end:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
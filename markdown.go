@@ -0,0 +1,70 @@
+package flagfig
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+func GenMarkdown(w io.Writer) error {
+	return CommandLine.GenMarkdown(w)
+}
+
+// GenMarkdown writes a Markdown table describing every flag: its default, environment variable, configuration file key
+// and usage. Generating the documentation, with go generate for example, keeps it in sync with the code.
+// Flags placed in a group with WithGroup get a table of their own, under a heading
+func (f *FlagfigSet) GenMarkdown(w io.Writer) (err error) {
+	for i, group := range f.usageGroups() {
+		if i != 0 {
+			_, err = fmt.Fprintln(w)
+			if err != nil {
+				return
+			}
+		}
+		if len(group.Name) != 0 {
+			_, err = fmt.Fprintf(w, "### %s\n\n", markdownEscape(group.Name))
+			if err != nil {
+				return
+			}
+		}
+		_, err = fmt.Fprint(w, "| Flag | Default | Environment | Config key | Description |\n|---|---|---|---|---|\n")
+		if err != nil {
+			return
+		}
+		for _, info := range group.Flags {
+			name := f.usageNames(info)
+			if len(info.Type) != 0 {
+				name += " " + info.Type
+			}
+			usage := info.Usage
+			if info.Required {
+				usage += " (required)"
+			}
+			if len(info.Deprecated) != 0 {
+				usage = "**Deprecated:** " + info.Deprecated
+				if len(info.Replacement) != 0 {
+					usage += ", use `" + f.dash(info.Replacement) + "`"
+				}
+			}
+			_, err = fmt.Fprintf(w, "| %s | %s | %s | %s | %s |\n", markdownCode(name), markdownCode(info.Default),
+				markdownCode(info.Env), markdownCode(info.ConfigKey), markdownEscape(usage))
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// markdownCode formats s as code in a table cell, or leaves the cell empty if s is blank
+func markdownCode(s string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	return "`" + strings.Replace(s, "|", "\\|", -1) + "`"
+}
+
+// markdownEscape makes s safe to put in a table cell
+func markdownEscape(s string) string {
+	return strings.Replace(strings.Replace(s, "|", "\\|", -1), "\n", " ", -1)
+}
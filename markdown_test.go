@@ -0,0 +1,49 @@
+package flagfig
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+)
+
+func TestGenMarkdown(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.AddConfigLayer("base", "base.json")
+	f.String("host", "localhost", "MYAPP_HOST", "host to connect to", Required())
+	f.String("addr", "", "", "old name for host")
+	f.Deprecate("addr", "renamed", "host")
+	f.Int("tls-port", 443, "", "port | for TLS", WithGroup("TLS"))
+	out := &bytes.Buffer{}
+	err := f.GenMarkdown(out)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	expected := "| Flag | Default | Environment | Config key | Description |\n|---|---|---|---|---|\n" +
+		"| `-addr string` |  |  | `addr` | **Deprecated:** renamed, use `-host` |\n" +
+		"| `-host string` | `localhost` | `MYAPP_HOST` | `host` | host to connect to (required) |\n" +
+		"\n### TLS\n\n" +
+		"| Flag | Default | Environment | Config key | Description |\n|---|---|---|---|---|\n" +
+		"| `-tls-port int` | `443` |  | `tls-port` | port \\| for TLS |\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestGenMarkdownGNUStyle(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetGNUStyle(true)
+	f.String("host", "localhost", "", "host to connect to", Short("H"))
+	f.String("addr", "", "", "old name for host")
+	f.Deprecate("addr", "renamed", "host")
+	out := &bytes.Buffer{}
+	err := f.GenMarkdown(out)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	expected := "| Flag | Default | Environment | Config key | Description |\n|---|---|---|---|---|\n" +
+		"| `--addr string` |  |  |  | **Deprecated:** renamed, use `--host` |\n" +
+		"| `-H, --host string` | `localhost` |  |  | host to connect to |\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
@@ -1,7 +1,13 @@
 package flagfig
 
 import (
+	"errors"
 	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 )
 
 // Nester creates an expected interface to enable configuration objects to be nested and composed relatively painlessly.
@@ -119,25 +125,224 @@ func (n *NesterBase) AfterParsed() (err error) {
 	return nil
 }
 
+// NesterDependent is implemented by Nesters whose AfterParsed uses the values of other Nesters, such as a server
+// config that loads the certificates its TLS config worked out. The Nesters it depends on have AfterParsed, and
+// Validate, called before its own. They must be parsed along with it, either listed or, with FindNested, held in the
+// fields of a listed config. Nesters may not depend on each other in a cycle
+type NesterDependent interface {
+	DependsOn() []Nester
+}
+
+// NesterValidator is implemented by Nesters that check their values once parsed. Validate is called after AfterParsed
+// has run on every nested config, so it sees the final values, including those other configs work out. Keep
+// transformations in AfterParsed and checks in Validate, so that shared validators may be composed:
+//
+//	func (c *dbConfig) Validate() error {
+//		return validateAll(validHost(*c.Host), validPort(*c.Port))
+//	}
+type NesterValidator interface {
+	Validate() (err error)
+}
+
+// prefixedNester is a Nester whose flags are prefixed, see Prefixed
+type prefixedNester struct {
+	Nester
+	prefix string
+}
+
+// Prefixed prefixes the names of the flags the Nester registers, so that two instances of the same configuration can
+// be used side by side. The flag host becomes primary-db.host, so its configuration file key is primary-db.host too,
+// and its environment variable HOST becomes PRIMARY_DB_HOST:
+//
+//	primary, replica := newDbConfig(), newDbConfig()
+//	err := ParseNested(flag.ExitOnError, []Nester{Prefixed("primary-db", primary), Prefixed("replica-db", replica)}, os.Args[1:])
+//
+// Prefixes add up when Prefixed Nesters register other Prefixed Nesters. Flags named in calls such as MarkRequired
+// inside RegisterFlags are not prefixed, so pass FlagOptions such as Required to the flag instead
+func Prefixed(prefix string, n Nester) Nester {
+	return &prefixedNester{Nester: n, prefix: prefix}
+}
+
+// RegisterFlags registers the flags of the wrapped Nester, with the prefix
+func (p *prefixedNester) RegisterFlags(flags *FlagfigSet) {
+	namePrefix, envPrefix := flags.definePrefix, flags.defineEnvPrefix
+	flags.definePrefix += p.prefix + "."
+	flags.defineEnvPrefix += strings.ToUpper(defaultEnvKeyReplacer.Replace(p.prefix)) + "_"
+	defer func() { flags.definePrefix, flags.defineEnvPrefix = namePrefix, envPrefix }()
+	p.Nester.RegisterFlags(flags)
+}
+
+// Validate validates the wrapped Nester, if it is a NesterValidator
+func (p *prefixedNester) Validate() (err error) {
+	if v, ok := p.Nester.(NesterValidator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
 // ParseNested will register the flags for each nestedConfig, then execute Parse on the flags and then run AfterParsed on every nestedConfig provided
+//
+// Once every AfterParsed has succeeded, Validate is run on the nestedConfigs that are NesterValidators, so checks can
+// rely on the values AfterParsed works out. Both run in the order the nestedConfigs are found, except that
+// NesterDependents follow the Nesters they depend on
+//
+// Only the listed nestedConfigs are used. To have the Nesters held in their fields found as well, set FindNested in
+// the options to ParseNestedWithOptions
 func ParseNested(handling flag.ErrorHandling, nestedConfigs []Nester, args []string) (err error) {
-	flags := NewFlagfigSet("", handling)
-	for _, nc := range nestedConfigs {
-		nc.RegisterFlags(flags)
+	return ParseNestedWithOptions(NestedOptions{ErrorHandling: handling}, nestedConfigs, args)
+}
+
+// NestedOptions sets up the FlagfigSet that ParseNestedWithOptions parses the nested configs with
+type NestedOptions struct {
+	// Name is the name of the set, shown in usage
+	Name          string
+	ErrorHandling flag.ErrorHandling
+	// ConfigFileFlag is the name of a flag naming a configuration file to read, as AddConfigFile adds, or blank for
+	// none
+	ConfigFileFlag string
+	// ConfigFileUsage is the usage message of the ConfigFileFlag
+	ConfigFileUsage string
+	// ConfigFileDefault is the configuration file read when the ConfigFileFlag is not given. It is skipped if it does
+	// not exist
+	ConfigFileDefault string
+	// EnvPrefix, if not blank, turns on AutomaticEnv with this prefix, so every flag may be set from the environment
+	EnvPrefix string
+	// AllAfterParsed runs AfterParsed on every nested config, even after one fails, and returns all of the failures
+	// as NesterErrors. Otherwise, the first failure is returned as is
+	AllAfterParsed bool
+	// FindNested includes the Nesters found in the exported fields of each nested config, whether held by value, by
+	// pointer, embedded or in a slice, after the config holding them, so listing the outermost config is enough. Each
+	// Nester is only used once, even if it is listed as well.
+	// Leave it off for configs that call RegisterFlags on the Nesters they hold themselves, as those would be
+	// registered twice. A config that gets its RegisterFlags from an embedded Nester has the same problem, so give it
+	// its own RegisterFlags, or embed the Nester by a named field instead
+	FindNested bool
+}
+
+// ParseNestedWithOptions is ParseNested with the whole pipeline of configuration file, environment and command line:
+//
+//	err := flagfig.ParseNestedWithOptions(flagfig.NestedOptions{
+//		ErrorHandling:     flag.ExitOnError,
+//		ConfigFileFlag:    "config",
+//		ConfigFileUsage:   "configuration file",
+//		ConfigFileDefault: "/etc/myapp/config.json",
+//		EnvPrefix:         "MYAPP",
+//	}, []flagfig.Nester{cfg}, os.Args[1:])
+func ParseNestedWithOptions(opts NestedOptions, nestedConfigs []Nester, args []string) (err error) {
+	if opts.FindNested {
+		nestedConfigs = expandNesters(nestedConfigs)
+	}
+	nestedConfigs, err = orderNesters(nestedConfigs)
+	if err != nil {
+		return err
+	}
+	flags := NewFlagfigSet(opts.Name, opts.ErrorHandling)
+	if len(opts.ConfigFileFlag) != 0 {
+		flags.AddConfigFile(opts.ConfigFileFlag, opts.ConfigFileUsage)
+		if _, statErr := os.Stat(opts.ConfigFileDefault); len(opts.ConfigFileDefault) != 0 && statErr == nil {
+			fl := flags.Lookup(opts.ConfigFileFlag)
+			_ = fl.Value.Set(opts.ConfigFileDefault)
+			fl.DefValue = opts.ConfigFileDefault
+		}
+	}
+	if len(opts.EnvPrefix) != 0 {
+		flags.AutomaticEnv(opts.EnvPrefix)
+	}
+	err = registerNesters(flags, nestedConfigs)
+	if err != nil {
+		return err
 	}
 	err = flags.Parse(args)
 	if err != nil {
 		return err
 	}
+	return afterParsed(nestedConfigs, opts.AllAfterParsed)
+}
+
+// afterParsed runs AfterParsed on the nested configs, then Validate on those that are NesterValidators, stopping at
+// the first failure unless all is true. Nothing is validated if any AfterParsed failed
+func afterParsed(nestedConfigs []Nester, all bool) error {
+	var failures NesterErrors
 	for _, nc := range nestedConfigs {
-		err = nc.AfterParsed()
+		err := nc.AfterParsed()
+		if err != nil && !all {
+			return err
+		}
 		if err != nil {
+			failures = append(failures, &NesterError{Nester: nc, Err: err})
+		}
+	}
+	if len(failures) != 0 {
+		return failures
+	}
+	for _, nc := range nestedConfigs {
+		v, ok := nc.(NesterValidator)
+		if !ok {
+			continue
+		}
+		err := v.Validate()
+		if err != nil && !all {
 			return err
 		}
+		if err != nil {
+			failures = append(failures, &NesterError{Nester: nc, Err: err})
+		}
+	}
+	if len(failures) != 0 {
+		return failures
 	}
 	return nil
 }
 
+// ParseNestedAll is ParseNested, but runs AfterParsed on every nested config, returning all of the failures as
+// NesterErrors
+func ParseNestedAll(handling flag.ErrorHandling, nestedConfigs []Nester, args []string) (err error) {
+	return ParseNestedWithOptions(NestedOptions{ErrorHandling: handling, AllAfterParsed: true}, nestedConfigs, args)
+}
+
+// NesterError is a failure of a Nester's AfterParsed or Validate
+type NesterError struct {
+	Nester Nester
+	Err    error
+}
+
+func (e *NesterError) Error() string {
+	return describeNester(e.Nester) + ": " + e.Err.Error()
+}
+
+func (e *NesterError) Unwrap() error {
+	return e.Err
+}
+
+// describeNester names the Nester by its type, and its prefix if it has one
+func describeNester(n Nester) string {
+	prefix := ""
+	for p, ok := n.(*prefixedNester); ok; p, ok = n.(*prefixedNester) {
+		prefix += p.prefix + "."
+		n = p.Nester
+	}
+	if len(prefix) == 0 {
+		return fmt.Sprintf("%T", n)
+	}
+	return fmt.Sprintf("%T (%s)", n, strings.TrimSuffix(prefix, "."))
+}
+
+// NesterErrors lists the failures of every Nester whose AfterParsed or Validate failed, in the order they ran
+type NesterErrors []*NesterError
+
+func (e NesterErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("%d nested configs failed:", len(e)))
+	for _, failure := range e {
+		sb.WriteString("\n  ")
+		sb.WriteString(failure.Error())
+	}
+	return sb.String()
+}
+
 // MustParseNested does the same as ParseNested, but panics on error instead of returning the error
 func MustParseNested(handling flag.ErrorHandling, nestedConfigs []Nester, args []string) {
 	err := ParseNested(handling, nestedConfigs, args)
@@ -145,3 +350,173 @@ func MustParseNested(handling flag.ErrorHandling, nestedConfigs []Nester, args [
 		panic(err)
 	}
 }
+
+// MustParseNestedFromEnv is MustParseNested wired up the way most programs want, named after the program, which is
+// os.Args[0] without its directory and extension. The arguments are os.Args, a -config flag names a configuration
+// file, which defaults to the first one found on the ConfigSearchPath, and every flag may be set from the environment,
+// prefixed with the program's name, so the flag db.host of myapp is read from MYAPP_DB_HOST. It exits on a parse
+// error and panics if an AfterParsed fails:
+//
+//	func main() {
+//		cfg := newAppConfig()
+//		flagfig.MustParseNestedFromEnv(cfg)
+//		run(cfg)
+//	}
+func MustParseNestedFromEnv(nestedConfigs ...Nester) {
+	name := filepath.Base(os.Args[0])
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	err := ParseNestedWithOptions(NestedOptions{
+		Name:              name,
+		ErrorHandling:     flag.ExitOnError,
+		ConfigFileFlag:    "config",
+		ConfigFileUsage:   "configuration file",
+		ConfigFileDefault: FindConfigFile(name),
+		EnvPrefix:         strings.ToUpper(defaultEnvKeyReplacer.Replace(name)),
+	}, nestedConfigs, os.Args[1:])
+	if err != nil {
+		panic(err)
+	}
+}
+
+// orderNesters sorts the Nesters so that each follows the Nesters it depends on, and otherwise keeps their order
+func orderNesters(nesters []Nester) ([]Nester, error) {
+	byKey := make(map[Nester]Nester, len(nesters))
+	for _, n := range nesters {
+		byKey[unwrapNester(n)] = n
+	}
+	out := make([]Nester, 0, len(nesters))
+	// done is true once the Nester is in out, and false while its dependencies are being added
+	done := make(map[Nester]bool, len(nesters))
+	var path []Nester
+	var visit func(n Nester) error
+	visit = func(n Nester) error {
+		key := unwrapNester(n)
+		if finished, ok := done[key]; ok {
+			if finished {
+				return nil
+			}
+			return nesterCycleError(append(path, n))
+		}
+		done[key] = false
+		path = append(path, n)
+		if d, ok := key.(NesterDependent); ok {
+			for _, dep := range d.DependsOn() {
+				parsed, ok := byKey[unwrapNester(dep)]
+				if !ok {
+					return fmt.Errorf("%s depends on %s, which is not being parsed", describeNester(n), describeNester(dep))
+				}
+				if err := visit(parsed); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		done[key] = true
+		out = append(out, n)
+		return nil
+	}
+	for _, n := range nesters {
+		if err := visit(n); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// nesterCycleError describes the Nesters on path, which ends where the cycle closes
+func nesterCycleError(path []Nester) error {
+	last := unwrapNester(path[len(path)-1])
+	start := 0
+	for unwrapNester(path[start]) != last {
+		start++
+	}
+	names := make([]string, 0, len(path)-start)
+	for _, n := range path[start:] {
+		names = append(names, describeNester(n))
+	}
+	return errors.New("nested configs depend on each other: " + strings.Join(names, " -> "))
+}
+
+// unwrapNester is the Nester without its prefixes
+func unwrapNester(n Nester) Nester {
+	for {
+		p, ok := n.(*prefixedNester)
+		if !ok {
+			return n
+		}
+		n = p.Nester
+	}
+}
+
+// expandNesters lists the Nesters, each followed by the Nesters found in its fields, without repeating any
+func expandNesters(nesters []Nester) []Nester {
+	out := make([]Nester, 0, len(nesters))
+	seen := make(map[interface{}]bool)
+	for _, n := range nesters {
+		out = appendNesters(out, n, seen)
+	}
+	return out
+}
+
+// appendNesters appends n and the Nesters in its fields to out, skipping those already seen
+func appendNesters(out []Nester, n Nester, seen map[interface{}]bool) []Nester {
+	if p, ok := n.(*prefixedNester); ok {
+		// What the wrapped Nester holds shares its prefix
+		for _, inner := range appendNesters(nil, p.Nester, seen) {
+			out = append(out, &prefixedNester{Nester: inner, prefix: p.prefix})
+		}
+		return out
+	}
+	v := reflect.ValueOf(n)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() || seen[n] {
+			return out
+		}
+		seen[n] = true
+	}
+	out = append(out, n)
+	return appendFieldNesters(out, v, seen)
+}
+
+// appendFieldNesters appends the Nesters held by v, a struct, a pointer to one, or a slice or array
+func appendFieldNesters(out []Nester, v reflect.Value, seen map[interface{}]bool) []Nester {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			out = appendFieldNesters(out, v.Elem(), seen)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			out = appendValueNesters(out, v.Index(i), seen)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if len(v.Type().Field(i).PkgPath) == 0 {
+				out = appendValueNesters(out, v.Field(i), seen)
+			}
+		}
+	}
+	return out
+}
+
+// appendValueNesters appends v if it is a Nester, or else the Nesters it holds
+func appendValueNesters(out []Nester, v reflect.Value, seen map[interface{}]bool) []Nester {
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return out
+	}
+	if v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface && v.CanAddr() {
+		v = v.Addr()
+	}
+	if n, ok := v.Interface().(Nester); ok {
+		return appendNesters(out, n, seen)
+	}
+	if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
+		// A struct that is not a Nester may still hold some
+		ptr := v.Interface()
+		if seen[ptr] {
+			return out
+		}
+		seen[ptr] = true
+	}
+	return appendFieldNesters(out, v, seen)
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"strconv"
 	"strings"
 	"testing"
@@ -110,3 +112,273 @@ func TestNesterBase(t *testing.T) {
 		t.Error("strings did not match, expected '", expected, "' but got '", actual, "'")
 	}
 }
+
+func TestPrefixed(t *testing.T) {
+	_ = os.Setenv("REPLICA_DB_COOL_STRING", "from env")
+	defer func() { _ = os.Unsetenv("REPLICA_DB_COOL_STRING") }()
+	primary, replica := newMyConfig(), newMyConfig()
+	err := ParseNested(flag.ContinueOnError, []Nester{Prefixed("primary-db", primary), Prefixed("replica-db", replica)},
+		[]string{"-primary-db.mySecretNumber=3", "-replica-db.mySecretNumber=4"})
+	if err != nil {
+		t.Fatal("did not expect an error, but got: ", err)
+	}
+	if primary.MySecretSquare != 9 || replica.MySecretSquare != 16 {
+		t.Errorf("expected 9 and 16, got %d and %d", primary.MySecretSquare, replica.MySecretSquare)
+	}
+	if *primary.MyCoolString != "ice cold, baby" || *replica.MyCoolString != "from env" {
+		t.Errorf("expected the default and the environment, got %q and %q", *primary.MyCoolString, *replica.MyCoolString)
+	}
+}
+
+type myAppConfig struct {
+	NesterBase
+	Server  *myServerConfig
+	Extras  []*myConfig
+	Name    *string
+	ignored *myConfig
+}
+
+func (c *myAppConfig) RegisterFlags(flags *FlagfigSet) {
+	c.Name = flags.String("name", "app", "", "app name")
+}
+
+func TestParseNested_Fields(t *testing.T) {
+	extra := newMyConfig()
+	extra.MySecretNumberConf.FlagName = "extraNumber"
+	extra.MyCoolStringConf.FlagName = "extraString"
+	cfg := &myAppConfig{Server: newMyServerConfig(), Extras: []*myConfig{extra}, ignored: newMyConfig()}
+	args := []string{"-lastName=Wojno", "-mySecretNumber=2", "-extraNumber=3"}
+	opts := NestedOptions{ErrorHandling: flag.ContinueOnError, FindNested: true}
+	if err := ParseNestedWithOptions(opts, []Nester{cfg, cfg.Server}, args); err != nil {
+		t.Fatal("did not expect an error, but got: ", err)
+	}
+	if cfg.Server.FullName != "Chris Wojno" || cfg.Server.NestedConfig.MySecretSquare != 4 || extra.MySecretSquare != 9 {
+		t.Errorf("expected the nested configs to be parsed, got %q, %d and %d", cfg.Server.FullName, cfg.Server.NestedConfig.MySecretSquare, extra.MySecretSquare)
+	}
+	if cfg.ignored.MySecretNumber != nil {
+		t.Error("expected unexported fields to be left alone")
+	}
+}
+
+// delegatingConfig registers and finishes the config it holds itself, as configs did before FindNested
+type delegatingConfig struct {
+	Inner *myConfig
+}
+
+func (c *delegatingConfig) RegisterFlags(flags *FlagfigSet) {
+	c.Inner.RegisterFlags(flags)
+}
+
+func (c *delegatingConfig) AfterParsed() error {
+	return c.Inner.AfterParsed()
+}
+
+func TestParseNested_ManualDelegation(t *testing.T) {
+	cfg := &delegatingConfig{Inner: newMyConfig()}
+	if err := ParseNested(flag.ContinueOnError, []Nester{cfg}, []string{"-mySecretNumber=3"}); err != nil {
+		t.Fatal("did not expect an error, but got: ", err)
+	}
+	if cfg.Inner.MySecretSquare != 9 {
+		t.Error("expected the inner config to be parsed, got ", cfg.Inner.MySecretSquare)
+	}
+
+	cfg = &delegatingConfig{Inner: newMyConfig()}
+	cmd := NewCommand("app", "", flag.ContinueOnError)
+	cmd.Nesters = []Nester{cfg}
+	cmd.Run = func(*Command, []string) error { return nil }
+	if err := cmd.Execute([]string{"-mySecretNumber=4"}); err != nil {
+		t.Fatal("did not expect an error, but got: ", err)
+	}
+	if cfg.Inner.MySecretSquare != 16 {
+		t.Error("expected the inner config to be parsed by the command, got ", cfg.Inner.MySecretSquare)
+	}
+}
+
+func TestParseNestedWithOptions(t *testing.T) {
+	path, remove := testTempFile(t)
+	defer remove()
+	_ = ioutil.WriteFile(path, []byte(`{"firstName": "Jane", "lastName": "Doe", "mySecretNumber": 5}`), 0600)
+	_ = os.Setenv("NESTED_OPTS_LASTNAME", "Roe")
+	defer func() { _ = os.Unsetenv("NESTED_OPTS_LASTNAME") }()
+	opts := NestedOptions{
+		Name:              "app",
+		ErrorHandling:     flag.ContinueOnError,
+		ConfigFileFlag:    "config",
+		ConfigFileUsage:   "configuration file",
+		ConfigFileDefault: path,
+		EnvPrefix:         "NESTED_OPTS",
+		FindNested:        true,
+	}
+	cfg := newMyServerConfig()
+	cfg.LastNameConf.EnvName = ""
+	if err := ParseNestedWithOptions(opts, []Nester{cfg}, []string{"-mySecretNumber=6"}); err != nil {
+		t.Fatal("did not expect an error, but got: ", err)
+	}
+	if cfg.FullName != "Jane Roe" || cfg.NestedConfig.MySecretSquare != 36 {
+		t.Errorf("expected the file, then the environment, then the command line, got %q and %d", cfg.FullName, cfg.NestedConfig.MySecretSquare)
+	}
+
+	opts.ConfigFileDefault = path + ".missing"
+	cfg = newMyServerConfig()
+	if err := ParseNestedWithOptions(opts, []Nester{cfg}, []string{"-lastName=Wojno"}); err != nil {
+		t.Fatal("expected a missing default file to be skipped, but got: ", err)
+	}
+}
+
+type failingConfig struct {
+	NesterBase
+	message string
+}
+
+func (c *failingConfig) RegisterFlags(flags *FlagfigSet) {}
+
+func (c *failingConfig) AfterParsed() error {
+	return errors.New(c.message)
+}
+
+func TestParseNestedAll(t *testing.T) {
+	first, second := &failingConfig{message: "first"}, &failingConfig{message: "second"}
+	other := newMyConfig()
+	err := ParseNestedAll(flag.ContinueOnError, []Nester{first, Prefixed("other", other), Prefixed("db", second)}, []string{"-other.mySecretNumber=2"})
+	failures, ok := err.(NesterErrors)
+	if !ok || len(failures) != 2 || failures[0].Nester != first {
+		t.Fatal("expected both failures, got ", err)
+	}
+	expected := "2 nested configs failed:\n  *flagfig.failingConfig: first\n  *flagfig.failingConfig (db): second"
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err)
+	}
+	if other.MySecretSquare != 4 {
+		t.Error("expected AfterParsed to run after a failure, got ", other.MySecretSquare)
+	}
+	if err = ParseNested(flag.ContinueOnError, []Nester{first, second}, []string{}); err == nil || err.Error() != "first" {
+		t.Error("expected ParseNested to stop at the first failure, got ", err)
+	}
+}
+
+type portConfig struct {
+	NesterBase
+	Port    *int
+	checked int
+}
+
+func (c *portConfig) RegisterFlags(flags *FlagfigSet) {
+	c.Port = flags.Int("port", 8080, "", "port")
+}
+
+func (c *portConfig) Validate() error {
+	c.checked++
+	if *c.Port < 1 || *c.Port > 65535 {
+		return fmt.Errorf("port %d is out of range", *c.Port)
+	}
+	return nil
+}
+
+func TestNesterValidator(t *testing.T) {
+	cases := map[string]struct {
+		nesters  func(c *portConfig) []Nester
+		args     []string
+		expected string
+		checked  int
+	}{
+		"valid": {
+			nesters: func(c *portConfig) []Nester { return []Nester{c} },
+			args:    []string{"-port=80"},
+			checked: 1,
+		},
+		"invalid": {
+			nesters:  func(c *portConfig) []Nester { return []Nester{c} },
+			args:     []string{"-port=0"},
+			expected: "port 0 is out of range",
+			checked:  1,
+		},
+		"prefixed": {
+			nesters:  func(c *portConfig) []Nester { return []Nester{Prefixed("admin", c)} },
+			args:     []string{"-admin.port=70000"},
+			expected: "port 70000 is out of range",
+			checked:  1,
+		},
+		"after parsed failed": {
+			nesters: func(c *portConfig) []Nester {
+				return []Nester{&failingConfig{message: "broken"}, c}
+			},
+			args:     []string{"-port=0"},
+			expected: "broken",
+		},
+	}
+	for caseName, c := range cases {
+		config := &portConfig{}
+		err := ParseNested(flag.ContinueOnError, c.nesters(config), c.args)
+		if len(c.expected) == 0 && err != nil {
+			t.Errorf("case %s: unexpected error: %s", caseName, err)
+		}
+		if len(c.expected) != 0 && (err == nil || err.Error() != c.expected) {
+			t.Errorf("case %s: expected %q, got %v", caseName, c.expected, err)
+		}
+		if config.checked != c.checked {
+			t.Errorf("case %s: expected Validate to run %d times, got %d", caseName, c.checked, config.checked)
+		}
+	}
+}
+
+// orderedConfig records the order AfterParsed runs in
+type orderedConfig struct {
+	NesterBase
+	name  string
+	deps  []Nester
+	order *[]string
+}
+
+func (c *orderedConfig) RegisterFlags(flags *FlagfigSet) {}
+
+func (c *orderedConfig) DependsOn() []Nester {
+	return c.deps
+}
+
+func (c *orderedConfig) AfterParsed() error {
+	*c.order = append(*c.order, c.name)
+	return nil
+}
+
+func TestNesterDependent(t *testing.T) {
+	var order []string
+	tls := &orderedConfig{name: "tls", order: &order}
+	db := &orderedConfig{name: "db", order: &order}
+	server := &orderedConfig{name: "server", deps: []Nester{tls, db}, order: &order}
+	app := &orderedConfig{name: "app", deps: []Nester{server}, order: &order}
+	err := ParseNested(flag.ContinueOnError, []Nester{app, server, Prefixed("admin", db), tls}, []string{})
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	expected := "tls,db,server,app"
+	if strings.Join(order, ",") != expected {
+		t.Errorf("expected %s, got %s", expected, strings.Join(order, ","))
+	}
+
+	tls.deps = []Nester{app}
+	err = ParseNested(flag.ContinueOnError, []Nester{app, server, db, tls}, []string{})
+	expectedErr := "nested configs depend on each other: *flagfig.orderedConfig -> *flagfig.orderedConfig -> *flagfig.orderedConfig -> *flagfig.orderedConfig"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("expected %q, got %v", expectedErr, err)
+	}
+
+	tls.deps = nil
+	err = ParseNested(flag.ContinueOnError, []Nester{app, server, db}, []string{})
+	expectedErr = "*flagfig.orderedConfig depends on *flagfig.orderedConfig, which is not being parsed"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("expected %q, got %v", expectedErr, err)
+	}
+}
+
+func TestMustParseNestedFromEnv(t *testing.T) {
+	defer func(saved []string) { os.Args = saved }(os.Args)
+	os.Args = []string{"/usr/local/bin/my-app", "-mySecretNumber=3"}
+	_ = os.Setenv("MY_APP_MYCOOLSTRING", "from env")
+	defer func() { _ = os.Unsetenv("MY_APP_MYCOOLSTRING") }()
+	cfg := newMyConfig()
+	cfg.MyCoolStringConf.EnvName = ""
+	MustParseNestedFromEnv(cfg)
+	if cfg.MySecretSquare != 9 || *cfg.MyCoolString != "from env" {
+		t.Errorf("expected 9 and the environment, got %d and %q", cfg.MySecretSquare, *cfg.MyCoolString)
+	}
+}
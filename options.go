@@ -0,0 +1,282 @@
+package flagfig
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// FlagOption customizes a single flag. Any number of them may be passed at the end of a flag constructor:
+//
+//	password := flags.String("db-password", "", "DB_PASSWORD", "database password", flagfig.FromSources(flagfig.SourceEnv))
+type FlagOption func(f *FlagfigSet, name string)
+
+// applyOptions applies each option to the flag with the given name, which must already be defined
+func (f *FlagfigSet) applyOptions(name string, opts []FlagOption) {
+	for _, opt := range opts {
+		opt(f, name)
+	}
+}
+
+// FromSources restricts the flag to being set by only the listed sources. Collate fails if any other source provides
+// a value. This is useful for secrets, which should not be given on the command line where they show up in ps:
+//
+//	flagfig.FromSources(flagfig.SourceEnv, flagfig.SourceFile)
+func FromSources(sources ...Source) FlagOption {
+	return func(f *FlagfigSet, name string) {
+		f.allowedSources[name] = sources
+	}
+}
+
+// sourceAllowed is true if the flag may be set by source. Flags without a FromSources option may be set by any source
+func (f *FlagfigSet) sourceAllowed(name string, source Source) bool {
+	sources, ok := f.allowedSources[name]
+	if !ok {
+		return true
+	}
+	for _, s := range sources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+// MergeStrategy decides what happens when more than one source sets a StringSlice or StringMap flag
+type MergeStrategy int
+
+const (
+	// MergeReplace keeps only the value from the source with the highest precedence. This is the default
+	MergeReplace MergeStrategy = iota
+	// MergeAppend combines the values from every source. Lists are appended in order of precedence, so the items
+	// from configuration files come first and the command line items come last. Maps are merged, and when more than
+	// one source sets the same key, the source with the highest precedence wins
+	MergeAppend
+)
+
+// Merge chooses how the values for a StringSlice or StringMap flag are combined across sources.
+// It has no effect on other types of flags
+func Merge(strategy MergeStrategy) FlagOption {
+	return func(f *FlagfigSet, name string) {
+		f.mergeStrategies[name] = strategy
+	}
+}
+
+// prepareMerges resets the flags using MergeAppend so that every source is applied to them, in order.
+// Command line values are set aside and returned, to be applied last by finishMerges
+func (f *FlagfigSet) prepareMerges(visited map[string]bool) (commandLine map[string]string, err error) {
+	commandLine = make(map[string]string)
+	for name, strategy := range f.mergeStrategies {
+		m, ok := f.FlagSet.Lookup(name).Value.(mergeable)
+		if !ok || strategy != MergeAppend {
+			continue
+		}
+		if visited[name] {
+			if _, ok := f.mergeCommandLine[name]; !ok {
+				f.mergeCommandLine[name] = valueText(f.FlagSet.Lookup(name))
+			}
+			commandLine[name] = f.mergeCommandLine[name]
+		}
+		m.reset()
+		m.setMerge(true)
+	}
+	return
+}
+
+// finishMerges applies the command line values set aside by prepareMerges, then returns the flags to replacing
+func (f *FlagfigSet) finishMerges(commandLine map[string]string) {
+	for name, raw := range commandLine {
+		fl := f.FlagSet.Lookup(name)
+		_ = setValueText(fl, fl, raw)
+		f.origins[name] = origin{source: SourceFlag}
+	}
+	for name := range f.mergeStrategies {
+		if m, ok := f.FlagSet.Lookup(name).Value.(mergeable); ok {
+			m.setMerge(false)
+		}
+	}
+}
+
+// repeatable makes flags holding lists and maps add up when they are repeated on the command line, as in
+// -header=a -header=b, while the first one still replaces the default
+func (f *FlagfigSet) repeatable(repeat bool) {
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		if m, ok := fl.Value.(mergeable); ok {
+			m.unset()
+			m.setMerge(repeat)
+		}
+	})
+}
+
+// DefaultFrom makes the flag default to the resolved value of another flag, when no source sets it.
+// For example, the address to advertise is usually the address being listened on:
+//
+//	listen := flags.String("listen-addr", ":8080", "MYAPP_LISTEN_ADDR", "address to listen on")
+//	advertise := flags.String("advertise-addr", "", "MYAPP_ADVERTISE_ADDR", "address to advertise", flagfig.DefaultFrom("listen-addr"))
+//
+// The other flag must be of the same type. Chains are allowed, but cycles are reported as an error by Collate
+func DefaultFrom(other string) FlagOption {
+	return func(f *FlagfigSet, name string) {
+		f.defaultFrom[name] = other
+	}
+}
+
+// applyDefaultFrom copies values into the flags declared with DefaultFrom that no source has set
+func (f *FlagfigSet) applyDefaultFrom() (err error) {
+	done := make(map[string]bool)
+	for name := range f.defaultFrom {
+		err = f.resolveDefaultFrom(name, done, nil)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// resolveDefaultFrom resolves the flag's DefaultFrom chain. resolving holds the chain so far, which is how cycles are found
+func (f *FlagfigSet) resolveDefaultFrom(name string, done map[string]bool, resolving []string) (err error) {
+	other, ok := f.defaultFrom[name]
+	if !ok || done[name] {
+		return nil
+	}
+	for i, r := range resolving {
+		if r == name {
+			return fmt.Errorf("cycle in DefaultFrom: %s", strings.Join(append(resolving[i:], name), " -> "))
+		}
+	}
+	err = f.resolveDefaultFrom(other, done, append(resolving, name))
+	if err != nil {
+		return err
+	}
+	done[name] = true
+	if f.IsSet(name) {
+		return nil
+	}
+	fl, from := f.FlagSet.Lookup(name), f.FlagSet.Lookup(other)
+	if fl == nil || from == nil {
+		return fmt.Errorf("flag -%s defaults from -%s, which is not defined", name, other)
+	}
+	err = setValueText(fl, from, valueText(from))
+	if err != nil {
+		_, err = f.redactValue(other, valueText(from), err)
+		return fmt.Errorf("flag -%s cannot default from -%s, set by %s: %v", name, other, f.describeOrigin(other), err)
+	}
+	f.origins[name] = origin{source: SourceDefault, detail: "-" + other}
+	return nil
+}
+
+// valueText is the flag's value in a form that its Set method, or the Set method of a flag of the same type, accepts.
+// Lists and maps use JSON, so items with commas survive
+func valueText(fl *flag.Flag) string {
+	if _, ok := fl.Value.(mergeable); ok {
+		raw, err := json.Marshal(fl.Value.(flag.Getter).Get())
+		if err == nil {
+			return string(raw)
+		}
+	}
+	return fl.Value.String()
+}
+
+// setValueText sets the flag to text, made by valueText for from, which is the flag itself or another one
+func setValueText(fl, from *flag.Flag, text string) error {
+	if _, ok := from.Value.(mergeable); ok {
+		expectJSON(fl.Value)
+	}
+	return fl.Value.Set(text)
+}
+
+// expectJSON makes the next Set of a jsonSettable value decode JSON
+func expectJSON(v flag.Value) {
+	if j, ok := v.(jsonSettable); ok {
+		j.expectJSON()
+	}
+}
+
+// Sensitive marks the flag as a secret, such as a password or token. Its value is shown as **** wherever flagfig
+// prints configuration: the effective configuration printed by AddValidateFlag, DumpJSON, the AdminHandler, the
+// trace written for SetTrace, and the errors for values the flag does not accept. DumpYAML and DumpEnv leave it out.
+// See UnsafeRevealSensitive to show it anyway, while debugging
+func Sensitive() FlagOption {
+	return func(f *FlagfigSet, name string) {
+		f.sensitive[name] = true
+	}
+}
+
+// Hidden leaves the flag out of usage, generated documentation and shell completions, while it still works as usual.
+// It suits flags meant for debugging or for other programs, rather than for people
+func Hidden() FlagOption {
+	return func(f *FlagfigSet, name string) {
+		f.hidden[name] = true
+	}
+}
+
+// Reloadable lets the flag be changed while the program runs, through the handler made by AdminHandler. Only mark
+// flags whose new values the program picks up, say from a Snapshot or an OnParsed hook
+func Reloadable() FlagOption {
+	return func(f *FlagfigSet, name string) {
+		f.reloadable[name] = true
+	}
+}
+
+func UnsafeRevealSensitive(reveal bool) {
+	CommandLine.UnsafeRevealSensitive(reveal)
+}
+
+// UnsafeRevealSensitive, when reveal is true, stops flagfig from hiding the values of Sensitive flags, so secrets are
+// printed as they are wherever flagfig prints configuration. This is for tracking down a bad secret while debugging,
+// and must never be left on where the output is logged or served. Snapshot.Checksum and expvarflags.Publish still leave
+// Sensitive flags out
+func (f *FlagfigSet) UnsafeRevealSensitive(reveal bool) {
+	f.revealSensitive = reveal
+}
+
+// redactedValue is what is shown in place of the value of a Sensitive flag
+const redactedValue = "****"
+
+// redacted is true if the value of the flag must be hidden: it is Sensitive, and not revealed by UnsafeRevealSensitive
+func (f *FlagfigSet) redacted(name string) bool {
+	return f.sensitive[name] && !f.revealSensitive
+}
+
+// redact is the value of the flag as it should be shown to people: **** for a redacted flag that has a value
+func (f *FlagfigSet) redact(name, value string) string {
+	if f.redacted(name) && len(value) != 0 {
+		return redactedValue
+	}
+	return value
+}
+
+// displayValue is the flag's value as it should be shown to people
+func (f *FlagfigSet) displayValue(fl *flag.Flag) string {
+	return f.redact(fl.Name, fl.Value.String())
+}
+
+// redactValue hides value, and any mention of it in err, if the flag is redacted. Errors often quote the value they
+// could not use, as in: parsing "hunter2": invalid syntax
+func (f *FlagfigSet) redactValue(name, value string, err error) (string, error) {
+	if !f.redacted(name) || len(value) == 0 {
+		return value, err
+	}
+	return redactedValue, &redactedError{err: err, value: value}
+}
+
+// redactedError is an error whose message mentions the value of a redacted flag, shown as **** instead
+type redactedError struct {
+	err   error
+	value string
+}
+
+func (e *redactedError) Error() string {
+	return strings.ReplaceAll(e.err.Error(), e.value, redactedValue)
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// invalidValue is the error for a value the flag does not accept, redacted if need be
+func (f *FlagfigSet) invalidValue(name string, source Source, detail, value string, err error) *ErrInvalidValue {
+	value, err = f.redactValue(name, value, err)
+	return &ErrInvalidValue{Flag: name, Source: source, Detail: detail, Value: value, Err: err}
+}
@@ -0,0 +1,224 @@
+package flagfig
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFromSources(t *testing.T) {
+	tmpFileName, tfremove := testTempFile(t)
+	defer tfremove()
+	if err := ioutil.WriteFile(tmpFileName, []byte(`{"file-only":"x","env-only":"y"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Setenv("ENV_SECRET", "from-env")
+	defer func() { _ = os.Unsetenv("ENV_SECRET") }()
+
+	cases := map[string]struct {
+		args     []string
+		expected string
+	}{
+		"command line is rejected": {
+			args:     []string{"-env-only=x"},
+			expected: "command line",
+		},
+		"config file is rejected": {
+			args:     []string{"-config=" + tmpFileName},
+			expected: "configuration file",
+		},
+		"allowed sources are fine": {
+			args: []string{},
+		},
+	}
+	for caseName, c := range cases {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.AddConfigFile("config", "config file")
+		f.String("file-only", "", "", "file only", FromSources(SourceFile))
+		secret := f.String("env-only", "", "ENV_SECRET", "env only", FromSources(SourceEnv))
+		err := f.Parse(c.args)
+		if len(c.expected) == 0 {
+			if err != nil {
+				t.Errorf("case %s: unexpected error: %s", caseName, err)
+			}
+			if *secret != "from-env" {
+				t.Errorf("case %s: env-only should be read from the environment, is %q", caseName, *secret)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), c.expected) {
+			t.Errorf("case %s: expected an error mentioning %s, got %v", caseName, c.expected, err)
+		}
+	}
+}
+
+func TestFromSourcesRejectsEnv(t *testing.T) {
+	_ = os.Setenv("ENV_NOT_ALLOWED", "from-env")
+	defer func() { _ = os.Unsetenv("ENV_NOT_ALLOWED") }()
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.String("flag-only", "", "ENV_NOT_ALLOWED", "flag only", FromSources(SourceFlag))
+	err := f.Parse([]string{})
+	if err == nil || !strings.Contains(err.Error(), "ENV_NOT_ALLOWED") {
+		t.Error("expected an error naming the environment variable, got ", err)
+	}
+}
+
+func TestMergeAppend(t *testing.T) {
+	tmpFileName, tfremove := testTempFile(t)
+	defer tfremove()
+	content := `{"headers":["file"],"replaced":["file"],"labels":{"a":"file","b":"file"}}`
+	if err := ioutil.WriteFile(tmpFileName, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Setenv("ENV_MERGE_HEADERS", "env1,env2")
+	_ = os.Setenv("ENV_MERGE_LABELS", `{"b":"env","c":"env"}`)
+	defer func() {
+		_ = os.Unsetenv("ENV_MERGE_HEADERS")
+		_ = os.Unsetenv("ENV_MERGE_LABELS")
+	}()
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.AddConfigFile("config", "config file")
+	headers := f.StringSlice("headers", []string{"default"}, "ENV_MERGE_HEADERS", "headers", Merge(MergeAppend))
+	replaced := f.StringSlice("replaced", []string{"default"}, "ENV_MERGE_HEADERS", "replaced")
+	labels := f.StringMap("labels", nil, "ENV_MERGE_LABELS", "labels", Merge(MergeAppend))
+	if err := f.Parse([]string{"-config", tmpFileName, "-headers=flag", "-labels=c=flag"}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*headers, []string{"file", "env1", "env2", "flag"}) {
+		t.Error("headers should be appended in order of precedence, is ", *headers)
+	}
+	if !reflect.DeepEqual(*replaced, []string{"env1", "env2"}) {
+		t.Error("replaced should only have the environment's items, is ", *replaced)
+	}
+	if !reflect.DeepEqual(*labels, map[string]string{"a": "file", "b": "env", "c": "flag"}) {
+		t.Error("labels should be merged by precedence, is ", *labels)
+	}
+	if source, _ := f.Origin("headers"); source != SourceFlag {
+		t.Error("headers should report the command line as its origin, got ", source)
+	}
+}
+
+func TestMergeAppendWithRequiredEnv(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	headers := f.StringSliceEnv("headers", nil, EnvOpt{Name: "ENV_MERGE_MISSING", Required: true}, "headers",
+		Merge(MergeAppend))
+	if err := f.Parse([]string{"-headers=flag"}); err != nil {
+		t.Fatal("the command line should satisfy a required environment variable, got ", err)
+	}
+	if !reflect.DeepEqual(*headers, []string{"flag"}) {
+		t.Error("headers should be [flag], is ", *headers)
+	}
+}
+
+func TestDefaultFrom(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.String("listen-addr", ":8080", "", "listen")
+	advertise := f.String("advertise-addr", "", "", "advertise", DefaultFrom("listen-addr"))
+	public := f.String("public-addr", "", "", "public", DefaultFrom("advertise-addr"))
+	explicit := f.String("explicit-addr", "", "", "explicit", DefaultFrom("listen-addr"))
+	if err := f.Parse([]string{"-listen-addr=:9090", "-explicit-addr=:1"}); err != nil {
+		t.Fatal(err)
+	}
+	if *advertise != ":9090" {
+		t.Error("advertise-addr should default to listen-addr, is ", *advertise)
+	}
+	if *public != ":9090" {
+		t.Error("public-addr should default through advertise-addr, is ", *public)
+	}
+	if *explicit != ":1" {
+		t.Error("explicit-addr was set and should not be defaulted, is ", *explicit)
+	}
+	if f.IsSet("advertise-addr") {
+		t.Error("advertise-addr fell back to a default and should not be set")
+	}
+
+	f = NewFlagfigSet("test", flag.ContinueOnError)
+	f.String("a", "", "", "a", DefaultFrom("b"))
+	f.String("b", "", "", "b", DefaultFrom("a"))
+	if err := f.Parse([]string{}); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Error("expected a cycle error, got ", err)
+	}
+}
+
+func TestSensitiveRedaction(t *testing.T) {
+	tmpFileName, tfremove := testTempFile(t)
+	defer tfremove()
+	if err := ioutil.WriteFile(tmpFileName, []byte(`{"pin":"12ab"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Setenv("ENV_SECRET_PIN", "12ab")
+	defer func() { _ = os.Unsetenv("ENV_SECRET_PIN") }()
+
+	cases := map[string]struct {
+		args   []string
+		env    bool
+		reveal bool
+	}{
+		"command line": {
+			args: []string{"-pin=12ab"},
+		},
+		"command line, collecting errors": {
+			args: []string{"-collect", "-pin=12ab"},
+		},
+		"environment": {
+			env: true,
+		},
+		"configuration file": {
+			args: []string{"-config=" + tmpFileName},
+		},
+		"revealed": {
+			args:   []string{"-pin=12ab"},
+			reveal: true,
+		},
+	}
+	for caseName, c := range cases {
+		handling := flag.ContinueOnError
+		args := c.args
+		if len(args) != 0 && args[0] == "-collect" {
+			handling, args = CollectErrors, args[1:]
+		}
+		f := NewFlagfigSet("test", handling)
+		f.SetOutput(ioutil.Discard)
+		f.AddConfigFile("config", "config file")
+		envName := ""
+		if c.env {
+			envName = "ENV_SECRET_PIN"
+		}
+		f.Int("pin", 0, envName, "pin", Sensitive())
+		f.UnsafeRevealSensitive(c.reveal)
+		err := f.Parse(args)
+		if c.reveal {
+			if err == nil || !strings.Contains(err.Error(), "12ab") {
+				t.Errorf("case %s: expected the value to be revealed, got %v", caseName, err)
+			}
+			continue
+		}
+		var invalid *ErrInvalidValue
+		if !errors.As(err, &invalid) {
+			t.Fatalf("case %s: expected an invalid value error, got %v", caseName, err)
+		}
+		if strings.Contains(err.Error(), "12ab") || invalid.Value != redactedValue {
+			t.Errorf("case %s: expected the value to be redacted, got %v", caseName, err)
+		}
+	}
+}
+
+func TestSensitiveRedactionInTrace(t *testing.T) {
+	for _, reveal := range []bool{false, true} {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.String("password", "", "", "password", Sensitive())
+		f.UnsafeRevealSensitive(reveal)
+		trace := &strings.Builder{}
+		f.SetTrace(trace)
+		if err := f.Parse([]string{"-password=hunter2"}); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(trace.String(), "hunter2") != reveal {
+			t.Errorf("expected the password to be shown only when revealed (%t), got %s", reveal, trace.String())
+		}
+	}
+}
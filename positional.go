@@ -0,0 +1,238 @@
+package flagfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ArgType is the type a positional argument is converted to
+type ArgType int
+
+const (
+	// StringArg is taken as is
+	StringArg ArgType = iota
+	// IntArg is converted with strconv.Atoi
+	IntArg
+	// Float64Arg is converted with strconv.ParseFloat
+	Float64Arg
+	// BoolArg is converted with strconv.ParseBool
+	BoolArg
+	// DurationArg is converted with time.ParseDuration
+	DurationArg
+)
+
+func (t ArgType) String() string {
+	switch t {
+	case StringArg:
+		return "string"
+	case IntArg:
+		return "int"
+	case Float64Arg:
+		return "float64"
+	case BoolArg:
+		return "bool"
+	case DurationArg:
+		return "duration"
+	}
+	return "unknown"
+}
+
+// PositionalArg is a positional argument declared with Arg. Its value is available after Parse
+type PositionalArg struct {
+	Name     string
+	Type     ArgType
+	Required bool
+	raw      string
+	value    interface{}
+	isSet    bool
+}
+
+// Arg declares a positional argument, to be added to a set with Positional. Required arguments must come before
+// the optional ones
+func Arg(name string, typ ArgType, required bool) *PositionalArg {
+	return &PositionalArg{Name: name, Type: typ, Required: required}
+}
+
+// Positional declares the positional arguments of the CommandLine
+func Positional(args ...*PositionalArg) {
+	CommandLine.Positional(args...)
+}
+
+// Positional declares the positional arguments, in the order they are expected after the flags. Parse converts them
+// to their types and fails if a required one is missing, instead of leaving the caller to slice Args():
+//
+//	input := flagfig.Arg("input", flagfig.StringArg, true)
+//	count := flagfig.Arg("count", flagfig.IntArg, false)
+//	flags.Positional(input, count)
+//	err := flags.Parse(os.Args[1:])
+//	read(input.String(), count.Int())
+//
+// Arguments beyond the declared ones are not an error, Args still returns all of the positional arguments.
+// PassthroughArgs are not counted
+func (f *FlagfigSet) Positional(args ...*PositionalArg) {
+	for _, arg := range args {
+		if arg.Required && len(f.positionals) != 0 && !f.positionals[len(f.positionals)-1].Required {
+			panic(fmt.Sprintf("%s required argument <%s> follows an optional one", f.Name(), arg.Name))
+		}
+		f.positionals = append(f.positionals, arg)
+	}
+}
+
+// ExactArgs requires exactly n positional arguments on the CommandLine
+func ExactArgs(n int) {
+	CommandLine.ExactArgs(n)
+}
+
+// ExactArgs requires exactly n positional arguments. Parse fails with a usage error otherwise
+func (f *FlagfigSet) ExactArgs(n int) {
+	f.minArgs, f.maxArgs = n, n
+}
+
+// MinArgs requires at least n positional arguments on the CommandLine
+func MinArgs(n int) {
+	CommandLine.MinArgs(n)
+}
+
+// MinArgs requires at least n positional arguments. Parse fails with a usage error otherwise
+func (f *FlagfigSet) MinArgs(n int) {
+	f.minArgs = n
+}
+
+// MaxArgs allows at most n positional arguments on the CommandLine
+func MaxArgs(n int) {
+	CommandLine.MaxArgs(n)
+}
+
+// MaxArgs allows at most n positional arguments. Parse fails with a usage error otherwise. MaxArgs(0) rejects all
+// positional arguments
+func (f *FlagfigSet) MaxArgs(n int) {
+	f.maxArgs = n
+}
+
+// checkArgCount reports a problem if the number of positional arguments is outside of ExactArgs, MinArgs and MaxArgs
+func (f *FlagfigSet) checkArgCount() error {
+	n := len(f.ownArgs())
+	switch {
+	case f.minArgs == f.maxArgs && n != f.minArgs:
+		return fmt.Errorf("expected %s, got %d", pluralArgs(f.minArgs), n)
+	case n < f.minArgs:
+		return fmt.Errorf("expected at least %s, got %d", pluralArgs(f.minArgs), n)
+	case f.maxArgs >= 0 && n > f.maxArgs:
+		return fmt.Errorf("expected at most %s, got %d", pluralArgs(f.maxArgs), n)
+	}
+	return nil
+}
+
+// pluralArgs is "no arguments", "1 argument" or "n arguments"
+func pluralArgs(n int) string {
+	switch n {
+	case 0:
+		return "no arguments"
+	case 1:
+		return "1 argument"
+	}
+	return fmt.Sprintf("%d arguments", n)
+}
+
+// parsePositionals checks the number of positional arguments and converts them to their declared types
+func (f *FlagfigSet) parsePositionals() error {
+	if err := f.checkArgCount(); err != nil {
+		return f.failParse(err)
+	}
+	args := f.ownArgs()
+	missing := make([]string, 0)
+	for i, arg := range f.positionals {
+		if i >= len(args) {
+			if arg.Required {
+				missing = append(missing, "<"+arg.Name+">")
+			}
+			continue
+		}
+		if err := arg.set(args[i]); err != nil {
+			return f.failParse(err)
+		}
+	}
+	switch len(missing) {
+	case 0:
+		return nil
+	case 1:
+		return f.failParse(fmt.Errorf("missing required argument %s", missing[0]))
+	}
+	return f.failParse(fmt.Errorf("missing required arguments %s", strings.Join(missing, ", ")))
+}
+
+// set converts the argument from the command line
+func (a *PositionalArg) set(raw string) (err error) {
+	var v interface{}
+	switch a.Type {
+	case IntArg:
+		v, err = strconv.Atoi(raw)
+	case Float64Arg:
+		v, err = strconv.ParseFloat(raw, 64)
+	case BoolArg:
+		v, err = strconv.ParseBool(raw)
+	case DurationArg:
+		v, err = time.ParseDuration(raw)
+	default:
+		v = raw
+	}
+	if err != nil {
+		return fmt.Errorf("argument <%s> is %q, but must be of type %s", a.Name, raw, a.Type)
+	}
+	a.raw, a.value, a.isSet = raw, v, true
+	return nil
+}
+
+// IsSet reports whether the argument was given on the command line
+func (a *PositionalArg) IsSet() bool {
+	return a.isSet
+}
+
+// Get is the converted value, or nil if the argument was not given
+func (a *PositionalArg) Get() interface{} {
+	return a.value
+}
+
+// String is the argument as it was given on the command line
+func (a *PositionalArg) String() string {
+	return a.raw
+}
+
+// Int is the value of an IntArg, or 0
+func (a *PositionalArg) Int() int {
+	v, _ := a.value.(int)
+	return v
+}
+
+// Float64 is the value of a Float64Arg, or 0
+func (a *PositionalArg) Float64() float64 {
+	v, _ := a.value.(float64)
+	return v
+}
+
+// Bool is the value of a BoolArg, or false
+func (a *PositionalArg) Bool() bool {
+	v, _ := a.value.(bool)
+	return v
+}
+
+// Duration is the value of a DurationArg, or 0
+func (a *PositionalArg) Duration() time.Duration {
+	v, _ := a.value.(time.Duration)
+	return v
+}
+
+// argsSynopsis lists the declared positional arguments for usage, optional ones in brackets
+func (f *FlagfigSet) argsSynopsis() string {
+	words := make([]string, 0, len(f.positionals))
+	for _, arg := range f.positionals {
+		if arg.Required {
+			words = append(words, "<"+arg.Name+">")
+		} else {
+			words = append(words, "[<"+arg.Name+">]")
+		}
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,145 @@
+package flagfig
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPositional(t *testing.T) {
+	cases := map[string]struct {
+		args     []string
+		expected string
+	}{
+		"all": {
+			args: []string{"-v", "in.txt", "3", "1.5", "true", "2s", "extra"},
+		},
+		"optional missing": {
+			args: []string{"in.txt"},
+		},
+		"required missing": {
+			args:     []string{"-v"},
+			expected: "missing required argument <input>",
+		},
+		"not an int": {
+			args:     []string{"in.txt", "three"},
+			expected: `argument <count> is "three", but must be of type int`,
+		},
+	}
+	for caseName, c := range cases {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.SetOutput(&bytes.Buffer{})
+		f.Bool("v", false, "", "verbose")
+		input := Arg("input", StringArg, true)
+		count := Arg("count", IntArg, false)
+		ratio := Arg("ratio", Float64Arg, false)
+		dry := Arg("dry", BoolArg, false)
+		wait := Arg("wait", DurationArg, false)
+		f.Positional(input, count, ratio, dry, wait)
+		err := f.Parse(c.args)
+		if len(c.expected) != 0 {
+			if err == nil || err.Error() != c.expected {
+				t.Errorf("case %s: expected %q, got %v", caseName, c.expected, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %s: unexpected error: %s", caseName, err)
+			continue
+		}
+		if input.String() != "in.txt" || !input.IsSet() {
+			t.Errorf("case %s: expected input in.txt, got %q", caseName, input)
+		}
+		if len(c.args) < 3 {
+			if count.IsSet() || count.Get() != nil || count.Int() != 0 {
+				t.Errorf("case %s: expected count to be unset, got %v", caseName, count.Get())
+			}
+			continue
+		}
+		if count.Int() != 3 || ratio.Float64() != 1.5 || !dry.Bool() || wait.Duration() != 2*time.Second {
+			t.Errorf("case %s: got %v %v %v %v", caseName, count.Get(), ratio.Get(), dry.Get(), wait.Get())
+		}
+		if f.NArg() != 6 {
+			t.Errorf("case %s: expected Args to keep all 6 arguments, got %d", caseName, f.NArg())
+		}
+	}
+}
+
+func TestPositional_RequiredAfterOptional(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(string), "<output>") {
+			t.Error("expected a panic about <output>, got ", r)
+		}
+	}()
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.Positional(Arg("input", StringArg, false), Arg("output", StringArg, true))
+}
+
+func TestPositional_CommandUsage(t *testing.T) {
+	cmd := NewCommand("copy", "", flag.ContinueOnError)
+	out := &bytes.Buffer{}
+	cmd.Flags.SetOutput(out)
+	cmd.Flags.Positional(Arg("from", StringArg, true), Arg("to", StringArg, false))
+	cmd.Run = func(cmd *Command, args []string) error { return nil }
+	if err := cmd.Execute([]string{}); err == nil {
+		t.Fatal("expected an error for the missing argument")
+	}
+	expected := "missing required argument <from>\nUsage: copy [flags] <from> [<to>]\n"
+	if !strings.HasPrefix(out.String(), expected) {
+		t.Errorf("expected %q, got %q", expected, out)
+	}
+}
+
+func TestArgCount(t *testing.T) {
+	cases := map[string]struct {
+		limit    func(f *FlagfigSet)
+		args     []string
+		expected string
+	}{
+		"exact": {
+			limit: func(f *FlagfigSet) { f.ExactArgs(2) },
+			args:  []string{"a", "b"},
+		},
+		"exact, too many": {
+			limit:    func(f *FlagfigSet) { f.ExactArgs(1) },
+			args:     []string{"a", "b"},
+			expected: "expected 1 argument, got 2",
+		},
+		"too few": {
+			limit:    func(f *FlagfigSet) { f.MinArgs(2) },
+			args:     []string{"-v", "a"},
+			expected: "expected at least 2 arguments, got 1",
+		},
+		"too many": {
+			limit:    func(f *FlagfigSet) { f.MaxArgs(0) },
+			args:     []string{"a"},
+			expected: "expected no arguments, got 1",
+		},
+		"in range": {
+			limit: func(f *FlagfigSet) { f.MinArgs(1); f.MaxArgs(3) },
+			args:  []string{"a", "b", "c"},
+		},
+	}
+	for caseName, c := range cases {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		out := &bytes.Buffer{}
+		f.SetOutput(out)
+		f.Bool("v", false, "", "verbose")
+		c.limit(f)
+		err := f.Parse(c.args)
+		if len(c.expected) == 0 {
+			if err != nil {
+				t.Errorf("case %s: unexpected error: %s", caseName, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != c.expected {
+			t.Errorf("case %s: expected %q, got %v", caseName, c.expected, err)
+		}
+		if !strings.HasPrefix(out.String(), c.expected+"\nUsage of test:\n") {
+			t.Errorf("case %s: expected the error and usage, got %q", caseName, out)
+		}
+	}
+}
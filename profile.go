@@ -0,0 +1,126 @@
+package flagfig
+
+import (
+	"sort"
+	"strings"
+)
+
+// profileDefaultSection is the section of a configuration file that every profile is built on top of
+const profileDefaultSection = "default"
+
+// SetProfile selects the configuration file profile for the CommandLine
+func SetProfile(name string) {
+	CommandLine.SetProfile(name)
+}
+
+// SetProfile turns on profiles and selects which section of each configuration file provides the flag values.
+// With profiles on, a configuration file may be split into sections:
+//
+//	{
+//		"default":    {"http-addr": ":8080", "log-level": "info"},
+//		"production": {"log-level": "warn"},
+//		"staging":    {"http-addr": ":9090"}
+//	}
+//
+// The "default" section is applied first, then the section for the profile is applied on top of it, so the
+// production profile above uses http-addr :8080 and log-level warn. A file that has neither a "default" section
+// nor a section for the profile, and has keys that are not sections, is read as a regular, flat file. Parse fails if
+// a sectioned file has no section for the profile, as when its name is misspelled, or has keys outside the sections.
+// If the profile flag was added with AddProfileFlag and is set on the command line or the environment, it takes
+// precedence over the name given here.
+func (f *FlagfigSet) SetProfile(name string) {
+	f.profilesOn = true
+	f.profile = name
+}
+
+// AddProfileFlag adds a profile flag to the CommandLine
+func AddProfileFlag(name, envName, usage string) *string {
+	return CommandLine.AddProfileFlag(name, envName, usage)
+}
+
+// AddProfileFlag turns on profiles (see SetProfile) and adds a flag, such as -profile, that selects the profile.
+// As the profile decides how the configuration files are read, it can only be set on the command line or by the
+// environment variable envName (which may be blank), never by a configuration file.
+func (f *FlagfigSet) AddProfileFlag(name, envName, usage string) *string {
+	p := new(string)
+	f.profilesOn = true
+	f.profileFlagName = name
+	f.profileEnvName = envName
+	f.internalFlags[name] = true
+	f.order = append(f.order, name)
+	f.FlagSet.StringVar(p, name, "", usage)
+	return p
+}
+
+// selectedProfile is the profile chosen by the profile flag or the environment, falling back to SetProfile
+func (f *FlagfigSet) selectedProfile() string {
+	if len(f.profileFlagName) == 0 {
+		return f.profile
+	}
+	fl := f.FlagSet.Lookup(f.profileFlagName)
+	if f.IsSet(f.profileFlagName) {
+		return fl.Value.String()
+	}
+	if len(f.profileEnvName) != 0 {
+		if envVal, ok := f.lookupEnv(f.profileEnvName); ok && len(envVal) != 0 {
+			_ = fl.Value.Set(envVal)
+			f.origins[f.profileFlagName] = origin{source: SourceEnv, detail: f.profileEnvName}
+			return envVal
+		}
+	}
+	if len(f.profile) != 0 {
+		_ = fl.Value.Set(f.profile)
+	}
+	return f.profile
+}
+
+// applyProfile flattens a sectioned configuration document into the values for the selected profile, and records
+// which section each key came from in keys, as in production.port. Documents without sections, or read while profiles
+// are off, are returned unchanged. A sectioned document must have a section for the selected profile, and nothing but
+// sections, as the rest would be ignored
+func (f *FlagfigSet) applyProfile(layer configLayer, doc map[string]interface{}, keys map[string]string) (map[string]interface{}, error) {
+	if !f.profilesOn {
+		return doc, nil
+	}
+	profile := f.selectedProfile()
+	defaults, hasDefaults := doc[profileDefaultSection].(map[string]interface{})
+	section, hasSection := doc[profile].(map[string]interface{})
+	if len(profile) == 0 {
+		hasSection = false
+	}
+	stray := make([]string, 0)
+	for key, v := range doc {
+		if !f.isSection(key, v) {
+			stray = append(stray, key)
+		}
+	}
+	// Without a default section, or one for the profile, only a file of nothing but sections is split into profiles
+	if !hasDefaults && !hasSection && (len(profile) == 0 || len(stray) != 0 || len(doc) == 0) {
+		return doc, nil
+	}
+	if len(stray) != 0 {
+		sort.Strings(stray)
+		return nil, errorOfKind(ErrUnknownKey, "keys outside the profile sections of configuration file %s: %s",
+			layer.describe(), strings.Join(stray, ", "))
+	}
+	if len(profile) != 0 && !hasSection {
+		return nil, errorOfKind(ErrUnknownProfile, "configuration file %s has no section for profile %q",
+			layer.describe(), profile)
+	}
+	flat := make(map[string]interface{})
+	for k, v := range defaults {
+		flat[k] = v
+		keys[k] = profileDefaultSection + "." + k
+	}
+	for k, v := range section {
+		flat[k] = v
+		keys[k] = profile + "." + k
+	}
+	return flat, nil
+}
+
+// isSection is true if the key of a configuration document can be a profile section: an object that is not a flag
+func (f *FlagfigSet) isSection(key string, v interface{}) bool {
+	_, ok := v.(map[string]interface{})
+	return ok && f.FlagSet.Lookup(key) == nil
+}
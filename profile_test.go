@@ -0,0 +1,127 @@
+package flagfig
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	tmpFileName, tfremove := testTempFile(t)
+	defer tfremove()
+	content := `{
+		"default":    {"http-addr": ":8080", "log-level": "info"},
+		"production": {"log-level": "warn"},
+		"staging":    {"http-addr": ":9090"}
+	}`
+	if err := ioutil.WriteFile(tmpFileName, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Setenv("ENV_PROFILE", "staging")
+	defer func() { _ = os.Unsetenv("ENV_PROFILE") }()
+
+	cases := map[string]struct {
+		args     []string
+		envName  string
+		profile  string
+		httpAddr string
+		logLevel string
+	}{
+		"from flag": {
+			args:     []string{"-profile=production"},
+			httpAddr: ":8080",
+			logLevel: "warn",
+		},
+		"from env": {
+			envName:  "ENV_PROFILE",
+			httpAddr: ":9090",
+			logLevel: "info",
+		},
+		"from SetProfile": {
+			profile:  "production",
+			httpAddr: ":8080",
+			logLevel: "warn",
+		},
+		"default section only": {
+			httpAddr: ":8080",
+			logLevel: "info",
+		},
+	}
+	for caseName, c := range cases {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.AddConfigFile("config", "config file")
+		profile := f.AddProfileFlag("profile", c.envName, "profile")
+		if len(c.profile) != 0 {
+			f.SetProfile(c.profile)
+		}
+		httpAddr := f.String("http-addr", "", "", "http address")
+		logLevel := f.String("log-level", "", "", "log level")
+		if err := f.Parse(append(c.args, "-config", tmpFileName)); err != nil {
+			t.Fatal(err)
+		}
+		if *httpAddr != c.httpAddr || *logLevel != c.logLevel {
+			t.Errorf("case %s: expected %s %s, got %s %s (profile %q)", caseName, c.httpAddr, c.logLevel, *httpAddr, *logLevel, *profile)
+		}
+	}
+}
+
+func TestProfilesRejected(t *testing.T) {
+	cases := map[string]struct {
+		content  string
+		profile  string
+		expected error
+	}{
+		"misspelled profile": {
+			content:  `{"default": {"log-level": "info"}, "production": {"log-level": "warn"}}`,
+			profile:  "prodution",
+			expected: ErrUnknownProfile,
+		},
+		"misspelled profile without a default section": {
+			content:  `{"production": {"log-level": "warn"}, "staging": {"log-level": "debug"}}`,
+			profile:  "prodution",
+			expected: ErrUnknownProfile,
+		},
+		"key outside the sections": {
+			content:  `{"default": {"log-level": "info"}, "production": {"log-level": "warn"}, "log-level": "debug"}`,
+			profile:  "production",
+			expected: ErrUnknownKey,
+		},
+	}
+	for caseName, c := range cases {
+		tmpFileName, tfremove := testTempFile(t)
+		if err := ioutil.WriteFile(tmpFileName, []byte(c.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.SetOutput(ioutil.Discard)
+		f.AddConfigFile("config", "config file")
+		f.AddProfileFlag("profile", "", "profile")
+		f.String("log-level", "", "", "log level")
+		err := f.Parse([]string{"-profile", c.profile, "-config", tmpFileName})
+		if !errors.Is(err, c.expected) {
+			t.Errorf("case %s: expected %v, got %v", caseName, c.expected, err)
+		}
+		tfremove()
+	}
+}
+
+func TestProfilesFlatFile(t *testing.T) {
+	tmpFileName, tfremove := testTempFile(t)
+	defer tfremove()
+	if err := ioutil.WriteFile(tmpFileName, []byte(`{"log-level": "warn", "labels": {"team": "a"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.AddConfigFile("config", "config file")
+	f.SetProfile("production")
+	logLevel := f.String("log-level", "", "", "log level")
+	f.StringMap("labels", nil, "", "labels")
+	if err := f.Parse([]string{"-config", tmpFileName}); err != nil {
+		t.Fatal(err)
+	}
+	if *logLevel != "warn" {
+		t.Error("a file without sections should be read as it is, got ", *logLevel)
+	}
+}
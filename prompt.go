@@ -0,0 +1,95 @@
+package flagfig
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// errNoHiddenInput is returned where input cannot be read without echoing it
+var errNoHiddenInput = errors.New("hidden input is not supported on this platform")
+
+// terminalCheck reports whether prompts may be shown, it is replaced in tests
+var terminalCheck = isTerminal
+
+func PromptMissing() {
+	CommandLine.PromptMissing()
+}
+
+// PromptMissing asks for the values of required flags that no source set, instead of failing, when standard input is
+// a terminal. The prompt shows the flag's usage, and Sensitive flags are read without echoing what is typed. That is
+// supported on Linux, macOS, the BSDs and Windows. Elsewhere, or if the terminal will not stop echoing, a missing
+// Sensitive flag fails Parse with an error saying it cannot be prompted for. An empty answer leaves the flag missing,
+// and an invalid one is asked again. Values given this way count as set on the command line.
+//
+// When standard input is not a terminal, such as in scripts and services, missing flags fail Parse as usual. Only
+// Parse prompts: Reload and Watch, which run while no one may be at the terminal, report missing flags as errors
+func (f *FlagfigSet) PromptMissing() {
+	f.promptMissing = true
+}
+
+// promptRequired asks for each missing required flag
+func (f *FlagfigSet) promptRequired() error {
+	in := f.promptIn
+	if in == nil {
+		in = os.Stdin
+	}
+	if !terminalCheck(in) {
+		return nil
+	}
+	reader := bufio.NewReader(in)
+	for _, name := range f.order {
+		if !f.required[name] || f.IsSet(name) {
+			continue
+		}
+		fl := f.FlagSet.Lookup(name)
+		for {
+			_, _ = fmt.Fprintf(f.Output(), "-%s (%s): ", name, fl.Usage)
+			answer, err := f.readAnswer(in, reader, f.sensitive[name])
+			if err == errNoHiddenInput {
+				_, _ = fmt.Fprintln(f.Output())
+				return fmt.Errorf("-%s is sensitive, and cannot be prompted for: %v", name, err)
+			}
+			if err != nil && err != io.EOF {
+				return err
+			}
+			if len(answer) == 0 {
+				break
+			}
+			if setErr := fl.Value.Set(answer); setErr != nil {
+				_, setErr = f.redactValue(name, answer, setErr)
+				_, _ = fmt.Fprintf(f.Output(), "invalid value for -%s: %v\n", name, setErr)
+				if err == io.EOF {
+					break
+				}
+				continue
+			}
+			f.origins[name] = origin{source: SourceFlag, detail: "prompt"}
+			break
+		}
+	}
+	return nil
+}
+
+// readAnswer reads one line of input, without echoing it if hidden is true
+func (f *FlagfigSet) readAnswer(in io.Reader, reader *bufio.Reader, hidden bool) (answer string, err error) {
+	read := func() error {
+		answer, err = reader.ReadString('\n')
+		answer = strings.TrimRight(answer, "\r\n")
+		return err
+	}
+	file, isFile := in.(*os.File)
+	if !hidden || !isFile {
+		err = read()
+		return
+	}
+	if echoErr := withoutEcho(file, read); echoErr == errNoHiddenInput {
+		return "", echoErr
+	}
+	// The newline typed was not echoed either
+	_, _ = fmt.Fprintln(f.Output())
+	return
+}
@@ -0,0 +1,25 @@
+package flagfig
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// withoutEcho turns off the terminal's echo while read runs
+func withoutEcho(file *os.File, read func() error) error {
+	fd := file.Fd()
+	var state syscall.Termios
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCGETS, uintptr(unsafe.Pointer(&state))); errno != 0 {
+		return errNoHiddenInput
+	}
+	quiet := state
+	quiet.Lflag &^= syscall.ECHO
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCSETS, uintptr(unsafe.Pointer(&quiet))); errno != 0 {
+		return errNoHiddenInput
+	}
+	defer func() {
+		_, _, _ = syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCSETS, uintptr(unsafe.Pointer(&state)))
+	}()
+	return read()
+}
@@ -0,0 +1,11 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package flagfig
+
+import "os"
+
+// withoutEcho is not supported on this system
+func withoutEcho(file *os.File, read func() error) error {
+	return errNoHiddenInput
+}
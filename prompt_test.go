@@ -0,0 +1,103 @@
+package flagfig
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestPromptMissing(t *testing.T) {
+	cases := map[string]struct {
+		terminal       bool
+		input          string
+		expectedOutput string
+		expectedErr    string
+	}{
+		"answered": {
+			terminal:       true,
+			input:          "localhost\nabc\n8080\n",
+			expectedOutput: "-host (host to connect to): -port (port): invalid value for -port: parse error\n-port (port): ",
+		},
+		"empty answer": {
+			terminal:       true,
+			input:          "\n",
+			expectedOutput: "-host (host to connect to): -port (port): ",
+			expectedErr:    "missing required flags: -host, -port",
+		},
+		"not a terminal": {
+			input:       "localhost\n8080\n",
+			expectedErr: "missing required flags: -host, -port",
+		},
+	}
+	defer func() { terminalCheck = isTerminal }()
+	for caseName, c := range cases {
+		terminal := c.terminal
+		terminalCheck = func(interface{}) bool { return terminal }
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		out := &bytes.Buffer{}
+		f.SetOutput(out)
+		f.promptIn = strings.NewReader(c.input)
+		host := f.String("host", "", "", "host to connect to", Required())
+		port := f.Int("port", 0, "", "port", Required())
+		f.String("user", "admin", "", "user")
+		f.PromptMissing()
+		err := f.Parse([]string{})
+		if len(c.expectedErr) != 0 {
+			// Parse reports the error, and the usage, after the prompts
+			expected := c.expectedOutput + c.expectedErr + "\nUsage of test:"
+			if !strings.HasPrefix(out.String(), expected) {
+				t.Errorf("case %s: expected output starting %q, got %q", caseName, expected, out)
+			}
+		} else if out.String() != c.expectedOutput {
+			t.Errorf("case %s: expected output %q, got %q", caseName, c.expectedOutput, out)
+		}
+		if len(c.expectedErr) != 0 {
+			if err == nil || err.Error() != c.expectedErr {
+				t.Errorf("case %s: expected %q, got %v", caseName, c.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %s: unexpected error: %s", caseName, err)
+			continue
+		}
+		if *host != "localhost" || *port != 8080 {
+			t.Errorf("case %s: got %q and %d", caseName, *host, *port)
+		}
+		if source, _ := f.Origin("port"); source != SourceFlag {
+			t.Errorf("case %s: expected port from the command line, got %s", caseName, source)
+		}
+	}
+}
+
+func TestPromptMissingNotOnReload(t *testing.T) {
+	tmpFileName, tfremove := testTempFile(t)
+	defer tfremove()
+	if err := ioutil.WriteFile(tmpFileName, []byte(`{"host":"example.com"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { terminalCheck = isTerminal }()
+	terminalCheck = func(interface{}) bool { return true }
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	out := &bytes.Buffer{}
+	f.SetOutput(out)
+	f.promptIn = strings.NewReader("localhost\n")
+	f.AddConfigFile("config", "config file")
+	f.String("host", "", "", "host to connect to", Required())
+	f.PromptMissing()
+	if err := f.Parse([]string{"-config", tmpFileName}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(tmpFileName, []byte(`{}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	err := f.Reload()
+	if err == nil || err.Error() != "missing required flags: -host" {
+		t.Error("expected the missing flag to fail the reload, got ", err)
+	}
+	if out.Len() != 0 {
+		t.Error("Reload should not prompt, got ", out.String())
+	}
+}
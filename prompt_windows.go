@@ -0,0 +1,27 @@
+package flagfig
+
+import (
+	"os"
+	"syscall"
+)
+
+// enableEchoInput is the ENABLE_ECHO_INPUT console mode
+const enableEchoInput = 0x4
+
+var procSetConsoleMode = kernel32.NewProc("SetConsoleMode")
+
+// withoutEcho turns off the console's echo while read runs
+func withoutEcho(file *os.File, read func() error) error {
+	handle := syscall.Handle(file.Fd())
+	var mode uint32
+	if err := syscall.GetConsoleMode(handle, &mode); err != nil {
+		return errNoHiddenInput
+	}
+	if done, _, _ := procSetConsoleMode.Call(uintptr(handle), uintptr(mode&^enableEchoInput)); done == 0 {
+		return errNoHiddenInput
+	}
+	defer func() {
+		_, _, _ = procSetConsoleMode.Call(uintptr(handle), uintptr(mode))
+	}()
+	return read()
+}
@@ -0,0 +1,67 @@
+package flagfig
+
+import "flag"
+
+func Recollate() error {
+	return CommandLine.Recollate()
+}
+
+// Recollate resolves the flags defined since the last Parse, for libraries that define their flags after the program
+// has parsed its own. The new flags are read from the configuration files and the environment, as Collate does, while
+// the flags resolved before are left as they are. The command line is not read again, so late flags cannot be given
+// there. Validation runs again for every flag, and the OnParsed hooks added since the last Parse run too
+func (f *FlagfigSet) Recollate() (err error) {
+	fresh := make(map[string]*flag.Flag)
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		if _, ok := f.origins[fl.Name]; !ok {
+			fresh[fl.Name] = fl
+			f.origins[fl.Name] = origin{source: SourceDefault}
+		}
+	})
+	if len(fresh) == 0 {
+		return nil
+	}
+	p := f.newProblems()
+	err = f.readConfigurationFiles(fresh, p)
+	if err != nil {
+		return
+	}
+	err = f.readEnvironment(fresh, nil, p)
+	if err != nil {
+		return
+	}
+	if f.expandValues {
+		// The flags resolved before are expanded already, so they are taken as they are
+		expanded := make(map[string]string)
+		f.FlagSet.VisitAll(func(fl *flag.Flag) {
+			if _, ok := fresh[fl.Name]; !ok {
+				expanded[fl.Name] = fl.Value.String()
+			}
+		})
+		for name := range fresh {
+			switch f.flagTypes[name] {
+			case stringType, stringSliceType:
+				_, err = f.expandFlag(name, expanded, nil)
+				if err = p.add(err); err != nil {
+					return
+				}
+			}
+		}
+	}
+	err = p.add(f.validate())
+	if err == nil {
+		err = p.err()
+	}
+	if err != nil {
+		return
+	}
+	for _, hook := range f.parsedHooks[f.hooksRun:] {
+		err = hook(f)
+		if err != nil {
+			return
+		}
+	}
+	f.hooksRun = len(f.parsedHooks)
+	f.publish()
+	return
+}
@@ -0,0 +1,55 @@
+package flagfig
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestRecollate(t *testing.T) {
+	path, cleanup := testTempFile(t)
+	defer cleanup()
+	if err := ioutil.WriteFile(path, []byte(`{"host": "from-file", "cache-dir": "/var/cache"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Setenv("ENV_RECOLLATE_CACHE_SIZE", "8")
+	defer func() { _ = os.Unsetenv("ENV_RECOLLATE_CACHE_SIZE") }()
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.AddConfigLayer("base", path)
+	host := f.String("host", "localhost", "", "host")
+	if err := f.Parse([]string{"-host=from-flag"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A library defines its flags late
+	dir := f.String("cache-dir", "", "", "cache directory")
+	size := f.Int("cache-size", 16, "ENV_RECOLLATE_CACHE_SIZE", "cache size", Max(32))
+	hookRan := 0
+	f.OnParsed(func(*FlagfigSet) error {
+		hookRan++
+		return nil
+	})
+	if err := f.Recollate(); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if *host != "from-flag" || *dir != "/var/cache" || *size != 8 || hookRan != 1 {
+		t.Errorf("expected from-flag, /var/cache, 8 and one hook, got %s, %s, %d and %d", *host, *dir, *size, hookRan)
+	}
+	if source, _ := f.Origin("host"); source != SourceFlag {
+		t.Error("expected -host to keep its origin, got ", source)
+	}
+	if n, _ := f.Snapshot().GetInt("cache-size"); n != 8 {
+		t.Error("expected the snapshot to include the late flags, got ", n)
+	}
+	if err := f.Recollate(); err != nil || hookRan != 1 {
+		t.Errorf("expected nothing to do, got %v and %d hooks", err, hookRan)
+	}
+
+	f.Int("cache-ttl", 0, "ENV_RECOLLATE_CACHE_SIZE", "cache ttl", Min(10))
+	err := f.Recollate()
+	expected := "-cache-ttl is 8 from environment variable ENV_RECOLLATE_CACHE_SIZE, but must be at least 10"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}
@@ -0,0 +1,56 @@
+package flagfig
+
+import (
+	"flag"
+	"os"
+	"sync"
+)
+
+// registeredNesters are the Nesters added by RegisterNester, in the order they were added
+var (
+	registeredNesters []Nester
+	registryMu        sync.Mutex
+)
+
+// RegisterNester adds Nesters to the ones ParseRegistered parses, so that packages can contribute their configuration
+// from their init functions, and the program need not know about all of them:
+//
+//	package db
+//
+//	var Config = newConfig()
+//
+//	func init() {
+//		flagfig.RegisterNester(flagfig.Prefixed("db", Config))
+//	}
+//
+// The Nesters are parsed in the order they are registered, which follows the order packages are initialized, so use
+// NesterDependent when one needs another to finish first
+func RegisterNester(nesters ...Nester) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registeredNesters = append(registeredNesters, nesters...)
+}
+
+// RegisteredNesters lists the Nesters added by RegisterNester
+func RegisteredNesters() []Nester {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return append([]Nester(nil), registeredNesters...)
+}
+
+// ParseRegistered parses args into every Nester added by RegisterNester, as ParseNested does, exiting on a parse
+// error as the CommandLine does:
+//
+//	func main() {
+//		if err := flagfig.ParseRegistered(os.Args[1:]); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+func ParseRegistered(args []string) (err error) {
+	return ParseRegisteredWithOptions(NestedOptions{Name: os.Args[0], ErrorHandling: flag.ExitOnError}, args)
+}
+
+// ParseRegisteredWithOptions is ParseRegistered, with the set up as ParseNestedWithOptions does
+func ParseRegisteredWithOptions(opts NestedOptions, args []string) (err error) {
+	return ParseNestedWithOptions(opts, RegisteredNesters(), args)
+}
@@ -0,0 +1,31 @@
+package flagfig
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestParseRegistered(t *testing.T) {
+	defer func(saved []Nester) { registeredNesters = saved }(registeredNesters)
+	registeredNesters = nil
+	primary, replica := newMyConfig(), newMyConfig()
+	RegisterNester(Prefixed("primary", primary))
+	RegisterNester(Prefixed("replica", replica))
+	if len(RegisteredNesters()) != 2 {
+		t.Fatal("expected 2 registered Nesters, got ", len(RegisteredNesters()))
+	}
+	err := ParseRegistered([]string{"-primary.mySecretNumber=3", "-replica.mySecretNumber=4"})
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if primary.MySecretSquare != 9 || replica.MySecretSquare != 16 {
+		t.Errorf("expected 9 and 16, got %d and %d", primary.MySecretSquare, replica.MySecretSquare)
+	}
+
+	registeredNesters = nil
+	RegisterNester(&failingConfig{message: "broken"})
+	err = ParseRegisteredWithOptions(NestedOptions{ErrorHandling: flag.ContinueOnError}, []string{})
+	if err == nil || err.Error() != "broken" {
+		t.Error("expected the AfterParsed failure, got ", err)
+	}
+}
@@ -0,0 +1,48 @@
+package flagfig
+
+import (
+	"flag"
+	"fmt"
+	"runtime/debug"
+)
+
+func SafeParse(arguments []string) error {
+	return CommandLine.SafeParse(arguments)
+}
+
+// SafeParse is Parse for programs that flagfig must never stop, such as plugin hosts. Whatever the error handling of
+// the set, problems are returned rather than exiting or panicking, as with flag.ContinueOnError, or CollectErrors if
+// the set was made with it. A panic anywhere in Parse, including in OnParsed hooks, decode hooks and Nesters, is
+// recovered and returned as a *PanicError.
+//
+// Programming errors, such as defining a flag twice, still panic where the flag is defined, which is before SafeParse
+// runs. ParseNested returns those as a DuplicateFlagError instead, for the flags defined by Nesters
+func (f *FlagfigSet) SafeParse(arguments []string) (err error) {
+	handling := f.FlagSet.ErrorHandling()
+	f.FlagSet.Init(f.Name(), flag.ContinueOnError)
+	defer func() {
+		f.FlagSet.Init(f.Name(), handling)
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return f.Parse(arguments)
+}
+
+// PanicError is a panic that SafeParse recovered from
+type PanicError struct {
+	// Value is what was passed to panic
+	Value interface{}
+	// Stack is the stack trace of the goroutine when it panicked
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic while parsing: %v", e.Value)
+}
+
+// Unwrap is the panic's value, if it was an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
@@ -0,0 +1,64 @@
+package flagfig
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestSafeParse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "safe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	cases := map[string]struct {
+		handling flag.ErrorHandling
+		args     []string
+		setup    func(f *FlagfigSet)
+		panics   bool
+	}{
+		"exit on error": {
+			handling: flag.ExitOnError,
+			args:     []string{"-port=eighty"},
+		},
+		"panic on error": {
+			handling: flag.PanicOnError,
+			args:     []string{"-nope"},
+		},
+		"panicking hook": {
+			handling: flag.ContinueOnError,
+			setup: func(f *FlagfigSet) {
+				f.OnParsed(func(*FlagfigSet) error {
+					panic("hook failed")
+				})
+			},
+			panics: true,
+		},
+		"unreadable configuration file": {
+			handling: flag.PanicOnError,
+			setup: func(f *FlagfigSet) {
+				// A directory cannot be read as a file
+				f.AddConfigLayer("base", dir)
+			},
+		},
+	}
+	for caseName, c := range cases {
+		f := NewFlagfigSet("test", c.handling)
+		f.SetOutput(ioutil.Discard)
+		f.Int("port", 8080, "", "port")
+		if c.setup != nil {
+			c.setup(f)
+		}
+		err := f.SafeParse(c.args)
+		var panicErr *PanicError
+		if err == nil || errors.As(err, &panicErr) != c.panics {
+			t.Errorf("case %s: expected an error, panicking %t, got %v", caseName, c.panics, err)
+		}
+		if f.ErrorHandling() != c.handling {
+			t.Errorf("case %s: expected the error handling to be put back, got %v", caseName, f.ErrorHandling())
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package flagfig
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+func WriteSampleConfig(w io.Writer, format ConfigFormat) error {
+	return CommandLine.WriteSampleConfig(w, format)
+}
+
+// WriteSampleConfig writes a configuration file that sets every flag to its default value, with the flag's usage as a
+// comment above it. Edit the result to taste; it is read back exactly like any other configuration file:
+//
+//	if *printSample {
+//		_ = flags.WriteSampleConfig(os.Stdout, flagfig.ConfigYAML)
+//		os.Exit(0)
+//	}
+//
+// Flags that configuration files may not set, Hidden flags and deprecated flags are left out. Sensitive strings are
+// left blank
+func (f *FlagfigSet) WriteSampleConfig(w io.Writer, format ConfigFormat) (err error) {
+	type entry struct {
+		key, value string
+		comments   []string
+	}
+	entries := make([]entry, 0)
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		if err != nil || f.internalFlags[fl.Name] || f.hidden[fl.Name] || !f.sourceAllowed(fl.Name, SourceFile) {
+			return
+		}
+		if _, ok := f.deprecations[fl.Name]; ok {
+			return
+		}
+		var raw []byte
+		raw, err = json.Marshal(f.sampleValue(fl))
+		if err != nil {
+			err = fmt.Errorf("flag -%s: %v", fl.Name, err)
+			return
+		}
+		_, usage := f.unquoteUsage(fl)
+		comments := strings.Split(usage, "\n")
+		if f.required[fl.Name] {
+			comments = append(comments, "(required)")
+		}
+		entries = append(entries, entry{key: fl.Name, value: string(raw), comments: comments})
+	})
+	if err != nil {
+		return
+	}
+	sb := strings.Builder{}
+	switch format {
+	case ConfigYAML:
+		for _, e := range entries {
+			for _, comment := range e.comments {
+				sb.WriteString(strings.TrimRight("# "+comment, " ") + "\n")
+			}
+			key := e.key
+			if !yamlPlainKey.MatchString(key) {
+				quoted, _ := json.Marshal(key)
+				key = string(quoted)
+			}
+			sb.WriteString(key + ": " + e.value + "\n")
+		}
+	default:
+		sb.WriteString("{\n")
+		for i, e := range entries {
+			for _, comment := range e.comments {
+				sb.WriteString(strings.TrimRight("  // "+comment, " ") + "\n")
+			}
+			key, _ := json.Marshal(e.key)
+			sb.WriteString("  " + string(key) + ": " + e.value)
+			if i != len(entries)-1 {
+				sb.WriteString(",")
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("}\n")
+	}
+	_, err = fmt.Fprint(w, sb.String())
+	return
+}
+
+// yamlPlainKey matches the keys that do not need quotes in YAML
+var yamlPlainKey = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// sampleValue is the flag's default, as it would be written in a configuration file
+func (f *FlagfigSet) sampleValue(fl *flag.Flag) interface{} {
+	switch v := fl.Value.(type) {
+	case *stringSliceValue:
+		if v.defaults == nil {
+			return []string{}
+		}
+		return v.defaults
+	case *stringMapValue:
+		return v.defaults
+	case *jsonValue:
+		if json.Valid([]byte(fl.DefValue)) {
+			return json.RawMessage(fl.DefValue)
+		}
+		return nil
+	}
+	switch f.flagTypes[fl.Name] {
+	case boolType:
+		return fl.DefValue == "true"
+	case intType, int64Type, uintType, uint64Type, floatType:
+		return json.Number(fl.DefValue)
+	case stringType:
+		if f.sensitive[fl.Name] {
+			return ""
+		}
+	}
+	// Durations are written as text, such as "1m30s", which reads back just as well
+	return fl.DefValue
+}
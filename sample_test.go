@@ -0,0 +1,123 @@
+package flagfig
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newSampleTestSet() *FlagfigSet {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.AddConfigFile("config", "configuration file")
+	f.String("host", "localhost", "", "host to connect to\nor listen on")
+	f.Int("port", 8080, "", "port", Required())
+	f.Duration("timeout", 90*time.Second, "", "timeout")
+	f.Bool("debug", false, "", "debug")
+	f.StringSlice("peers", []string{"a", "b"}, "", "peers")
+	f.StringMap("labels", map[string]string{"zone": "eu"}, "", "labels")
+	f.String("password", "hunter2", "", "password", Sensitive())
+	f.String("secret", "", "", "from the environment only", FromSources(SourceEnv))
+	f.String("trace-id", "", "", "for debugging", Hidden())
+	f.String("old-host", "", "", "old host")
+	f.Deprecate("old-host", "", "host")
+	return f
+}
+
+func TestWriteSampleConfig(t *testing.T) {
+	cases := map[string]struct {
+		format   ConfigFormat
+		expected string
+	}{
+		"json": {
+			format: ConfigJSON,
+			expected: `{
+  // debug
+  "debug": false,
+  // host to connect to
+  // or listen on
+  "host": "localhost",
+  // labels
+  "labels": {"zone":"eu"},
+  // password
+  "password": "",
+  // peers
+  "peers": ["a","b"],
+  // port
+  // (required)
+  "port": 8080,
+  // timeout
+  "timeout": "1m30s"
+}
+`,
+		},
+		"yaml": {
+			format: ConfigYAML,
+			expected: `# debug
+debug: false
+# host to connect to
+# or listen on
+host: "localhost"
+# labels
+labels: {"zone":"eu"}
+# password
+password: ""
+# peers
+peers: ["a","b"]
+# port
+# (required)
+port: 8080
+# timeout
+timeout: "1m30s"
+`,
+		},
+	}
+	for caseName, c := range cases {
+		out := &bytes.Buffer{}
+		err := newSampleTestSet().WriteSampleConfig(out, c.format)
+		if err != nil {
+			t.Fatalf("case %s: unexpected error: %s", caseName, err)
+		}
+		if out.String() != c.expected {
+			t.Errorf("case %s: expected:\n%s\ngot:\n%s", caseName, c.expected, out.String())
+		}
+	}
+}
+
+func TestWriteSampleConfigReadsBack(t *testing.T) {
+	for _, ext := range []string{".json", ".yml"} {
+		name, cleanup := testTempFile(t)
+		defer cleanup()
+		path := name + ext
+		defer func() { _ = os.Remove(path) }()
+		out := &bytes.Buffer{}
+		format := configFormatFor(path)
+		if err := newSampleTestSet().WriteSampleConfig(out, format); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, out.Bytes(), 0600); err != nil {
+			t.Fatal(err)
+		}
+		f := newSampleTestSet()
+		if err := f.Parse([]string{"-config=" + path}); err != nil {
+			t.Fatalf("%s: unexpected error: %s", ext, err)
+		}
+		for name, expected := range map[string]interface{}{
+			"host":    "localhost",
+			"port":    8080,
+			"timeout": 90 * time.Second,
+			"peers":   []string{"a", "b"},
+			"labels":  map[string]string{"zone": "eu"},
+		} {
+			if source, _ := f.Origin(name); source != SourceFile {
+				t.Errorf("%s: expected -%s to be set by the file, got %s", ext, name, source)
+			}
+			if v := f.Lookup(name).Value.(flag.Getter).Get(); !reflect.DeepEqual(v, expected) {
+				t.Errorf("%s: expected -%s to be %v, got %v", ext, name, expected, v)
+			}
+		}
+	}
+}
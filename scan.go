@@ -0,0 +1,336 @@
+package flagfig
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// scanArgs rewrites the command line into the form the embedded flag.FlagSet parses: each flag as -name=value, using
+// the flag's real name rather than an alias, followed by "--" and the positional arguments. It scans the way the flag
+// package does, stopping at the first positional argument or "--", unless SetInterspersed is on. Anything it does not
+// understand, such as an undefined flag or a flag missing its value, is left as is from there on, so that
+// flag.FlagSet reports it exactly as it always has, including -h and -help. With SetGNUStyle, problems are reported by
+// scanArgs instead, as the flag package would accept the wrong syntax
+func (f *FlagfigSet) scanArgs(arguments []string) (out []string, err error) {
+	out = make([]string, 0, len(arguments)+1)
+	f.unknownFlags = nil
+	f.passthrough = nil
+	var positionals []string
+	for i := 0; i < len(arguments); i++ {
+		arg := arguments[i]
+		if (len(arg) < 2 || arg[0] != '-') && f.interspersed {
+			positionals = append(positionals, arg)
+			continue
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			f.recordPassthrough(arguments[i:])
+			return f.scanPositionals(out, arguments[i:]), nil
+		}
+		if arg == "--" {
+			f.recordPassthrough(arguments[i:])
+			if len(positionals) != 0 {
+				return append(f.scanPositionals(out, positionals), arguments[i+1:]...), nil
+			}
+			return append(out, arguments[i:]...), nil
+		}
+		if f.gnuStyle {
+			expanded, used, err := f.scanGNU(arg, arguments[i+1:])
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, expanded...)
+			i += used
+			continue
+		}
+		name := strings.TrimPrefix(arg[1:], "-")
+		value, hasValue := "", false
+		if eq := strings.Index(name, "="); eq >= 0 {
+			name, value, hasValue = name[:eq], name[eq+1:], true
+		}
+		fl := f.FlagSet.Lookup(f.canonicalName(name))
+		if fl == nil && f.combinedShort && arg[1] != '-' && !hasValue {
+			if expanded, used, err := f.expandShort(name, arguments[i+1:]); err == nil {
+				out = append(out, expanded...)
+				i += used
+				continue
+			}
+		}
+		if fl == nil && f.ignoreUnknown && len(name) != 0 && name[0] != '-' && name[0] != '=' && name != "h" && name != "help" {
+			f.unknownFlags = append(f.unknownFlags, arg)
+			continue
+		}
+		if len(name) == 0 || name[0] == '-' || name[0] == '=' || fl == nil {
+			return append(out, arguments[i:]...), nil
+		}
+		if !hasValue && !isBoolFlag(fl) {
+			if i+1 == len(arguments) {
+				return append(out, arguments[i:]...), nil
+			}
+			i++
+			value, hasValue = arguments[i], true
+		}
+		out = append(out, flagArg(fl, value, hasValue))
+	}
+	if len(positionals) != 0 {
+		return f.scanPositionals(out, positionals), nil
+	}
+	return out, nil
+}
+
+// flagArg is the flag as the embedded flag.FlagSet parses it
+func flagArg(fl *flag.Flag, value string, hasValue bool) string {
+	if hasValue {
+		return "-" + fl.Name + "=" + value
+	}
+	return "-" + fl.Name
+}
+
+// canonicalName is the name of the flag that name is an alias of, or name itself
+func (f *FlagfigSet) canonicalName(name string) string {
+	if canonical, ok := f.aliases[name]; ok {
+		return canonical
+	}
+	return name
+}
+
+// addAlias makes alias another name for the flag on the command line. Like the flag package does for flags, it panics
+// if the name is already taken
+func (f *FlagfigSet) addAlias(name, alias string) {
+	if _, taken := f.aliases[alias]; taken || f.FlagSet.Lookup(alias) != nil {
+		panic(fmt.Sprintf("%s flag redefined: %s", f.Name(), alias))
+	}
+	f.aliases[alias] = name
+}
+
+// Short adds a single letter alias for the flag, such as -v for -verbose. The alias is only a command line name: the
+// flag is still set by its own environment variable and configuration file key
+func Short(letter string) FlagOption {
+	return func(f *FlagfigSet, name string) {
+		f.addAlias(name, letter)
+		f.shorts[name] = letter
+	}
+}
+
+func SetCombinedShortFlags(combined bool) {
+	CommandLine.SetCombinedShortFlags(combined)
+}
+
+// SetCombinedShortFlags lets single letter flags be grouped behind one dash, as POSIX tools allow: -xvf file is the
+// same as -x -v -f file. Every letter but the last must be a Bool flag. The last may take a value, either from the
+// rest of the argument, as in -ffile, or from the next argument. Single letter flags include Short aliases.
+// A group that is also the name of a flag, such as -xvf for a flag named xvf, is still that flag
+func (f *FlagfigSet) SetCombinedShortFlags(combined bool) {
+	f.combinedShort = combined
+}
+
+// expandShort expands a group of single letter flags, such as xvf, into separate flags. rest is the arguments after
+// the group, and used is how many of them were taken as a value
+func (f *FlagfigSet) expandShort(group string, rest []string) (expanded []string, used int, err error) {
+	for i, letter := range group {
+		fl := f.FlagSet.Lookup(f.canonicalName(string(letter)))
+		if fl == nil && letter == 'h' {
+			return nil, 0, flag.ErrHelp
+		}
+		if fl == nil {
+			return nil, 0, undefinedFlagError("-" + string(letter))
+		}
+		if isBoolFlag(fl) {
+			expanded = append(expanded, "-"+fl.Name)
+			continue
+		}
+		value := strings.TrimPrefix(group[i+len(string(letter)):], "=")
+		if i+len(string(letter)) == len(group) {
+			if len(rest) == 0 {
+				return nil, 0, fmt.Errorf("flag needs an argument: -%c", letter)
+			}
+			value, used = rest[0], 1
+		}
+		return append(expanded, "-"+fl.Name+"="+value), used, nil
+	}
+	return expanded, 0, nil
+}
+
+func SetKeyValueOverrides(overrides bool) {
+	CommandLine.SetKeyValueOverrides(overrides)
+}
+
+// SetKeyValueOverrides lets positional arguments written as name=value set flags, the way make takes variables:
+//
+//	myapp build port=9090 verbose=true
+//
+// is the same as myapp -port=9090 -verbose=true build. Only names of defined flags, or their aliases, are taken, any
+// other argument stays positional, as does everything after "--"
+func (f *FlagfigSet) SetKeyValueOverrides(overrides bool) {
+	f.keyValueOverrides = overrides
+}
+
+// scanPositionals appends the positional arguments to the scanned flags, after "--". With SetKeyValueOverrides, the
+// name=value arguments among them become flags
+func (f *FlagfigSet) scanPositionals(flags, positionals []string) []string {
+	if !f.keyValueOverrides {
+		return append(append(flags, "--"), positionals...)
+	}
+	rest := make([]string, 0, len(positionals))
+	for i, arg := range positionals {
+		if arg == "--" {
+			rest = append(rest, positionals[i:]...)
+			break
+		}
+		if eq := strings.Index(arg, "="); eq > 0 && arg[0] != '-' {
+			if fl := f.FlagSet.Lookup(f.canonicalName(arg[:eq])); fl != nil {
+				flags = append(flags, flagArg(fl, arg[eq+1:], true))
+				continue
+			}
+		}
+		rest = append(rest, arg)
+	}
+	return append(append(flags, "--"), rest...)
+}
+
+func SetInterspersed(interspersed bool) {
+	CommandLine.SetInterspersed(interspersed)
+}
+
+// SetInterspersed lets flags follow positional arguments, as GNU getopt does: myapp in.txt -v out.txt is the same as
+// myapp -v in.txt out.txt. Args returns the positional arguments in order. "--" still ends the flags, so arguments
+// that look like flags can be passed after it
+func (f *FlagfigSet) SetInterspersed(interspersed bool) {
+	f.interspersed = interspersed
+}
+
+func SetGNUStyle(gnu bool) {
+	CommandLine.SetGNUStyle(gnu)
+}
+
+// SetGNUStyle makes the command line work like GNU tools: long names take two dashes, as in --name value or
+// --name=value, and a single dash is for single letter flags and Short aliases, which may be grouped as
+// SetCombinedShortFlags describes, as in -xvf file. "--" ends the flags. Usage output, the shell completions,
+// GenMarkdown and GenManPage show the flags the same way
+func (f *FlagfigSet) SetGNUStyle(gnu bool) {
+	f.gnuStyle = gnu
+}
+
+// scanGNU rewrites one flag written GNU style. rest is the arguments after it, and used is how many of them were
+// taken as a value
+func (f *FlagfigSet) scanGNU(arg string, rest []string) (expanded []string, used int, err error) {
+	if !strings.HasPrefix(arg, "--") {
+		expanded, used, err = f.expandShort(arg[1:], rest)
+		if _, undefined := err.(undefinedFlagError); undefined && f.ignoreUnknown {
+			f.unknownFlags = append(f.unknownFlags, arg)
+			return nil, 0, nil
+		}
+		return
+	}
+	name := arg[2:]
+	value, hasValue := "", false
+	if eq := strings.Index(name, "="); eq >= 0 {
+		name, value, hasValue = name[:eq], name[eq+1:], true
+	}
+	fl := f.FlagSet.Lookup(f.canonicalName(name))
+	if fl == nil && (name == "help" || name == "h") {
+		return nil, 0, flag.ErrHelp
+	}
+	if fl == nil && f.ignoreUnknown {
+		f.unknownFlags = append(f.unknownFlags, arg)
+		return nil, 0, nil
+	}
+	if fl == nil {
+		return nil, 0, undefinedFlagError("--" + name)
+	}
+	if !hasValue && !isBoolFlag(fl) {
+		if len(rest) == 0 {
+			return nil, 0, fmt.Errorf("flag needs an argument: --%s", name)
+		}
+		value, hasValue, used = rest[0], true, 1
+	}
+	return []string{flagArg(fl, value, hasValue)}, used, nil
+}
+
+func PassthroughArgs() []string {
+	return CommandLine.PassthroughArgs()
+}
+
+// PassthroughArgs is everything after the first "--" following the flags, untouched, or nil if there was no "--". It
+// is meant for commands that run another program with its own arguments:
+//
+//	myproxy -port=8080 -- myserver -debug file
+//
+// gives myserver -debug file, whether or not those look like flags of myproxy. Args still includes them, but
+// Positional arguments and ExactArgs, MinArgs and MaxArgs only count the arguments before the "--"
+func (f *FlagfigSet) PassthroughArgs() []string {
+	return f.passthrough
+}
+
+// recordPassthrough keeps the arguments after "--" for PassthroughArgs, rest being the arguments after the flags
+func (f *FlagfigSet) recordPassthrough(rest []string) {
+	if after := ArgsAfterArgWithEqualTo("--", rest...); len(after) != len(rest) {
+		f.passthrough = append([]string{}, after...)
+	}
+}
+
+// ownArgs is the positional arguments before the PassthroughArgs
+func (f *FlagfigSet) ownArgs() []string {
+	args := f.Args()
+	if f.passthrough == nil {
+		return args
+	}
+	n := len(args) - len(f.passthrough)
+	if n > 0 && args[n-1] == "--" {
+		n--
+	}
+	return args[:n]
+}
+
+// undefinedFlagError is the error for a flag that is not defined, worded like the flag package's, with the dashes the
+// flag was written with
+type undefinedFlagError string
+
+func (e undefinedFlagError) Error() string {
+	return "flag provided but not defined: " + string(e)
+}
+
+func SetIgnoreUnknownFlags(ignore bool) {
+	CommandLine.SetIgnoreUnknownFlags(ignore)
+}
+
+// SetIgnoreUnknownFlags makes Parse skip flags that are not defined instead of failing, collecting them for
+// UnknownFlags. This lets a wrapper resolve its own flags and pass the rest on to the program it wraps. Since there is
+// no telling whether an unknown flag takes a value, only the argument itself is collected, so the wrapped program's
+// flags must be written as -name=value. -h and -help still print the usage
+func (f *FlagfigSet) SetIgnoreUnknownFlags(ignore bool) {
+	f.ignoreUnknown = ignore
+}
+
+func UnknownFlags() []string {
+	return CommandLine.UnknownFlags()
+}
+
+// UnknownFlags lists the flags Parse skipped because of SetIgnoreUnknownFlags, as they were written on the command line
+func (f *FlagfigSet) UnknownFlags() []string {
+	return f.unknownFlags
+}
+
+// failParse reports a problem found while scanning the command line the way flag.FlagSet reports its own: it prints
+// the error and the usage, then follows the error handling of the set
+func (f *FlagfigSet) failParse(err error) error {
+	if err != flag.ErrHelp {
+		_, _ = fmt.Fprintln(f.Output(), err)
+	}
+	if f.FlagSet.Usage == nil {
+		f.defaultUsage()
+	} else {
+		f.FlagSet.Usage()
+	}
+	switch f.ErrorHandling() {
+	case flag.ExitOnError:
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		os.Exit(2)
+	case flag.PanicOnError:
+		panic(err)
+	}
+	return err
+}
@@ -0,0 +1,382 @@
+package flagfig
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestShort(t *testing.T) {
+	_ = os.Setenv("ENV_SHORT_NAME", "from-env")
+	defer func() { _ = os.Unsetenv("ENV_SHORT_NAME") }()
+	cases := map[string]struct {
+		args         []string
+		expectedV    bool
+		expectedName string
+		expectedArgs []string
+	}{
+		"long names": {
+			args:         []string{"-verbose", "--name", "x", "file"},
+			expectedV:    true,
+			expectedName: "x",
+			expectedArgs: []string{"file"},
+		},
+		"short names": {
+			args:         []string{"-v", "-n=y", "--", "-file"},
+			expectedV:    true,
+			expectedName: "y",
+			expectedArgs: []string{"-file"},
+		},
+		"short value as next argument": {
+			args:         []string{"-n", "-v"},
+			expectedName: "-v",
+			expectedArgs: []string{},
+		},
+		"environment": {
+			args:         []string{"-v=false"},
+			expectedName: "from-env",
+			expectedArgs: []string{},
+		},
+	}
+	for caseName, c := range cases {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		verbose := f.Bool("verbose", false, "", "verbose", Short("v"))
+		name := f.String("name", "", "ENV_SHORT_NAME", "name", Short("n"))
+		err := f.Parse(c.args)
+		if err != nil {
+			t.Errorf("case %s: unexpected error: %s", caseName, err)
+			continue
+		}
+		if *verbose != c.expectedV || *name != c.expectedName {
+			t.Errorf("case %s: expected %v and %q, got %v and %q", caseName, c.expectedV, c.expectedName, *verbose, *name)
+		}
+		if !reflect.DeepEqual(f.Args(), c.expectedArgs) {
+			t.Errorf("case %s: expected arguments %v, got %v", caseName, c.expectedArgs, f.Args())
+		}
+	}
+}
+
+func TestShortErrorsAndUsage(t *testing.T) {
+	out := &bytes.Buffer{}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetOutput(out)
+	f.Bool("verbose", false, "", "verbose", Short("v"))
+	if err := f.Parse([]string{"-v", "-x"}); err == nil || err.Error() != "flag provided but not defined: -x" {
+		t.Error("expected the flag package's error for an undefined flag, got ", err)
+	}
+	expected := "flag provided but not defined: -x\nUsage of test:\n  -v, -verbose\n    \tverbose\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a short name that is already taken")
+		}
+	}()
+	f.Bool("values", false, "", "values", Short("v"))
+}
+
+func TestSetCombinedShortFlags(t *testing.T) {
+	cases := map[string]struct {
+		args         []string
+		expectedFile string
+		expectedArgs []string
+		expectedErr  bool
+	}{
+		"grouped with value as next argument": {
+			args:         []string{"-xvf", "out.tar", "dir"},
+			expectedFile: "out.tar",
+			expectedArgs: []string{"dir"},
+		},
+		"grouped with attached value": {
+			args:         []string{"-xvfout.tar"},
+			expectedFile: "out.tar",
+			expectedArgs: []string{},
+		},
+		"flag named like a group": {
+			args:         []string{"-xv"},
+			expectedArgs: []string{},
+		},
+		"unknown letter": {
+			args:        []string{"-xq"},
+			expectedErr: true,
+		},
+	}
+	for caseName, c := range cases {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.SetOutput(&bytes.Buffer{})
+		f.SetCombinedShortFlags(true)
+		extract := f.Bool("x", false, "", "extract")
+		f.Bool("verbose", false, "", "verbose", Short("v"))
+		file := f.String("file", "", "", "file", Short("f"))
+		xv := f.Bool("xv", false, "", "a flag with a name made of short flags")
+		err := f.Parse(c.args)
+		if c.expectedErr {
+			if err == nil {
+				t.Errorf("case %s: expected an error", caseName)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %s: unexpected error: %s", caseName, err)
+			continue
+		}
+		if *file != c.expectedFile || *extract == *xv {
+			t.Errorf("case %s: unexpected values -file=%q -x=%v -xv=%v", caseName, *file, *extract, *xv)
+		}
+		if !reflect.DeepEqual(f.Args(), c.expectedArgs) {
+			t.Errorf("case %s: expected arguments %v, got %v", caseName, c.expectedArgs, f.Args())
+		}
+	}
+}
+
+func TestSetGNUStyle(t *testing.T) {
+	cases := map[string]struct {
+		args         []string
+		expectedName string
+		expectedArgs []string
+		expectedErr  string
+	}{
+		"long with separate value": {
+			args:         []string{"--name", "x", "--verbose", "file"},
+			expectedName: "x",
+			expectedArgs: []string{"file"},
+		},
+		"long with equals and grouped short": {
+			args:         []string{"--name=y", "-vn", "z", "--", "--name"},
+			expectedName: "z",
+			expectedArgs: []string{"--name"},
+		},
+		"single dash is for short flags": {
+			args:        []string{"-verbose"},
+			expectedErr: "flag provided but not defined: -e",
+		},
+		"unknown long flag": {
+			args:        []string{"--nope"},
+			expectedErr: "flag provided but not defined: --nope",
+		},
+		"missing value": {
+			args:        []string{"--name"},
+			expectedErr: "flag needs an argument: --name",
+		},
+		"help": {
+			args:        []string{"--help"},
+			expectedErr: flag.ErrHelp.Error(),
+		},
+	}
+	for caseName, c := range cases {
+		out := &bytes.Buffer{}
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.SetOutput(out)
+		f.SetGNUStyle(true)
+		f.Bool("verbose", false, "", "verbose", Short("v"))
+		name := f.String("name", "", "", "name", Short("n"))
+		err := f.Parse(c.args)
+		if len(c.expectedErr) != 0 {
+			if err == nil || err.Error() != c.expectedErr {
+				t.Errorf("case %s: expected %q, got %v", caseName, c.expectedErr, err)
+			}
+			if !bytes.Contains(out.Bytes(), []byte("  -n, --name string\n")) {
+				t.Errorf("case %s: expected the usage, got %q", caseName, out.String())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %s: unexpected error: %s", caseName, err)
+			continue
+		}
+		if *name != c.expectedName || !reflect.DeepEqual(f.Args(), c.expectedArgs) {
+			t.Errorf("case %s: unexpected -name=%q and arguments %v", caseName, *name, f.Args())
+		}
+	}
+}
+
+func TestSetKeyValueOverrides(t *testing.T) {
+	cases := map[string]struct {
+		overrides    bool
+		args         []string
+		expectedPort int
+		expectedArgs []string
+	}{
+		"off": {
+			args:         []string{"build", "port=9090"},
+			expectedPort: 8080,
+			expectedArgs: []string{"build", "port=9090"},
+		},
+		"after a positional": {
+			overrides:    true,
+			args:         []string{"build", "port=9090", "dir=out"},
+			expectedPort: 9090,
+			expectedArgs: []string{"build", "dir=out"},
+		},
+		"alias": {
+			overrides:    true,
+			args:         []string{"p=1", "build"},
+			expectedPort: 1,
+			expectedArgs: []string{"build"},
+		},
+		"after dashes": {
+			overrides:    true,
+			args:         []string{"-port=2", "--", "port=3"},
+			expectedPort: 2,
+			expectedArgs: []string{"port=3"},
+		},
+	}
+	for caseName, c := range cases {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		port := f.Int("port", 8080, "", "port", Short("p"))
+		f.SetKeyValueOverrides(c.overrides)
+		if err := f.Parse(c.args); err != nil {
+			t.Errorf("case %s: unexpected error: %s", caseName, err)
+			continue
+		}
+		if *port != c.expectedPort {
+			t.Errorf("case %s: expected port %d, got %d", caseName, c.expectedPort, *port)
+		}
+		if !reflect.DeepEqual(f.Args(), c.expectedArgs) {
+			t.Errorf("case %s: expected arguments %v, got %v", caseName, c.expectedArgs, f.Args())
+		}
+	}
+}
+
+func TestSetIgnoreUnknownFlags(t *testing.T) {
+	cases := map[string]struct {
+		gnu             bool
+		args            []string
+		expectedUnknown []string
+		expectedArgs    []string
+	}{
+		"single dash": {
+			args:            []string{"-x", "-port=1", "-timeout=5s", "file"},
+			expectedUnknown: []string{"-x", "-timeout=5s"},
+			expectedArgs:    []string{"file"},
+		},
+		"gnu": {
+			gnu:             true,
+			args:            []string{"--port", "1", "--timeout=5s", "-zq", "file"},
+			expectedUnknown: []string{"--timeout=5s", "-zq"},
+			expectedArgs:    []string{"file"},
+		},
+		"none": {
+			args:         []string{"-port=1"},
+			expectedArgs: []string{},
+		},
+	}
+	for caseName, c := range cases {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		port := f.Int("port", 8080, "", "port")
+		f.SetGNUStyle(c.gnu)
+		f.SetIgnoreUnknownFlags(true)
+		if err := f.Parse(c.args); err != nil {
+			t.Errorf("case %s: unexpected error: %s", caseName, err)
+			continue
+		}
+		if *port != 1 {
+			t.Errorf("case %s: expected port 1, got %d", caseName, *port)
+		}
+		if !reflect.DeepEqual(f.UnknownFlags(), c.expectedUnknown) {
+			t.Errorf("case %s: expected unknown flags %q, got %q", caseName, c.expectedUnknown, f.UnknownFlags())
+		}
+		if !reflect.DeepEqual(f.Args(), c.expectedArgs) {
+			t.Errorf("case %s: expected arguments %v, got %v", caseName, c.expectedArgs, f.Args())
+		}
+	}
+
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetOutput(&bytes.Buffer{})
+	f.SetIgnoreUnknownFlags(true)
+	if err := f.Parse([]string{"-x", "-help"}); err != flag.ErrHelp {
+		t.Error("expected -help to still print the usage, got ", err)
+	}
+}
+
+func TestPassthroughArgs(t *testing.T) {
+	cases := map[string]struct {
+		args                []string
+		expectedPassthrough []string
+		expectedArgs        []string
+	}{
+		"after the flags": {
+			args:                []string{"-port=1", "--", "server", "-port=2"},
+			expectedPassthrough: []string{"server", "-port=2"},
+			expectedArgs:        []string{"server", "-port=2"},
+		},
+		"after a positional": {
+			args:                []string{"file", "--", "-x"},
+			expectedPassthrough: []string{"-x"},
+			expectedArgs:        []string{"file", "--", "-x"},
+		},
+		"nothing after": {
+			args:                []string{"--"},
+			expectedPassthrough: []string{},
+			expectedArgs:        []string{},
+		},
+		"no dashes": {
+			args:         []string{"file"},
+			expectedArgs: []string{"file"},
+		},
+	}
+	for caseName, c := range cases {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.Int("port", 8080, "", "port")
+		f.MaxArgs(1)
+		if err := f.Parse(c.args); err != nil {
+			t.Errorf("case %s: unexpected error: %s", caseName, err)
+			continue
+		}
+		if !reflect.DeepEqual(f.PassthroughArgs(), c.expectedPassthrough) {
+			t.Errorf("case %s: expected passthrough %q, got %q", caseName, c.expectedPassthrough, f.PassthroughArgs())
+		}
+		if !reflect.DeepEqual(f.Args(), c.expectedArgs) {
+			t.Errorf("case %s: expected arguments %q, got %q", caseName, c.expectedArgs, f.Args())
+		}
+	}
+}
+
+func TestSetInterspersed(t *testing.T) {
+	cases := map[string]struct {
+		interspersed        bool
+		args                []string
+		expectedV           bool
+		expectedArgs        []string
+		expectedPassthrough []string
+	}{
+		"off": {
+			args:         []string{"in.txt", "-v", "out.txt"},
+			expectedArgs: []string{"in.txt", "-v", "out.txt"},
+		},
+		"on": {
+			interspersed: true,
+			args:         []string{"in.txt", "-v", "out.txt"},
+			expectedV:    true,
+			expectedArgs: []string{"in.txt", "out.txt"},
+		},
+		"dashes end the flags": {
+			interspersed:        true,
+			args:                []string{"in.txt", "--", "-v"},
+			expectedArgs:        []string{"in.txt", "-v"},
+			expectedPassthrough: []string{"-v"},
+		},
+	}
+	for caseName, c := range cases {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		verbose := f.Bool("verbose", false, "", "verbose", Short("v"))
+		f.SetInterspersed(c.interspersed)
+		if err := f.Parse(c.args); err != nil {
+			t.Errorf("case %s: unexpected error: %s", caseName, err)
+			continue
+		}
+		if *verbose != c.expectedV {
+			t.Errorf("case %s: expected verbose %v, got %v", caseName, c.expectedV, *verbose)
+		}
+		if !reflect.DeepEqual(f.Args(), c.expectedArgs) {
+			t.Errorf("case %s: expected arguments %q, got %q", caseName, c.expectedArgs, f.Args())
+		}
+		if !reflect.DeepEqual(f.PassthroughArgs(), c.expectedPassthrough) {
+			t.Errorf("case %s: expected passthrough %q, got %q", caseName, c.expectedPassthrough, f.PassthroughArgs())
+		}
+	}
+}
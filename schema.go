@@ -0,0 +1,113 @@
+package flagfig
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"sort"
+)
+
+// jsonSchema is the part of JSON Schema that GenJSONSchema writes
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 interface{}            `json:"type,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties interface{}            `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	Default              interface{}            `json:"default,omitempty"`
+	Deprecated           bool                   `json:"deprecated,omitempty"`
+}
+
+func GenJSONSchema(w io.Writer) error {
+	return CommandLine.GenJSONSchema(w)
+}
+
+// GenJSONSchema writes a JSON Schema (draft-07) describing the configuration file: each key with its type, usage,
+// default, Enum values, Pattern and Min and Max. Editors and CI can use it to check configuration files before they are
+// deployed. Required flags are listed as required, so the schema describes a file that holds the whole configuration.
+// With SetStrictConfig, keys that are not flags are rejected, as Parse would. Hidden flags are left out, like they are
+// from the rest of the documentation, so a strict schema rejects them too.
+// The schema describes flat files; it does not know about profile sections
+func (f *FlagfigSet) GenJSONSchema(w io.Writer) (err error) {
+	schema := &jsonSchema{
+		Schema:     "http://json-schema.org/draft-07/schema#",
+		Title:      f.Name(),
+		Type:       "object",
+		Properties: make(map[string]*jsonSchema),
+	}
+	if f.strictConfig {
+		schema.AdditionalProperties = false
+	}
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		if f.internalFlags[fl.Name] || f.hidden[fl.Name] || !f.sourceAllowed(fl.Name, SourceFile) {
+			return
+		}
+		schema.Properties[fl.Name] = f.flagSchema(fl)
+		if f.required[fl.Name] {
+			schema.Required = append(schema.Required, fl.Name)
+		}
+	})
+	sort.Strings(schema.Required)
+	raw, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return
+	}
+	_, err = w.Write(append(raw, '\n'))
+	return
+}
+
+// flagSchema describes the value of the flag in a configuration file
+func (f *FlagfigSet) flagSchema(fl *flag.Flag) *jsonSchema {
+	_, usage := f.unquoteUsage(fl)
+	s := &jsonSchema{Description: usage, Enum: f.enums[fl.Name]}
+	switch f.flagTypes[fl.Name] {
+	case boolType:
+		s.Type = "boolean"
+	case stringType:
+		s.Type = "string"
+	case intType, int64Type:
+		s.Type = "integer"
+	case uintType, uint64Type:
+		s.Type = "integer"
+		zero := 0.0
+		s.Minimum = &zero
+	case floatType:
+		s.Type = "number"
+	case durationType:
+		// Either text, such as "1m30s", or a number of nanoseconds
+		s.Type = []string{"string", "integer"}
+	case stringSliceType:
+		s.Type = "array"
+		s.Items = &jsonSchema{Type: "string"}
+	case stringMapType:
+		s.Type = "object"
+		s.AdditionalProperties = &jsonSchema{Type: "string"}
+	}
+	if p, ok := f.patterns[fl.Name]; ok {
+		if s.Items != nil {
+			s.Items.Pattern = p.re.String()
+		} else {
+			s.Pattern = p.re.String()
+		}
+	}
+	if f.flagTypes[fl.Name] != durationType {
+		if min, ok := f.minimums[fl.Name]; ok {
+			s.Minimum = &min
+		}
+		if max, ok := f.maximums[fl.Name]; ok {
+			s.Maximum = &max
+		}
+	}
+	if !f.sensitive[fl.Name] {
+		s.Default = f.sampleValue(fl)
+	}
+	_, s.Deprecated = f.deprecations[fl.Name]
+	return s
+}
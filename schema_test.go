@@ -0,0 +1,95 @@
+package flagfig
+
+import (
+	"bytes"
+	"flag"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestGenJSONSchema(t *testing.T) {
+	f := NewFlagfigSet("myapp", flag.ContinueOnError)
+	f.SetStrictConfig(true)
+	f.AddConfigFile("config", "configuration file")
+	f.String("level", "info", "", "log level", Enum("debug", "info"))
+	f.Int("port", 8080, "", "port", Min(1), Max(65535), Required())
+	f.Uint("workers", 0, "", "workers")
+	f.Duration("timeout", time.Second, "", "timeout")
+	f.StringSlice("peers", nil, "", "peers", Pattern(regexp.MustCompile(`^[a-z]+$`), "a name"))
+	f.StringMap("labels", nil, "", "labels")
+	f.String("token", "abc", "", "token", Sensitive(), Required())
+	f.String("secret", "", "", "secret", FromSources(SourceEnv))
+	f.String("trace-id", "", "", "for debugging", Hidden())
+	out := &bytes.Buffer{}
+	if err := f.GenJSONSchema(out); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	expected := `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "title": "myapp",
+  "type": "object",
+  "properties": {
+    "labels": {
+      "description": "labels",
+      "type": "object",
+      "additionalProperties": {
+        "type": "string"
+      },
+      "default": {}
+    },
+    "level": {
+      "description": "log level",
+      "type": "string",
+      "enum": [
+        "debug",
+        "info"
+      ],
+      "default": "info"
+    },
+    "peers": {
+      "description": "peers",
+      "type": "array",
+      "items": {
+        "type": "string",
+        "pattern": "^[a-z]+$"
+      },
+      "default": []
+    },
+    "port": {
+      "description": "port",
+      "type": "integer",
+      "minimum": 1,
+      "maximum": 65535,
+      "default": 8080
+    },
+    "timeout": {
+      "description": "timeout",
+      "type": [
+        "string",
+        "integer"
+      ],
+      "default": "1s"
+    },
+    "token": {
+      "description": "token",
+      "type": "string"
+    },
+    "workers": {
+      "description": "workers",
+      "type": "integer",
+      "minimum": 0,
+      "default": 0
+    }
+  },
+  "required": [
+    "port",
+    "token"
+  ],
+  "additionalProperties": false
+}
+`
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
@@ -0,0 +1,40 @@
+package flagfig
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// configExtensions are the configuration file formats, in the order they are looked for
+var configExtensions = []string{".json", ".yaml", ".yml"}
+
+// ConfigSearchPath lists where a program called name looks for its configuration file, from the most to the least
+// specific: name.json in the working directory, then config.json in name's directory in the user's configuration
+// directory, such as ~/.config/myapp/config.json, then /etc/myapp/config.json. Each is also looked for as .yaml and .yml
+func ConfigSearchPath(name string) []string {
+	dirs := make([]string, 0, 2)
+	if dir, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, filepath.Join(dir, name))
+	}
+	dirs = append(dirs, filepath.Join(string(filepath.Separator), "etc", name))
+	paths := make([]string, 0, len(configExtensions)*(len(dirs)+1))
+	for _, ext := range configExtensions {
+		paths = append(paths, name+ext)
+	}
+	for _, dir := range dirs {
+		for _, ext := range configExtensions {
+			paths = append(paths, filepath.Join(dir, "config"+ext))
+		}
+	}
+	return paths
+}
+
+// FindConfigFile is the first file on the ConfigSearchPath of name that exists, or blank if there is none
+func FindConfigFile(name string) string {
+	for _, path := range ConfigSearchPath(name) {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
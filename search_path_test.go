@@ -0,0 +1,36 @@
+package flagfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindConfigFile(t *testing.T) {
+	home, err := ioutil.TempDir("", "flagfig-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(home) }()
+	defer func(saved string) { _ = os.Setenv("XDG_CONFIG_HOME", saved) }(os.Getenv("XDG_CONFIG_HOME"))
+	_ = os.Setenv("XDG_CONFIG_HOME", home)
+
+	paths := ConfigSearchPath("flagfig-test")
+	if len(paths) != 9 || paths[0] != "flagfig-test.json" || paths[3] != filepath.Join(home, "flagfig-test", "config.json") {
+		t.Fatal("unexpected search path: ", paths)
+	}
+	if path := FindConfigFile("flagfig-test"); len(path) != 0 {
+		t.Error("expected no configuration file, got ", path)
+	}
+	user := filepath.Join(home, "flagfig-test", "config.yaml")
+	if err = os.MkdirAll(filepath.Dir(user), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(user, []byte("host: example.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if path := FindConfigFile("flagfig-test"); path != user {
+		t.Errorf("expected %s, got %s", user, path)
+	}
+}
@@ -0,0 +1,142 @@
+package flagfig
+
+import (
+	"flag"
+	"fmt"
+	"time"
+)
+
+func Settings() map[string]interface{} {
+	return CommandLine.Settings()
+}
+
+// Settings is the resolved value of every flag, by name, with its Go type: a string for String flags, an int for Int
+// flags, a []string for StringSlice flags, and so on. It is meant for logging or forwarding the whole configuration
+// without keeping the pointers returned when the flags were defined. The values are not redacted, see the Sensitive
+// field of FlagInfos before printing them. Lists and maps are copies, so changing them does not change the flags
+func (f *FlagfigSet) Settings() map[string]interface{} {
+	settings := make(map[string]interface{})
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		settings[fl.Name] = flagValue(fl)
+	})
+	return settings
+}
+
+// flagValue is the flag's value with its Go type, or its text if it has no Get method
+func flagValue(fl *flag.Flag) interface{} {
+	getter, ok := fl.Value.(flag.Getter)
+	if !ok {
+		return fl.Value.String()
+	}
+	switch v := getter.Get().(type) {
+	case []string:
+		return append([]string(nil), v...)
+	case map[string]string:
+		return copyStringMap(v)
+	default:
+		return v
+	}
+}
+
+// lookupValue is the typed value of the flag, or an error if there is no such flag
+func (f *FlagfigSet) lookupValue(name string) (interface{}, error) {
+	fl := f.FlagSet.Lookup(name)
+	if fl == nil {
+		return nil, fmt.Errorf("no such flag -%s", name)
+	}
+	return flagValue(fl), nil
+}
+
+// wrongType is the error for a flag read with a getter of another type
+func wrongType(name string, v interface{}, want string) error {
+	return fmt.Errorf("flag -%s is of type %T, not %s", name, v, want)
+}
+
+func GetString(name string) (string, error) {
+	return CommandLine.GetString(name)
+}
+
+// GetString is the value of a String flag, for code that only knows the flag's name. It is an error if there is no
+// such flag or it is of another type
+func (f *FlagfigSet) GetString(name string) (string, error) {
+	return getString(f.lookupValue, name)
+}
+
+// getString is GetString for FlagfigSets and Snapshots, which look the value up with lookup
+func getString(lookup func(name string) (interface{}, error), name string) (string, error) {
+	v, err := lookup(name)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", wrongType(name, v, "string")
+	}
+	return s, nil
+}
+
+func GetInt(name string) (int, error) {
+	return CommandLine.GetInt(name)
+}
+
+// GetInt is the value of an Int flag, see GetString
+func (f *FlagfigSet) GetInt(name string) (int, error) {
+	return getInt(f.lookupValue, name)
+}
+
+// getInt is GetInt, see getString
+func getInt(lookup func(name string) (interface{}, error), name string) (int, error) {
+	v, err := lookup(name)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := v.(int)
+	if !ok {
+		return 0, wrongType(name, v, "int")
+	}
+	return n, nil
+}
+
+func GetBool(name string) (bool, error) {
+	return CommandLine.GetBool(name)
+}
+
+// GetBool is the value of a Bool flag, see GetString
+func (f *FlagfigSet) GetBool(name string) (bool, error) {
+	return getBool(f.lookupValue, name)
+}
+
+// getBool is GetBool, see getString
+func getBool(lookup func(name string) (interface{}, error), name string) (bool, error) {
+	v, err := lookup(name)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, wrongType(name, v, "bool")
+	}
+	return b, nil
+}
+
+func GetDuration(name string) (time.Duration, error) {
+	return CommandLine.GetDuration(name)
+}
+
+// GetDuration is the value of a Duration flag, see GetString
+func (f *FlagfigSet) GetDuration(name string) (time.Duration, error) {
+	return getDuration(f.lookupValue, name)
+}
+
+// getDuration is GetDuration, see getString
+func getDuration(lookup func(name string) (interface{}, error), name string) (time.Duration, error) {
+	v, err := lookup(name)
+	if err != nil {
+		return 0, err
+	}
+	d, ok := v.(time.Duration)
+	if !ok {
+		return 0, wrongType(name, v, "time.Duration")
+	}
+	return d, nil
+}
@@ -0,0 +1,68 @@
+package flagfig
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSettings(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.String("host", "localhost", "", "host")
+	f.Int("port", 8080, "", "port")
+	f.Bool("verbose", false, "", "verbose")
+	f.Duration("timeout", time.Second, "", "timeout")
+	peers := f.StringSlice("peers", nil, "", "peers")
+	if err := f.Parse([]string{"-port=9090", "-peers=a,b"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"host":    "localhost",
+		"port":    9090,
+		"verbose": false,
+		"timeout": time.Second,
+		"peers":   []string{"a", "b"},
+	}
+	settings := f.Settings()
+	if !reflect.DeepEqual(settings, expected) {
+		t.Errorf("expected %v, got %v", expected, settings)
+	}
+	settings["peers"].([]string)[0] = "changed"
+	if (*peers)[0] != "a" {
+		t.Error("expected Settings to copy lists, got ", *peers)
+	}
+}
+
+func TestGetters(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.String("host", "localhost", "", "host")
+	f.Int("port", 8080, "", "port")
+	f.Bool("verbose", false, "", "verbose")
+	f.Duration("timeout", time.Second, "", "timeout")
+	if err := f.Parse([]string{"-verbose"}); err != nil {
+		t.Fatal(err)
+	}
+	host, err := f.GetString("host")
+	if host != "localhost" || err != nil {
+		t.Error("expected localhost, got ", host, err)
+	}
+	port, err := f.GetInt("port")
+	if port != 8080 || err != nil {
+		t.Error("expected 8080, got ", port, err)
+	}
+	verbose, err := f.GetBool("verbose")
+	if !verbose || err != nil {
+		t.Error("expected true, got ", verbose, err)
+	}
+	timeout, err := f.GetDuration("timeout")
+	if timeout != time.Second || err != nil {
+		t.Error("expected 1s, got ", timeout, err)
+	}
+	if _, err = f.GetInt("host"); err == nil || err.Error() != "flag -host is of type string, not int" {
+		t.Error("expected a type error, got ", err)
+	}
+	if _, err = f.GetString("missing"); err == nil || err.Error() != "no such flag -missing" {
+		t.Error("expected an error for a missing flag, got ", err)
+	}
+}
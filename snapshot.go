@@ -0,0 +1,141 @@
+package flagfig
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"time"
+)
+
+// Snapshot is the configuration as it was resolved at one time. It never changes, so any goroutine may read it, even
+// while Watch or Reload resolves the flags again, which the pointers returned when the flags were defined do not allow
+type Snapshot struct {
+	values map[string]interface{}
+	// texts are the values in a form Set accepts, see valueText
+	texts     map[string]string
+	origins   map[string]origin
+	sensitive map[string]bool
+	taken     time.Time
+}
+
+// TakeSnapshot is the Snapshot of the CommandLine, named so as not to clash with the Snapshot type
+func TakeSnapshot() *Snapshot {
+	return CommandLine.Snapshot()
+}
+
+// Snapshot is the configuration as of the last successful Parse or Reload, including any changes made by OnParsed
+// hooks. Before Parse, it holds the defaults. Take a new one whenever the latest values are wanted:
+//
+//	go flags.Watch(ctx)
+//	...
+//	limit, _ := flags.Snapshot().GetInt("limit")
+//
+// Taking one is cheap, as each Parse or Reload makes one for everyone to share
+func (f *FlagfigSet) Snapshot() *Snapshot {
+	if s, ok := f.snapshot.Load().(*Snapshot); ok {
+		return s
+	}
+	return f.takeSnapshot()
+}
+
+// takeSnapshot copies the values and origins of the flags as they are now
+func (f *FlagfigSet) takeSnapshot() *Snapshot {
+	s := &Snapshot{values: make(map[string]interface{}), texts: make(map[string]string), origins: make(map[string]origin),
+		sensitive: make(map[string]bool), taken: time.Now()}
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		s.values[fl.Name] = flagValue(fl)
+		s.texts[fl.Name] = valueText(fl)
+		s.origins[fl.Name] = f.origins[fl.Name]
+		s.sensitive[fl.Name] = f.sensitive[fl.Name]
+	})
+	return s
+}
+
+// publish makes a new Snapshot the current one, and adds it to the History
+func (f *FlagfigSet) publish() {
+	s := f.takeSnapshot()
+	f.addHistory(s)
+	f.snapshot.Store(s)
+}
+
+// Time is when the snapshot was taken
+func (s *Snapshot) Time() time.Time {
+	return s.taken
+}
+
+// Settings is the value of every flag in the snapshot, as FlagfigSet's Settings. It is a copy, so it may be changed
+func (s *Snapshot) Settings() map[string]interface{} {
+	settings := make(map[string]interface{}, len(s.values))
+	for name, v := range s.values {
+		switch typed := v.(type) {
+		case []string:
+			settings[name] = append([]string(nil), typed...)
+		case map[string]string:
+			settings[name] = copyStringMap(typed)
+		default:
+			settings[name] = v
+		}
+	}
+	return settings
+}
+
+// Origin reports where the flag's value came from when the snapshot was taken, as FlagfigSet's Origin
+func (s *Snapshot) Origin(name string) (source Source, detail string) {
+	o := s.origins[name]
+	return o.source, o.detail
+}
+
+// Sensitive reports whether the flag was marked Sensitive, so its value should not be shown
+func (s *Snapshot) Sensitive(name string) bool {
+	return s.sensitive[name]
+}
+
+// lookupValue is the value of the flag in the snapshot, or an error if there is no such flag
+func (s *Snapshot) lookupValue(name string) (interface{}, error) {
+	v, ok := s.values[name]
+	if !ok {
+		return nil, fmt.Errorf("no such flag -%s", name)
+	}
+	return v, nil
+}
+
+// GetString is the value of a String flag in the snapshot, as FlagfigSet's GetString
+func (s *Snapshot) GetString(name string) (string, error) {
+	return getString(s.lookupValue, name)
+}
+
+// GetInt is the value of an Int flag in the snapshot, as FlagfigSet's GetInt
+func (s *Snapshot) GetInt(name string) (int, error) {
+	return getInt(s.lookupValue, name)
+}
+
+// GetBool is the value of a Bool flag in the snapshot, as FlagfigSet's GetBool
+func (s *Snapshot) GetBool(name string) (bool, error) {
+	return getBool(s.lookupValue, name)
+}
+
+// GetDuration is the value of a Duration flag in the snapshot, as FlagfigSet's GetDuration
+func (s *Snapshot) GetDuration(name string) (time.Duration, error) {
+	return getDuration(s.lookupValue, name)
+}
+
+// Checksum identifies the configuration in the snapshot: it is the same whenever the flags have the same values, and
+// differs when any of them do, so a fleet can be checked for processes running another configuration. Sensitive
+// flags are left out, so it gives nothing of them away
+func (s *Snapshot) Checksum() string {
+	values := make(map[string]interface{}, len(s.values))
+	for name, v := range s.values {
+		if !s.sensitive[name] {
+			values[name] = v
+		}
+	}
+	// Maps are encoded with sorted keys, so the same values always give the same document
+	raw, err := json.Marshal(values)
+	if err != nil {
+		raw = []byte(fmt.Sprint(values))
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
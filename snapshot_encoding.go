@@ -0,0 +1,160 @@
+package flagfig
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// snapshotData is what is encoded of a Snapshot: the values, in a form Set accepts, and where they came from
+type snapshotData struct {
+	Values  map[string]string     `json:"values"`
+	Origins map[string]originData `json:"origins"`
+	Taken   time.Time             `json:"taken"`
+}
+
+type originData struct {
+	Source Source `json:"source"`
+	Detail string `json:"detail,omitempty"`
+	Key    string `json:"key,omitempty"`
+}
+
+func (s *Snapshot) data() snapshotData {
+	d := snapshotData{Values: s.texts, Origins: make(map[string]originData, len(s.origins)), Taken: s.taken}
+	for name, o := range s.origins {
+		d.Origins[name] = originData{Source: o.source, Detail: o.detail, Key: o.key}
+	}
+	return d
+}
+
+func (s *Snapshot) setData(d snapshotData) {
+	s.values = make(map[string]interface{})
+	s.texts = d.Values
+	if s.texts == nil {
+		s.texts = make(map[string]string)
+	}
+	s.origins = make(map[string]origin, len(d.Origins))
+	for name, o := range d.Origins {
+		s.origins[name] = origin{source: o.Source, detail: o.Detail, key: o.Key}
+	}
+	s.sensitive = make(map[string]bool)
+	s.taken = d.Taken
+}
+
+// MarshalBinary encodes the snapshot with gob, so it can be handed to another process and given to Restore there.
+// Sensitive values are encoded as they are, as the other process needs them, so keep the encoding as secret as they
+// are: pass it through a pipe or a file only its owner can read, never on a command line, in the environment, which
+// other processes of the same user may read, or in a log
+func (s *Snapshot) MarshalBinary() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(s.data()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a snapshot encoded by MarshalBinary. The decoded snapshot only knows the values as text,
+// as it does not know the flags, so pass it to Restore, rather than reading it with Settings or the getters
+func (s *Snapshot) UnmarshalBinary(dat []byte) error {
+	d := snapshotData{}
+	if err := gob.NewDecoder(bytes.NewReader(dat)).Decode(&d); err != nil {
+		return err
+	}
+	s.setData(d)
+	return nil
+}
+
+// MarshalJSON is MarshalBinary, for when the snapshot is better passed as text. It holds Sensitive values as they
+// are too
+func (s *Snapshot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.data())
+}
+
+// UnmarshalJSON is UnmarshalBinary, for a snapshot encoded by MarshalJSON
+func (s *Snapshot) UnmarshalJSON(dat []byte) error {
+	d := snapshotData{}
+	if err := json.Unmarshal(dat, &d); err != nil {
+		return err
+	}
+	s.setData(d)
+	return nil
+}
+
+func Restore(s *Snapshot) error {
+	return CommandLine.Restore(s)
+}
+
+// Restore sets the flags to the values and origins in the snapshot, then runs the OnParsed hooks, in place of Parse.
+// With it, a parent process can hand its exact configuration to the workers it starts, without each of them reading
+// the configuration files and the environment again, and maybe finding them changed:
+//
+//	// in the parent, the snapshot goes to the worker's file descriptor 3, as it holds the Sensitive values too
+//	r, w, _ := os.Pipe()
+//	cmd.ExtraFiles = []*os.File{r}
+//	err := cmd.Start()
+//	...
+//	_ = r.Close()
+//	_ = json.NewEncoder(w).Encode(flags.Snapshot())
+//	_ = w.Close()
+//
+//	// in the worker
+//	s := &flagfig.Snapshot{}
+//	if err := json.NewDecoder(os.NewFile(3, "state")).Decode(s); err != nil {
+//		...
+//	}
+//	err = flags.Restore(s)
+//
+// Values that came from the command line are restored as if given on the worker's command line, so Reload keeps them,
+// as it would have in the parent. Every flag in the snapshot must be defined, as it was in the parent. If a value
+// cannot be set, or a hook fails, the flags are left as they were
+func (f *FlagfigSet) Restore(s *Snapshot) (err error) {
+	f.reloadMu.Lock()
+	defer f.reloadMu.Unlock()
+	restore := f.saveValues()
+	defer func() {
+		if err != nil {
+			restore()
+		}
+	}()
+	return f.applySnapshot(s)
+}
+
+// applySnapshot sets the flags to the values and origins in s, runs the OnParsed hooks and publishes the result. The
+// caller holds reloadMu, and puts the values back if it fails
+func (f *FlagfigSet) applySnapshot(s *Snapshot) (err error) {
+	names := make([]string, 0, len(s.texts))
+	for name := range s.texts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fl := f.FlagSet.Lookup(name)
+		if fl == nil {
+			return fmt.Errorf("unable to restore flag -%s: it is not defined", name)
+		}
+		if m, ok := fl.Value.(mergeable); ok {
+			m.unset()
+		}
+		// Values from the command line are set as if given there, so Reload keeps them ahead of the other sources
+		if s.origins[name].source == SourceFlag {
+			expectJSON(fl.Value)
+			err = f.FlagSet.Set(name, s.texts[name])
+		} else {
+			err = setValueText(fl, fl, s.texts[name])
+		}
+		if err != nil {
+			_, err = f.redactValue(name, s.texts[name], err)
+			return fmt.Errorf("unable to restore flag -%s: %v", name, err)
+		}
+		f.origins[name] = s.origins[name]
+	}
+	err = f.runParsedHooks()
+	if err != nil {
+		return
+	}
+	f.publish()
+	return
+}
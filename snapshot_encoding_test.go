@@ -0,0 +1,135 @@
+package flagfig
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"testing"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	tmpFileName, tfremove := testTempFile(t)
+	defer tfremove()
+	if err := ioutil.WriteFile(tmpFileName, []byte(`{"port":9090,"peers":["a","b"]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	define := func() *FlagfigSet {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.AddConfigFile("config", "config file")
+		f.String("host", "localhost", "", "host")
+		f.Int("port", 8080, "", "port")
+		f.StringSlice("peers", []string{"z"}, "", "peers", Merge(MergeAppend))
+		return f
+	}
+	cases := map[string]struct {
+		encode func(s *Snapshot) ([]byte, error)
+		decode func(dat []byte, s *Snapshot) error
+	}{
+		"gob": {
+			encode: func(s *Snapshot) ([]byte, error) { return s.MarshalBinary() },
+			decode: func(dat []byte, s *Snapshot) error { return s.UnmarshalBinary(dat) },
+		},
+		"json": {
+			encode: func(s *Snapshot) ([]byte, error) { return json.Marshal(s) },
+			decode: func(dat []byte, s *Snapshot) error { return json.Unmarshal(dat, s) },
+		},
+	}
+	for caseName, c := range cases {
+		parent := define()
+		if err := parent.Parse([]string{"-config", tmpFileName, "-host=example.com"}); err != nil {
+			t.Fatal(err)
+		}
+		dat, err := c.encode(parent.Snapshot())
+		if err != nil {
+			t.Fatalf("case %s: %v", caseName, err)
+		}
+
+		child := define()
+		hookRan := false
+		child.OnParsed(func(f *FlagfigSet) error {
+			hookRan = true
+			return nil
+		})
+		s := &Snapshot{}
+		if err = c.decode(dat, s); err != nil {
+			t.Fatalf("case %s: %v", caseName, err)
+		}
+		if err = child.Restore(s); err != nil {
+			t.Fatalf("case %s: %v", caseName, err)
+		}
+		if !hookRan {
+			t.Errorf("case %s: expected the OnParsed hooks to run", caseName)
+		}
+		if child.Snapshot().Checksum() != parent.Snapshot().Checksum() {
+			t.Errorf("case %s: expected the child to have the parent's configuration, got %v, expected %v", caseName,
+				child.Settings(), parent.Settings())
+		}
+		if source, detail := child.Origin("port"); source != SourceFile || detail != tmpFileName {
+			t.Errorf("case %s: expected -port to come from %s, got %s %s", caseName, tmpFileName, source, detail)
+		}
+	}
+}
+
+func TestSnapshotRestoreThenReload(t *testing.T) {
+	tmpFileName, tfremove := testTempFile(t)
+	defer tfremove()
+	if err := ioutil.WriteFile(tmpFileName, []byte(`{"limit":5,"host":"example.com"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	define := func() (*FlagfigSet, *int) {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.AddConfigLayer("base", tmpFileName)
+		f.String("host", "localhost", "", "host")
+		return f, f.Int("limit", 10, "", "limit")
+	}
+	parent, _ := define()
+	if err := parent.Parse([]string{"-limit=9"}); err != nil {
+		t.Fatal(err)
+	}
+	dat, err := json.Marshal(parent.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Snapshot{}
+	if err = json.Unmarshal(dat, s); err != nil {
+		t.Fatal(err)
+	}
+	child, limit := define()
+	if err = child.Restore(s); err != nil {
+		t.Fatal(err)
+	}
+	if err = child.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if source, _ := child.Origin("limit"); *limit != 9 || source != SourceFlag {
+		t.Errorf("expected the command line's -limit=9 to outlast Reload, got %d from %s", *limit, source)
+	}
+	if source, _ := child.Origin("host"); source != SourceFile {
+		t.Error("expected -host to come from the file again, got ", source)
+	}
+}
+
+func TestSnapshotRestoreUndefinedFlag(t *testing.T) {
+	parent := NewFlagfigSet("test", flag.ContinueOnError)
+	parent.Int("port", 8080, "", "port")
+	parent.String("extra", "x", "", "extra")
+	if err := parent.Parse([]string{"-port=9090"}); err != nil {
+		t.Fatal(err)
+	}
+	dat, err := parent.Snapshot().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Snapshot{}
+	if err = s.UnmarshalBinary(dat); err != nil {
+		t.Fatal(err)
+	}
+	child := NewFlagfigSet("test", flag.ContinueOnError)
+	port := child.Int("port", 8080, "", "port")
+	if err = child.Restore(s); err == nil {
+		t.Error("expected an error for the flag the child does not define")
+	}
+	if *port != 8080 {
+		t.Errorf("expected -port to be left as it was, got %d", *port)
+	}
+}
@@ -0,0 +1,87 @@
+package flagfig
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	path, cleanup := testTempFile(t)
+	defer cleanup()
+	if err := ioutil.WriteFile(path, []byte(`{"limit": 1}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.AddConfigLayer("base", path)
+	limit := f.Int("limit", 10, "", "limit")
+	f.StringSlice("peers", []string{"a"}, "", "peers")
+	if n, _ := f.Snapshot().GetInt("limit"); n != 10 {
+		t.Error("expected the default before Parse, got ", n)
+	}
+	if err := f.Parse([]string{"-peers=b,c"}); err != nil {
+		t.Fatal(err)
+	}
+	before := f.Snapshot()
+	if source, _ := before.Origin("limit"); source != SourceFile {
+		t.Error("expected -limit from the file, got ", source)
+	}
+	settings := before.Settings()
+	settings["peers"].([]string)[0] = "changed"
+	if !reflect.DeepEqual(before.Settings()["peers"], []string{"b", "c"}) {
+		t.Error("expected Settings to be a copy, got ", before.Settings()["peers"])
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 2; i < 50; i++ {
+			_ = ioutil.WriteFile(path, []byte(fmt.Sprintf(`{"limit": %d}`, i)), 0600)
+			if err := f.Reload(); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		if _, err := f.Snapshot().GetInt("limit"); err != nil {
+			t.Error(err)
+		}
+	}
+	wg.Wait()
+	if n, _ := f.Snapshot().GetInt("limit"); n != 49 || *limit != 49 {
+		t.Errorf("expected the last reload, got %d and %d", n, *limit)
+	}
+	if n, _ := before.GetInt("limit"); n != 1 {
+		t.Error("expected the old snapshot to keep its value, got ", n)
+	}
+	if _, err := before.GetString("limit"); err == nil || err.Error() != "flag -limit is of type int, not string" {
+		t.Error("expected a type error, got ", err)
+	}
+}
+
+func TestSnapshot_Checksum(t *testing.T) {
+	checksum := func(args ...string) string {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.Int("limit", 10, "", "limit")
+		f.StringSlice("peers", nil, "", "peers")
+		f.String("password", "", "", "password", Sensitive())
+		if err := f.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		return f.Snapshot().Checksum()
+	}
+	base := checksum("-peers=a,b")
+	if len(base) != 64 || base != checksum("-peers=a,b", "-limit=10") {
+		t.Error("expected the same values to give the same checksum, got ", base)
+	}
+	if base == checksum("-peers=b,a") {
+		t.Error("expected different values to give another checksum")
+	}
+	if base != checksum("-peers=a,b", "-password=secret") {
+		t.Error("expected sensitive flags to be left out of the checksum")
+	}
+}
@@ -0,0 +1,102 @@
+package flagfig
+
+import "fmt"
+
+// Source identifies one of the places a flag's value can come from. They are listed from lowest to highest precedence
+type Source int
+
+const (
+	// SourceDefault is the value the flag was defined with, used when no other source sets it
+	SourceDefault Source = iota
+	// SourceFile is a configuration file
+	SourceFile
+	// SourceEnv is an environment variable
+	SourceEnv
+	// SourceFlag is the command line
+	SourceFlag
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceDefault:
+		return "default"
+	case SourceFile:
+		return "file"
+	case SourceEnv:
+		return "env"
+	case SourceFlag:
+		return "flag"
+	}
+	return "unknown"
+}
+
+// origin records which source set a flag, and more specifically, where: the environment variable name or the path
+// of the configuration file
+type origin struct {
+	source Source
+	detail string
+	// key is the configuration file key that set the flag, as written, for SourceFile
+	key string
+}
+
+// Origin reports where the CommandLine flag's value came from
+func Origin(name string) (source Source, detail string) {
+	return CommandLine.Origin(name)
+}
+
+// Origin reports where the flag's value came from after Parse. For SourceEnv, detail is the environment variable name,
+// for SourceFile, it is the path of the configuration file that provided the value, written as "label (path)" for
+// files added with AddConfigLayer. Values typed at a PromptMissing prompt are SourceFlag with the detail "prompt", and
+// values changed through AdminHandler are SourceFlag with the detail "admin".
+// It is blank for the other sources.
+// Flags that do not exist, or have not been parsed yet, are reported as SourceDefault.
+//
+// This is handy for logging your configuration at startup:
+//
+//	flags.VisitAll(func(fl *flag.Flag) {
+//		source, detail := flags.Origin(fl.Name)
+//		log.Printf("%s=%s (%s %s)", fl.Name, fl.Value, source, detail)
+//	})
+func (f *FlagfigSet) Origin(name string) (source Source, detail string) {
+	o := f.origins[name]
+	return o.source, o.detail
+}
+
+// IsSet reports whether the CommandLine flag was set by any source
+func IsSet(name string) bool {
+	return CommandLine.IsSet(name)
+}
+
+// IsSet reports whether the flag was explicitly set by the command line, the environment, or a configuration file
+// after Parse. It is false for flags that fell back to their default value, even if a source happened to provide the
+// same value as the default.
+func (f *FlagfigSet) IsSet(name string) bool {
+	return f.origins[name].source != SourceDefault
+}
+
+// describeOrigin names where the flag's value came from, for use in error messages
+func (f *FlagfigSet) describeOrigin(name string) string {
+	o := f.origins[name]
+	switch o.source {
+	case SourceFile:
+		if len(o.key) != 0 {
+			return fmt.Sprintf("configuration file %s, key %q", o.detail, o.key)
+		}
+		return "configuration file " + o.detail
+	case SourceEnv:
+		return "environment variable " + o.detail
+	case SourceFlag:
+		switch o.detail {
+		case "prompt":
+			return "the prompt"
+		case "admin":
+			return "the admin handler"
+		}
+		return "the command line"
+	}
+	if len(o.detail) != 0 {
+		// DefaultFrom
+		return "the value of " + o.detail
+	}
+	return "the default"
+}
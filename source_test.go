@@ -0,0 +1,117 @@
+package flagfig
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestOrigin(t *testing.T) {
+	tmpFileName, tfremove := testTempFile(t)
+	defer tfremove()
+	if err := ioutil.WriteFile(tmpFileName, []byte(`{"in-file":"x","in-env":"x","in-flag":"x"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Setenv("ENV_ORIGIN", "y")
+	defer func() { _ = os.Unsetenv("ENV_ORIGIN") }()
+
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.AddConfigFile("config", "config file")
+	f.String("in-file", "", "", "in file")
+	f.String("in-env", "", "ENV_ORIGIN", "in env")
+	f.String("in-flag", "", "ENV_ORIGIN", "in flag")
+	f.String("defaulted", "", "", "defaulted")
+	if err := f.Parse([]string{"-config", tmpFileName, "-in-flag=z"}); err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]struct {
+		source Source
+		detail string
+	}{
+		"in-file":   {source: SourceFile, detail: tmpFileName},
+		"in-env":    {source: SourceEnv, detail: "ENV_ORIGIN"},
+		"in-flag":   {source: SourceFlag},
+		"defaulted": {source: SourceDefault},
+		"config":    {source: SourceFlag},
+		"missing":   {source: SourceDefault},
+	}
+	for name, c := range cases {
+		source, detail := f.Origin(name)
+		if source != c.source || detail != c.detail {
+			t.Errorf("%s: expected %s %q, got %s %q", name, c.source, c.detail, source, detail)
+		}
+	}
+}
+
+func TestIsSet(t *testing.T) {
+	_ = os.Setenv("ENV_IS_SET", "8080")
+	defer func() { _ = os.Unsetenv("ENV_IS_SET") }()
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.Int("env-port", 8080, "ENV_IS_SET", "port")
+	f.Int("flag-port", 8080, "", "port")
+	f.Int("default-port", 8080, "", "port")
+	if err := f.Parse([]string{"-flag-port=8080"}); err != nil {
+		t.Fatal(err)
+	}
+	if !f.IsSet("env-port") {
+		t.Error("env-port was set by the environment")
+	}
+	if !f.IsSet("flag-port") {
+		t.Error("flag-port was set on the command line")
+	}
+	if f.IsSet("default-port") {
+		t.Error("default-port was not set")
+	}
+}
+
+func TestDescribeOrigin(t *testing.T) {
+	path, cleanup := testTempFile(t)
+	defer cleanup()
+	cases := map[string]struct {
+		content  string
+		setup    func(f *FlagfigSet)
+		expected string
+	}{
+		"key": {
+			content:  `{"port": 70000}`,
+			expected: `-port is 70000 from configuration file ` + path + `, key "port", but must be at most 65535`,
+		},
+		"alias": {
+			content: `{"listen_port": 70000}`,
+			setup: func(f *FlagfigSet) {
+				f.AliasConfigKey("port", "listen_port")
+			},
+			expected: `-port is 70000 from configuration file ` + path + `, key "listen_port", but must be at most 65535`,
+		},
+		"profile": {
+			content: `{"default": {"port": 80}, "production": {"port": 70000}}`,
+			setup: func(f *FlagfigSet) {
+				f.SetProfile("production")
+			},
+			expected: `-port is 70000 from configuration file ` + path + `, key "production.port", but must be at most 65535`,
+		},
+		"invalid alias": {
+			content: `{"listen_port": "eighty"}`,
+			setup: func(f *FlagfigSet) {
+				f.AliasConfigKey("port", "listen_port")
+			},
+			expected: `invalid value "eighty" for key "listen_port" (flag -port) in configuration file ` + path + `: parse error`,
+		},
+	}
+	for caseName, c := range cases {
+		if err := ioutil.WriteFile(path, []byte(c.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.AddConfigLayer("", path)
+		f.Int("port", 8080, "", "port", Max(65535))
+		if c.setup != nil {
+			c.setup(f)
+		}
+		err := f.Parse([]string{})
+		if err == nil || err.Error() != c.expected {
+			t.Errorf("case %s: expected %q, got %v", caseName, c.expected, err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package flagfig
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SetStrictConfig turns strict configuration files on or off for the CommandLine
+func SetStrictConfig(strict bool) {
+	CommandLine.SetStrictConfig(strict)
+}
+
+// SetStrictConfig makes Collate fail when a configuration file has keys that do not match any flag, listing them.
+// Without it, unknown keys are ignored, so a typo like "htp_addr" silently does nothing
+func (f *FlagfigSet) SetStrictConfig(strict bool) {
+	f.strictConfig = strict
+}
+
+// OnUnknownConfigKey sets the unknown configuration key callback for the CommandLine
+func OnUnknownConfigKey(callback func(path, key string)) {
+	CommandLine.OnUnknownConfigKey(callback)
+}
+
+// OnUnknownConfigKey calls callback for each key in a configuration file that does not match any flag. Use this to
+// warn about unknown keys without failing, such as:
+//
+//	flags.OnUnknownConfigKey(func(path, key string) {
+//		log.Printf("warning: %s: unknown key %q", path, key)
+//	})
+func (f *FlagfigSet) OnUnknownConfigKey(callback func(path, key string)) {
+	f.onUnknownKey = callback
+}
+
+// checkUnknownKeys reports the keys of the configuration document that do not match any flag
+func (f *FlagfigSet) checkUnknownKeys(layer configLayer, doc map[string]interface{}) (err error) {
+	if !f.strictConfig && f.onUnknownKey == nil {
+		return nil
+	}
+	unknown := make([]string, 0)
+	for key := range doc {
+		if f.FlagSet.Lookup(key) == nil {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	if f.onUnknownKey != nil {
+		for _, key := range unknown {
+			f.onUnknownKey(*layer.path, key)
+		}
+	}
+	if f.strictConfig && len(unknown) != 0 {
+		return errorOfKind(ErrUnknownKey, "unknown keys in configuration file %s: %s", layer.describe(), strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// SetStrictEnv turns strict environment checking on or off for the CommandLine
+func SetStrictEnv(strict bool) {
+	CommandLine.SetStrictEnv(strict)
+}
+
+// SetStrictEnv makes Collate fail when the environment has variables that start with the AutomaticEnv prefix, but
+// do not belong to any flag, listing them. This catches misspellings like MYAPP_HTP_ADDR, mirroring SetStrictConfig.
+// It has no effect unless AutomaticEnv was called with a prefix
+func (f *FlagfigSet) SetStrictEnv(strict bool) {
+	f.strictEnv = strict
+}
+
+// OnUnknownEnv sets the unknown environment variable callback for the CommandLine
+func OnUnknownEnv(callback func(name string)) {
+	CommandLine.OnUnknownEnv(callback)
+}
+
+// OnUnknownEnv calls callback for each environment variable that starts with the AutomaticEnv prefix, but does not
+// belong to any flag. Use this to warn rather than fail, see SetStrictEnv
+func (f *FlagfigSet) OnUnknownEnv(callback func(name string)) {
+	f.onUnknownEnv = callback
+}
+
+// indexedEnvSuffix matches the numbered variables used for lists, see lookupIndexedEnv
+var indexedEnvSuffix = regexp.MustCompile(`_[0-9]+$`)
+
+// checkUnknownEnv reports the environment variables under the prefix that no flag reads
+func (f *FlagfigSet) checkUnknownEnv() (err error) {
+	if (!f.strictEnv && f.onUnknownEnv == nil) || len(f.envPrefix) == 0 {
+		return nil
+	}
+	known := make(map[string]bool)
+	for name := range f.envOpts {
+		if envName := f.envNameFor(name); len(envName) != 0 {
+			known[f.normalizeEnvName(envName)] = true
+		}
+	}
+	if len(f.profileEnvName) != 0 {
+		known[f.normalizeEnvName(f.profileEnvName)] = true
+	}
+	candidates := make(map[string]bool)
+	for _, kv := range os.Environ() {
+		candidates[strings.SplitN(kv, "=", 2)[0]] = true
+	}
+	for name := range f.dotEnv {
+		candidates[name] = true
+	}
+	prefix := f.normalizeEnvName(f.envPrefix + "_")
+	unknown := make([]string, 0)
+	for name := range candidates {
+		normalized := f.normalizeEnvName(name)
+		if !strings.HasPrefix(normalized, prefix) || known[normalized] {
+			continue
+		}
+		if base := indexedEnvSuffix.ReplaceAllString(normalized, ""); base != normalized && known[base] {
+			// PEERS_0, PEERS_1, ... belong to the PEERS list
+			continue
+		}
+		unknown = append(unknown, name)
+	}
+	sort.Strings(unknown)
+	if f.onUnknownEnv != nil {
+		for _, name := range unknown {
+			f.onUnknownEnv(name)
+		}
+	}
+	if f.strictEnv && len(unknown) != 0 {
+		return errorOfKind(ErrUnknownKey, "unknown environment variables with prefix %s: %s", f.envPrefix, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// normalizeEnvName is the form of an environment variable name used to compare names, see SetEnvCaseInsensitive
+func (f *FlagfigSet) normalizeEnvName(name string) string {
+	if f.envIgnoreCase {
+		return strings.ToUpper(name)
+	}
+	return name
+}
@@ -0,0 +1,88 @@
+package flagfig
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestStrictConfig(t *testing.T) {
+	tmpFileName, tfremove := testTempFile(t)
+	defer tfremove()
+	if err := ioutil.WriteFile(tmpFileName, []byte(`{"http_addr":":80","htp_addr":":81","zzz":1}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.AddConfigFile("config", "config file")
+	f.String("http_addr", "", "", "http address")
+	f.SetStrictConfig(true)
+	err := f.Parse([]string{"-config", tmpFileName})
+	expected := "unknown keys in configuration file " + tmpFileName + ": htp_addr, zzz"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+
+	f = NewFlagfigSet("test", flag.ContinueOnError)
+	f.AddConfigFile("config", "config file")
+	httpAddr := f.String("http_addr", "", "", "http address")
+	warnings := make([]string, 0)
+	f.OnUnknownConfigKey(func(path, key string) {
+		warnings = append(warnings, key)
+	})
+	if err = f.Parse([]string{"-config", tmpFileName}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(warnings, []string{"htp_addr", "zzz"}) {
+		t.Error("expected warnings for htp_addr and zzz, got ", warnings)
+	}
+	if *httpAddr != ":80" {
+		t.Error("known keys should still be applied, http_addr is ", *httpAddr)
+	}
+}
+
+func TestStrictEnv(t *testing.T) {
+	vars := map[string]string{
+		"STRICTAPP_HTTP_ADDR": ":80",
+		"STRICTAPP_HTP_ADDR":  ":81",
+		"STRICTAPP_PEERS_0":   "a",
+		"STRICTAPP_TOKEN":     "explicitly named",
+		"OTHERAPP_HTP_ADDR":   "not our prefix",
+	}
+	for k, v := range vars {
+		_ = os.Setenv(k, v)
+	}
+	defer func() {
+		for k := range vars {
+			_ = os.Unsetenv(k)
+		}
+	}()
+	newSet := func() *FlagfigSet {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.AutomaticEnv("STRICTAPP")
+		f.String("http-addr", "", "", "http address")
+		f.StringSlice("peers", nil, "", "peers")
+		f.String("token", "", "STRICTAPP_TOKEN", "token")
+		return f
+	}
+	f := newSet()
+	f.SetStrictEnv(true)
+	err := f.Parse([]string{})
+	expected := "unknown environment variables with prefix STRICTAPP: STRICTAPP_HTP_ADDR"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+
+	f = newSet()
+	warnings := make([]string, 0)
+	f.OnUnknownEnv(func(name string) {
+		warnings = append(warnings, name)
+	})
+	if err = f.Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(warnings, []string{"STRICTAPP_HTP_ADDR"}) {
+		t.Error("expected a warning for STRICTAPP_HTP_ADDR, got ", warnings)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package flagfig
+
+// terminalWidth cannot find the size of the terminal on this system
+func terminalWidth(w interface{}) int {
+	return 0
+}
@@ -0,0 +1,25 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package flagfig
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// terminalWidth is the number of columns of the terminal w writes to, or 0 if it is not a terminal
+func terminalWidth(w interface{}) int {
+	file, ok := w.(interface{ Fd() uintptr })
+	if !ok || !isTerminal(w) {
+		return 0
+	}
+	var size struct {
+		rows, columns, xPixels, yPixels uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&size)))
+	if errno != 0 {
+		return 0
+	}
+	return int(size.columns)
+}
@@ -0,0 +1,34 @@
+package flagfig
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                       = syscall.NewLazyDLL("kernel32.dll")
+	procGetConsoleScreenBufferInfo = kernel32.NewProc("GetConsoleScreenBufferInfo")
+)
+
+// consoleScreenBufferInfo is CONSOLE_SCREEN_BUFFER_INFO
+type consoleScreenBufferInfo struct {
+	size, cursorPosition     [2]int16
+	attributes               uint16
+	left, top, right, bottom int16
+	maximumWindowSize        [2]int16
+}
+
+// terminalWidth is the number of columns of the console window w writes to, or 0 if it is not a console
+func terminalWidth(w interface{}) int {
+	file, ok := w.(*os.File)
+	if !ok {
+		return 0
+	}
+	var info consoleScreenBufferInfo
+	done, _, _ := procGetConsoleScreenBufferInfo.Call(file.Fd(), uintptr(unsafe.Pointer(&info)))
+	if done == 0 {
+		return 0
+	}
+	return int(info.right-info.left) + 1
+}
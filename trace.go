@@ -0,0 +1,76 @@
+package flagfig
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// traceCandidate is a value that a source offered for a flag while it was being resolved
+type traceCandidate struct {
+	source Source
+	detail string
+	value  string
+}
+
+// SetTrace turns on tracing for the CommandLine
+func SetTrace(w io.Writer) {
+	CommandLine.SetTrace(w)
+}
+
+// SetTrace makes Collate write, to w, every value that was considered for each flag and which one won. Use this
+// when you cannot work out why a flag has the value it does. Pass nil to turn tracing off. The output looks like:
+//
+//	-port
+//	    default  8080
+//	    file     9090 (/etc/myapp.json)
+//	    env      7070 (MYAPP_PORT)
+//	    => env 7070 (MYAPP_PORT)
+func (f *FlagfigSet) SetTrace(w io.Writer) {
+	f.trace = w
+}
+
+// traceCandidate records a candidate value for the flag when tracing is on
+func (f *FlagfigSet) traceCandidate(name string, source Source, detail, value string) {
+	if f.trace == nil {
+		return
+	}
+	f.traceLog[name] = append(f.traceLog[name], traceCandidate{source: source, detail: detail, value: f.redact(name, value)})
+}
+
+// writeTrace writes the candidates recorded during Collate, in order of precedence, followed by the winner
+func (f *FlagfigSet) writeTrace() {
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		candidates := append([]traceCandidate{{source: SourceDefault, value: f.redact(fl.Name, fl.DefValue)}}, f.traceLog[fl.Name]...)
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].source < candidates[j].source
+		})
+		_, _ = fmt.Fprintf(f.trace, "-%s\n", fl.Name)
+		for _, c := range candidates {
+			_, _ = fmt.Fprintf(f.trace, "    %-8s %s%s\n", c.source, c.value, traceDetail(c.detail))
+		}
+		o := f.origins[fl.Name]
+		_, _ = fmt.Fprintf(f.trace, "    => %s %s%s\n", o.source, f.displayValue(fl), traceDetail(o.detail))
+	})
+}
+
+func traceDetail(detail string) string {
+	if len(detail) == 0 {
+		return ""
+	}
+	return " (" + detail + ")"
+}
+
+// traceValue formats a value decoded from a configuration file as it appeared in the file
+func traceValue(val interface{}) string {
+	if s, ok := val.(string); ok {
+		return s
+	}
+	raw, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Sprint(val)
+	}
+	return string(raw)
+}
@@ -0,0 +1,42 @@
+package flagfig
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSetTrace(t *testing.T) {
+	tmpFileName, tfremove := testTempFile(t)
+	defer tfremove()
+	if err := ioutil.WriteFile(tmpFileName, []byte(`{"port":9090,"host":"file-host"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Setenv("ENV_TRACE_PORT", "7070")
+	_ = os.Setenv("ENV_TRACE_HOST", "env-host")
+	defer func() {
+		_ = os.Unsetenv("ENV_TRACE_PORT")
+		_ = os.Unsetenv("ENV_TRACE_HOST")
+	}()
+	trace := &bytes.Buffer{}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetTrace(trace)
+	f.AddConfigFile("config", "config file")
+	f.Int("port", 8080, "ENV_TRACE_PORT", "port")
+	f.String("host", "localhost", "ENV_TRACE_HOST", "host")
+	if err := f.Parse([]string{"-config", tmpFileName, "-host=flag-host"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"-port\n    default  8080\n    file     9090 (" + tmpFileName + ")\n    env      7070 (ENV_TRACE_PORT)\n    => env 7070 (ENV_TRACE_PORT)\n",
+		"-host\n    default  localhost\n    file     file-host (" + tmpFileName + ")\n    env      env-host (ENV_TRACE_HOST)\n    flag     flag-host\n    => flag flag-host\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(trace.String(), e) {
+			t.Errorf("trace should contain:\n%s\ngot:\n%s", e, trace.String())
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package flagfig
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Unmarshal copies the resolved CommandLine flag values into the struct ptr points to
+func Unmarshal(ptr interface{}) error {
+	return CommandLine.Unmarshal(ptr)
+}
+
+// Unmarshal copies the resolved flag values into the fields of the struct ptr points to, for programs that would
+// rather pass around a plain configuration struct than the pointers returned when defining flags. Call it after Parse:
+//
+//	type config struct {
+//		HTTPAddr string
+//		Timeout  time.Duration `flagfig:"request-timeout"`
+//	}
+//	var cfg config
+//	err := flags.Unmarshal(&cfg)
+//
+// A field takes the flag named in its flagfig tag, or else the flag whose name matches the field's, ignoring case,
+// dashes, dots and underscores, so HTTPAddr matches http-addr. Fields without a matching flag are left alone. Numbers
+// are converted between sizes, such as an Int flag into an int32 field. Other mismatched types are given to the hooks
+// added with AddDecodeHook, and are an error if there are none
+func (f *FlagfigSet) Unmarshal(ptr interface{}) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("flagfig: Unmarshal needs a pointer to a struct, not %T", ptr)
+	}
+	v = v.Elem()
+	byKey := make(map[string]*flag.Flag)
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		byKey[fieldKey(fl.Name)] = fl
+	})
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if len(field.PkgPath) != 0 {
+			continue
+		}
+		var fl *flag.Flag
+		if tag, ok := field.Tag.Lookup(tagName); ok {
+			if tag == "-" {
+				continue
+			}
+			fl = f.FlagSet.Lookup(parseBindTag(tag).name)
+		} else {
+			fl = byKey[fieldKey(field.Name)]
+		}
+		if fl == nil {
+			continue
+		}
+		if err := f.unmarshalFlag(fl, v.Field(i), field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unmarshalFlag copies the flag's value into the field
+func (f *FlagfigSet) unmarshalFlag(fl *flag.Flag, fv reflect.Value, field reflect.StructField) error {
+	getter, ok := fl.Value.(flag.Getter)
+	if !ok {
+		return fmt.Errorf("flagfig: cannot unmarshal -%s, its value has no Get method", fl.Name)
+	}
+	value := reflect.ValueOf(getter.Get())
+	switch {
+	case value.Type().AssignableTo(fv.Type()):
+		fv.Set(value)
+	case isNumber(value.Kind()) && isNumber(fv.Kind()):
+		fv.Set(value.Convert(fv.Type()))
+	case len(f.decodeHooks) != 0:
+		decoded, err := f.decode(value.Interface(), fv.Type())
+		if err != nil {
+			return fmt.Errorf("flagfig: cannot unmarshal -%s into field %s: %v", fl.Name, field.Name, err)
+		}
+		fv.Set(decoded)
+	default:
+		return fmt.Errorf("flagfig: cannot unmarshal -%s of type %s into field %s of type %s", fl.Name, value.Type(), field.Name, fv.Type())
+	}
+	return nil
+}
+
+// fieldKey is how flag and field names are compared by Unmarshal
+func fieldKey(name string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", "_", "", ".", "").Replace(name))
+}
+
+// isNumber is true for the integer and floating point kinds
+func isNumber(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package flagfig
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUnmarshal(t *testing.T) {
+	type config struct {
+		HTTPAddr string
+		Timeout  time.Duration `flagfig:"request-timeout"`
+		Workers  int32
+		Peers    []string
+		Limits   testLimits
+		Ignored  string `flagfig:"-"`
+		Other    string
+		internal string
+	}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.String("http-addr", ":8080", "", "listen address")
+	f.Duration("request-timeout", time.Second, "", "timeout")
+	f.Int("workers", 4, "", "workers")
+	f.StringSlice("peers", nil, "", "peers")
+	f.JSONVar(&testLimits{}, "limits", "", "limits")
+	f.String("ignored", "x", "", "ignored")
+	f.String("internal", "x", "", "internal")
+	if err := f.Parse([]string{"-peers=a,b", `-limits={"Burst":2}`}); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config{Other: "kept"}
+	if err := f.Unmarshal(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	expected := config{
+		HTTPAddr: ":8080",
+		Timeout:  time.Second,
+		Workers:  4,
+		Peers:    []string{"a", "b"},
+		Limits:   testLimits{Burst: 2},
+		Other:    "kept",
+	}
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("expected %+v, got %+v", expected, cfg)
+	}
+
+	var mismatched struct{ Workers string }
+	expectedErr := "flagfig: cannot unmarshal -workers of type int into field Workers of type string"
+	if err := f.Unmarshal(&mismatched); err == nil || err.Error() != expectedErr {
+		t.Errorf("expected %q, got %v", expectedErr, err)
+	}
+	if err := f.Unmarshal(cfg); err == nil {
+		t.Error("expected an error for a struct that is not a pointer")
+	}
+}
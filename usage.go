@@ -0,0 +1,187 @@
+package flagfig
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+func PrintDefaults() {
+	CommandLine.PrintDefaults()
+}
+
+// PrintDefaults prints the default values of all defined flags to Output(), in the same format as
+// flag.PrintDefaults, followed by where else each flag may be set: the environment variable and, when configuration
+// files are in use, the configuration file key:
+//
+//	-httpaddr string
+//	  	http address (default ":8080") [env MYAPP_HTTP_ADDR] [config httpaddr]
+//
+// Sensitive defaults are redacted. This is what -h and -help print, after the "Usage of" line.
+//
+// Flags placed in a group with WithGroup are listed after the others, under a heading for each group.
+// See SetUsageWidth and SetUsageColor to wrap and colorize the output
+func (f *FlagfigSet) PrintDefaults() {
+	width := f.usageWidth()
+	groups := f.usageGroups()
+	column := f.usageColumn(groups)
+	for _, group := range groups {
+		if len(group.Name) != 0 {
+			_, _ = fmt.Fprintf(f.Output(), "\n%s:\n", f.paint(group.Name, colorHeading))
+		}
+		for _, info := range group.Flags {
+			if width > 0 {
+				_, _ = fmt.Fprint(f.Output(), f.alignedUsage(info, column, width))
+			} else {
+				_, _ = fmt.Fprintln(f.Output(), f.usageLine(info))
+			}
+		}
+	}
+}
+
+// defaultUsage is the FlagSet's Usage function, unless the program replaces it
+func (f *FlagfigSet) defaultUsage() {
+	if f.usageTemplate != nil {
+		f.templateUsage()
+		return
+	}
+	if len(f.Name()) == 0 {
+		_, _ = fmt.Fprintf(f.Output(), "Usage:\n")
+	} else {
+		_, _ = fmt.Fprintf(f.Output(), "Usage of %s:\n", f.Name())
+	}
+	f.PrintDefaults()
+}
+
+// usageLine is the flag's entry in PrintDefaults, laid out like the flag package does
+func (f *FlagfigSet) usageLine(info FlagInfo) string {
+	sb := strings.Builder{}
+	sb.WriteString("  " + f.paint(f.usageNames(info), colorName))
+	if len(info.Type) != 0 {
+		sb.WriteString(" " + info.Type)
+	}
+	// Single letter flags without a type fit on one line, like the flag package does
+	if len(info.Name) == 1 && len(info.Type) == 0 && len(info.Short) == 0 {
+		sb.WriteString("\t")
+	} else {
+		sb.WriteString("\n    \t")
+	}
+	sb.WriteString(strings.Replace(info.Usage, "\n", "\n    \t", -1))
+	for _, word := range f.usageSuffix(info) {
+		sb.WriteString(" " + f.paint(word.text, word.color))
+	}
+	return sb.String()
+}
+
+// usageNames is the flag's names, as they are written on the command line
+func (f *FlagfigSet) usageNames(info FlagInfo) string {
+	return strings.Join(f.commandLineNames(info), ", ")
+}
+
+// commandLineNames is every name of the flag on the command line, with its dashes: its Short alias, its own name,
+// then its other aliases. Every generated usage, document and completion uses these, so they match what Parse accepts
+func (f *FlagfigSet) commandLineNames(info FlagInfo) []string {
+	names := bareNames(info)
+	for i, name := range names {
+		names[i] = f.dash(name)
+	}
+	return names
+}
+
+// bareNames is commandLineNames without the dashes
+func bareNames(info FlagInfo) []string {
+	names := make([]string, 0, len(info.Aliases)+2)
+	if len(info.Short) != 0 {
+		names = append(names, info.Short)
+	}
+	names = append(names, info.Name)
+	return append(names, info.Aliases...)
+}
+
+// dash is the flag name as it is written on the command line: with two dashes in GNU style, unless it is one letter
+func (f *FlagfigSet) dash(name string) string {
+	if f.gnuStyle && len(name) > 1 {
+		return "--" + name
+	}
+	return "-" + name
+}
+
+// usageSuffix is what follows the usage message: the default, the environment variable and the configuration key
+func (f *FlagfigSet) usageSuffix(info FlagInfo) (words []usageWord) {
+	if len(info.Default) != 0 {
+		if f.flagTypes[info.Name] == stringType && !info.Sensitive {
+			words = append(words, usageWord{text: fmt.Sprintf("(default %q)", info.Default), color: colorDefault})
+		} else {
+			words = append(words, usageWord{text: "(default " + info.Default + ")", color: colorDefault})
+		}
+	}
+	if len(info.Env) != 0 {
+		words = append(words, usageWord{text: "[env " + info.Env + "]", color: colorSource})
+	}
+	if len(info.ConfigKey) != 0 {
+		words = append(words, usageWord{text: "[config " + info.ConfigKey + "]", color: colorSource})
+	}
+	return
+}
+
+// unquoteUsage is flag.UnquoteUsage, with names for the types flagfig adds, which the flag package calls "value"
+func (f *FlagfigSet) unquoteUsage(fl *flag.Flag) (typeName, usage string) {
+	typeName, usage = flag.UnquoteUsage(fl)
+	if typeName != "value" || strings.Contains(fl.Usage, "`value`") {
+		return
+	}
+	switch f.flagTypes[fl.Name] {
+	case stringSliceType:
+		typeName = "strings"
+	case stringMapType:
+		typeName = "map"
+	case jsonType:
+		typeName = "json"
+	}
+	return
+}
+
+// defaultText is the flag's default as shown in usage, or blank if the default is the zero value
+func (f *FlagfigSet) defaultText(fl *flag.Flag) string {
+	if isZeroValue(fl) {
+		return ""
+	}
+	return f.redact(fl.Name, fl.DefValue)
+}
+
+// isZeroValue is true if the flag's default is the zero value of its type, which the flag package does not print
+func isZeroValue(fl *flag.Flag) bool {
+	value := fl.Value
+	if c, ok := value.(*collectingValue); ok {
+		value = c.Value
+	}
+	typ := reflect.TypeOf(value)
+	var z reflect.Value
+	if typ.Kind() == reflect.Ptr {
+		z = reflect.New(typ.Elem())
+	} else {
+		z = reflect.Zero(typ)
+	}
+	return fl.DefValue == z.Interface().(flag.Value).String()
+}
+
+// envKey is the environment variable that may set the flag, or blank if none may
+func (f *FlagfigSet) envKey(name string) string {
+	if !f.sourceAllowed(name, SourceEnv) {
+		return ""
+	}
+	return f.envNameFor(name)
+}
+
+// configKey is the configuration file key that may set the flag, or blank if there are no configuration files or the
+// flag may not be set by them, like the flags that control flagfig itself
+func (f *FlagfigSet) configKey(name string) string {
+	if len(f.configLayers) == 0 || !f.sourceAllowed(name, SourceFile) {
+		return ""
+	}
+	if f.internalFlags[name] {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,203 @@
+package flagfig
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// UsageWidthAuto makes SetUsageWidth use the width of the terminal that Output() writes to. If it is not a terminal,
+// the COLUMNS environment variable is used, or 80 columns if that is not set either
+const UsageWidthAuto = -1
+
+// maxUsageColumn is the widest the flag column of the aligned layout gets. Flags that are wider start their usage on
+// the next line
+const maxUsageColumn = 32
+
+func SetUsageWidth(width int) {
+	CommandLine.SetUsageWidth(width)
+}
+
+// SetUsageWidth makes PrintDefaults align the usage messages in a column and wrap them to width columns:
+//
+//	-config string    configuration file
+//	-httpaddr string  http address (default ":8080")
+//	                  [env MYAPP_HTTP_ADDR]
+//
+// Use UsageWidthAuto for the width of the terminal. The default, 0, keeps the layout of the flag package
+func (f *FlagfigSet) SetUsageWidth(width int) {
+	f.usageWidthSetting = width
+}
+
+// terminalSize is terminalWidth, which tests replace
+var terminalSize = terminalWidth
+
+// usageWidth is the width to wrap usage to, or 0 for the layout of the flag package
+func (f *FlagfigSet) usageWidth() int {
+	if f.usageWidthSetting != UsageWidthAuto {
+		return f.usageWidthSetting
+	}
+	if columns := terminalSize(f.Output()); columns > 0 {
+		return columns
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return 80
+}
+
+// ColorMode decides when usage output is colorized
+type ColorMode int
+
+const (
+	// ColorNever leaves usage output plain. This is the default
+	ColorNever ColorMode = iota
+	// ColorAuto colorizes usage output when it is written to a terminal, unless the NO_COLOR environment variable is set
+	ColorAuto
+	// ColorAlways colorizes usage output, even if it is piped to another program, such as less -R
+	ColorAlways
+)
+
+func SetUsageColor(mode ColorMode) {
+	CommandLine.SetUsageColor(mode)
+}
+
+// SetUsageColor chooses when the flag names, defaults, and environment and configuration keys in the usage output are
+// colorized
+func (f *FlagfigSet) SetUsageColor(mode ColorMode) {
+	f.usageColor = mode
+}
+
+// ANSI colors for the parts of the usage output
+const (
+	colorName    = "\x1b[1m"
+	colorDefault = "\x1b[33m"
+	colorSource  = "\x1b[2m"
+	colorHeading = "\x1b[1;4m"
+	colorReset   = "\x1b[0m"
+)
+
+// useColor is true if usage output should be colorized now
+func (f *FlagfigSet) useColor() bool {
+	switch f.usageColor {
+	case ColorAlways:
+		return true
+	case ColorAuto:
+		_, noColor := os.LookupEnv("NO_COLOR")
+		return !noColor && isTerminal(f.Output())
+	}
+	return false
+}
+
+// isTerminal is true if w is a terminal, rather than a file or a pipe
+func isTerminal(w interface{}) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// paint colors s, if usage output is colorized
+func (f *FlagfigSet) paint(s, color string) string {
+	if len(color) == 0 || !f.useColor() {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// usageWord is a piece of usage text that is never split when wrapping
+type usageWord struct {
+	text  string
+	color string
+}
+
+// usageHead is the flag and its type, which is the first column of the aligned layout
+func (f *FlagfigSet) usageHead(info FlagInfo) string {
+	head := "  " + f.usageNames(info)
+	if len(info.Type) != 0 {
+		head += " " + info.Type
+	}
+	return head
+}
+
+// usageColumn is where usage messages start in the aligned layout
+func (f *FlagfigSet) usageColumn(groups []UsageGroup) int {
+	column := 0
+	for _, group := range groups {
+		for _, info := range group.Flags {
+			if n := len(f.usageHead(info)) + 2; n > column && n <= maxUsageColumn {
+				column = n
+			}
+		}
+	}
+	if column == 0 {
+		column = maxUsageColumn
+	}
+	return column
+}
+
+// alignedUsage is the flag's entry in PrintDefaults when wrapping to width: the usage starts at column and each line
+// is at most width wide, unless a single word is wider
+func (f *FlagfigSet) alignedUsage(info FlagInfo, column, width int) string {
+	textWidth := width - column
+	if textWidth < 20 {
+		textWidth = 20
+	}
+	lines := make([][]usageWord, 0)
+	paragraphs := strings.Split(info.Usage, "\n")
+	for i, paragraph := range paragraphs {
+		words := make([]usageWord, 0)
+		for _, word := range strings.Fields(paragraph) {
+			words = append(words, usageWord{text: word})
+		}
+		if i == len(paragraphs)-1 {
+			words = append(words, f.usageSuffix(info)...)
+		}
+		lines = append(lines, wrapUsage(words, textWidth)...)
+	}
+	head := f.usageHead(info)
+	sb := strings.Builder{}
+	sb.WriteString("  " + f.paint(f.usageNames(info), colorName))
+	sb.WriteString(head[2+len(f.usageNames(info)):])
+	indent := strings.Repeat(" ", column)
+	if len(head) > column-2 {
+		sb.WriteString("\n" + indent)
+	} else {
+		sb.WriteString(strings.Repeat(" ", column-len(head)))
+	}
+	for i, line := range lines {
+		if i != 0 {
+			sb.WriteString("\n" + indent)
+		}
+		for j, word := range line {
+			if j != 0 {
+				sb.WriteString(" ")
+			}
+			sb.WriteString(f.paint(word.text, word.color))
+		}
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// wrapUsage splits words into lines that are at most width wide. A paragraph without words is one empty line
+func wrapUsage(words []usageWord, width int) [][]usageWord {
+	lines := [][]usageWord{{}}
+	length := 0
+	for _, word := range words {
+		last := len(lines) - 1
+		if length != 0 && length+1+len(word.text) > width {
+			lines = append(lines, []usageWord{})
+			last++
+			length = 0
+		}
+		if length != 0 {
+			length++
+		}
+		lines[last] = append(lines[last], word)
+		length += len(word.text)
+	}
+	return lines
+}
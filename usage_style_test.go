@@ -0,0 +1,69 @@
+package flagfig
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"testing"
+)
+
+func TestSetUsageWidth(t *testing.T) {
+	out := &bytes.Buffer{}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetOutput(out)
+	f.SetUsageWidth(50)
+	f.String("httpaddr", ":8080", "MYAPP_HTTP_ADDR", "the address to listen on for plain HTTP requests")
+	f.Bool("v", false, "", "verbose")
+	f.String("a-flag-with-a-very-long-name", "", "", "first line\nsecond line")
+	f.PrintDefaults()
+	expected := "  -a-flag-with-a-very-long-name string\n" +
+		"                    first line\n" +
+		"                    second line\n" +
+		"  -httpaddr string  the address to listen on for\n" +
+		"                    plain HTTP requests\n" +
+		"                    (default \":8080\")\n" +
+		"                    [env MYAPP_HTTP_ADDR]\n" +
+		"  -v                verbose\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestUsageWidthAuto(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetUsageWidth(UsageWidthAuto)
+	defer func() { terminalSize = terminalWidth }()
+	terminalSize = func(w interface{}) int { return 100 }
+	_ = os.Setenv("COLUMNS", "120")
+	if w := f.usageWidth(); w != 100 {
+		t.Error("expected the width of the terminal, got ", w)
+	}
+	terminalSize = func(w interface{}) int { return 0 }
+	defer func() { _ = os.Unsetenv("COLUMNS") }()
+	if w := f.usageWidth(); w != 120 {
+		t.Error("expected the width from COLUMNS, got ", w)
+	}
+	_ = os.Unsetenv("COLUMNS")
+	if w := f.usageWidth(); w != 80 {
+		t.Error("expected 80 columns without COLUMNS, got ", w)
+	}
+}
+
+func TestSetUsageColor(t *testing.T) {
+	out := &bytes.Buffer{}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetOutput(out)
+	f.Int("port", 8080, "", "port", WithGroup("Server"))
+	f.SetUsageColor(ColorAuto)
+	f.PrintDefaults()
+	if bytes.Contains(out.Bytes(), []byte("\x1b[")) {
+		t.Error("expected no color when not writing to a terminal, got ", out.String())
+	}
+	out.Reset()
+	f.SetUsageColor(ColorAlways)
+	f.PrintDefaults()
+	expected := "\n\x1b[1;4mServer\x1b[0m:\n  \x1b[1m-port\x1b[0m int\n    \tport \x1b[33m(default 8080)\x1b[0m\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
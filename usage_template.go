@@ -0,0 +1,213 @@
+package flagfig
+
+import (
+	"flag"
+	"fmt"
+	"text/template"
+)
+
+// FlagInfo describes a flag for documentation, such as a usage template
+type FlagInfo struct {
+	Name string
+	// Short is the single letter alias of the flag, see Short
+	Short string
+	// Aliases are the other command line names of the flag, see Alias
+	Aliases []string
+	// Type is the kind of value the flag takes, such as "string" or "duration", or a name taken from the usage
+	// message, as flag.UnquoteUsage does. It is blank for Bool flags
+	Type string
+	// Usage is the usage message, with the back quotes that named Type removed
+	Usage string
+	// Default is the default value, blank if it is the zero value of the type. Sensitive defaults are redacted
+	Default string
+	// Env is the environment variable that may set the flag, blank if none may
+	Env string
+	// ConfigKey is the configuration file key that may set the flag, blank if there are no configuration files or the
+	// flag may not be set by them
+	ConfigKey string
+	// Deprecated is the message given to Deprecate, or "deprecated" if it was blank. It is blank for current flags
+	Deprecated string
+	// Replacement is the flag that replaces a deprecated flag, if any
+	Replacement string
+	// Group is the heading the flag is listed under, see WithGroup. It is blank for flags without a group
+	Group string
+	// Enum lists the values the flag accepts, see Enum
+	Enum []string
+	// ValueHint is "file" for flags that take a file path, see FilePath, "dir" for flags that take a directory, see
+	// DirPath, and blank otherwise. Shell completions use it to complete the value
+	ValueHint string
+	// FilePatterns are the glob patterns given to FilePath, such as "*.json"
+	FilePatterns []string
+	Required     bool
+	Sensitive    bool
+	// Hidden flags are left out of usage, documentation and completions, see Hidden
+	Hidden bool
+	// Source and SourceDetail are where the value came from, as Origin reports them
+	Source       Source
+	SourceDetail string
+}
+
+// flagInfo collects what is known about the flag
+func (f *FlagfigSet) flagInfo(fl *flag.Flag) FlagInfo {
+	info := FlagInfo{
+		Name:      fl.Name,
+		Short:     f.shorts[fl.Name],
+		Aliases:   f.aliasNames[fl.Name],
+		Default:   f.defaultText(fl),
+		Env:       f.envKey(fl.Name),
+		ConfigKey: f.configKey(fl.Name),
+		Group:     f.flagGroups[fl.Name],
+		Enum:      f.enums[fl.Name],
+		Required:  f.required[fl.Name],
+		Sensitive: f.sensitive[fl.Name],
+		Hidden:    f.hidden[fl.Name],
+	}
+	info.Source, info.SourceDetail = f.Origin(fl.Name)
+	info.Type, info.Usage = f.unquoteUsage(fl)
+	hint := f.valueHintFor(fl.Name)
+	info.ValueHint, info.FilePatterns = hint.kind, hint.patterns
+	if d, ok := f.deprecations[fl.Name]; ok {
+		info.Deprecated = d.message
+		if len(info.Deprecated) == 0 {
+			info.Deprecated = "deprecated"
+		}
+		info.Replacement = d.replacement
+	}
+	return info
+}
+
+// FlagInfos describes every flag of the CommandLine
+func FlagInfos() []FlagInfo {
+	return CommandLine.FlagInfos()
+}
+
+// FlagInfos describes every flag but the Hidden ones, sorted by name, or in the order they were defined if
+// SetSortFlags(false) was called
+func (f *FlagfigSet) FlagInfos() []FlagInfo {
+	infos := make([]FlagInfo, 0)
+	if !f.unsorted {
+		f.FlagSet.VisitAll(func(fl *flag.Flag) {
+			if !f.hidden[fl.Name] {
+				infos = append(infos, f.flagInfo(fl))
+			}
+		})
+		return infos
+	}
+	seen := make(map[string]bool)
+	for _, name := range f.order {
+		if fl := f.FlagSet.Lookup(name); fl != nil && !seen[name] && !f.hidden[name] {
+			seen[name] = true
+			infos = append(infos, f.flagInfo(fl))
+		}
+	}
+	// Flags defined on the embedded FlagSet directly were not recorded, so they go last
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		if !seen[fl.Name] && !f.hidden[fl.Name] {
+			infos = append(infos, f.flagInfo(fl))
+		}
+	})
+	return infos
+}
+
+func LookupInfo(name string) (info FlagInfo, ok bool) {
+	return CommandLine.LookupInfo(name)
+}
+
+// LookupInfo is Lookup for tools that need to know what flagfig knows about the flag: its type, environment variable,
+// configuration key, default, group, whether it is hidden or deprecated and, after Parse, where its value came from.
+// It finds Hidden flags too. ok is false if there is no such flag
+func (f *FlagfigSet) LookupInfo(name string) (info FlagInfo, ok bool) {
+	fl := f.FlagSet.Lookup(name)
+	if fl == nil {
+		return FlagInfo{}, false
+	}
+	return f.flagInfo(fl), true
+}
+
+func SetSortFlags(sorted bool) {
+	CommandLine.SetSortFlags(sorted)
+}
+
+// SetSortFlags chooses whether usage output lists the flags sorted by name, which is the default and what the flag
+// package does, or in the order they were defined, which keeps flags that were organized in the code together.
+// Flags in groups, see WithGroup, are still listed under their group
+func (f *FlagfigSet) SetSortFlags(sorted bool) {
+	f.unsorted = !sorted
+}
+
+// UsageData is what a usage template is executed with
+type UsageData struct {
+	// Name is the name of the FlagfigSet, which is usually the program name
+	Name  string
+	Flags []FlagInfo
+	// Groups holds the same flags as Flags, split up by WithGroup. See UsageGroup
+	Groups []UsageGroup
+}
+
+func SetUsageTemplate(text string) error {
+	return CommandLine.SetUsageTemplate(text)
+}
+
+// SetUsageTemplate replaces the usage message, printed for -h or a bad flag, with a text/template executed with
+// UsageData. This lets an application brand or restructure its help:
+//
+//	err := flags.SetUsageTemplate(`mytool: does things
+//
+//	Options:
+//	{{range .Flags}}{{if not .Deprecated}}  --{{.Name}}{{if .Env}} (${{.Env}}){{end}}
+//	      {{.Usage}}
+//	{{end}}{{end}}`)
+//
+// The error is from parsing the template. PrintDefaults is not affected
+func (f *FlagfigSet) SetUsageTemplate(text string) (err error) {
+	t, err := template.New(f.Name()).Parse(text)
+	if err != nil {
+		return
+	}
+	f.usageTemplate = t
+	return
+}
+
+// templateUsage prints the usage message from the template set by SetUsageTemplate
+func (f *FlagfigSet) templateUsage() {
+	err := f.usageTemplate.Execute(f.Output(), UsageData{Name: f.Name(), Flags: f.FlagInfos(), Groups: f.usageGroups()})
+	if err != nil {
+		_, _ = fmt.Fprintf(f.Output(), "\nflagfig: usage template: %v\n", err)
+	}
+}
+
+// UsageGroup is a heading in the usage message and the flags listed under it
+type UsageGroup struct {
+	// Name is the group given to WithGroup. The first UsageGroup has a blank Name and holds the flags without a group,
+	// if there are any
+	Name  string
+	Flags []FlagInfo
+}
+
+// usageGroups splits the flags up by group. Flags without a group come first, then the groups in the order they were
+// first used
+func (f *FlagfigSet) usageGroups() []UsageGroup {
+	byName := make(map[string][]FlagInfo)
+	for _, info := range f.FlagInfos() {
+		byName[info.Group] = append(byName[info.Group], info)
+	}
+	groups := make([]UsageGroup, 0, len(f.groupOrder)+1)
+	for _, name := range append([]string{""}, f.groupOrder...) {
+		if flags, ok := byName[name]; ok {
+			groups = append(groups, UsageGroup{Name: name, Flags: flags})
+		}
+	}
+	return groups
+}
+
+// WithGroup lists the flag under a heading in the usage message, such as "TLS", so that programs with many flags
+// have organized help. Groups are listed in the order they are first used, after the flags without a group
+func WithGroup(group string) FlagOption {
+	return func(f *FlagfigSet, name string) {
+		if _, ok := f.groupSeen[group]; !ok {
+			f.groupSeen[group] = true
+			f.groupOrder = append(f.groupOrder, group)
+		}
+		f.flagGroups[name] = group
+	}
+}
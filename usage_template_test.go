@@ -0,0 +1,70 @@
+package flagfig
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSetUsageTemplate(t *testing.T) {
+	out := &bytes.Buffer{}
+	f := NewFlagfigSet("mytool", flag.ContinueOnError)
+	f.SetOutput(out)
+	f.String("host", "localhost", "MYTOOL_HOST", "the `address` to connect to")
+	f.String("old-host", "", "", "the address to connect to")
+	f.Deprecate("old-host", "", "host")
+	err := f.SetUsageTemplate(`{{.Name}} options:
+{{range .Flags}}{{if not .Deprecated}}  --{{.Name}} {{.Type}}{{if .Env}} (${{.Env}}){{end}}: {{.Usage}} [{{.Default}}]
+{{end}}{{end}}`)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if err = f.Parse([]string{"-help"}); err != flag.ErrHelp {
+		t.Fatal("expected flag.ErrHelp, got ", err)
+	}
+	expected := "mytool options:\n  --host address ($MYTOOL_HOST): the address to connect to [localhost]\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestSetUsageTemplateError(t *testing.T) {
+	f := NewFlagfigSet("mytool", flag.ContinueOnError)
+	if err := f.SetUsageTemplate("{{.Name"); err == nil {
+		t.Error("expected an error for a broken template")
+	}
+}
+
+func TestLookupInfo(t *testing.T) {
+	_ = os.Setenv("ENV_INFO_HOST", "example.com")
+	defer func() { _ = os.Unsetenv("ENV_INFO_HOST") }()
+	out := &bytes.Buffer{}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetOutput(out)
+	f.String("host", "localhost", "ENV_INFO_HOST", "host", WithGroup("Network"))
+	f.Bool("debug-dump", false, "", "dump internals", Hidden())
+	if err := f.Parse([]string{"-debug-dump"}); err != nil {
+		t.Fatal(err)
+	}
+	info, ok := f.LookupInfo("host")
+	expected := FlagInfo{Name: "host", Type: "string", Usage: "host", Default: "localhost", Env: "ENV_INFO_HOST", Group: "Network", Source: SourceEnv, SourceDetail: "ENV_INFO_HOST"}
+	if !ok || !reflect.DeepEqual(info, expected) {
+		t.Errorf("expected %+v, got %+v", expected, info)
+	}
+	if info, ok = f.LookupInfo("debug-dump"); !ok || !info.Hidden || info.Source != SourceFlag {
+		t.Errorf("expected the hidden flag, set on the command line, got %+v", info)
+	}
+	if _, ok = f.LookupInfo("missing"); ok {
+		t.Error("expected no info for a missing flag")
+	}
+	if len(f.FlagInfos()) != 1 {
+		t.Error("expected the hidden flag to be left out of FlagInfos, got ", f.FlagInfos())
+	}
+	f.PrintDefaults()
+	if strings.Contains(out.String(), "debug-dump") {
+		t.Error("expected the hidden flag to be left out of the usage, got ", out.String())
+	}
+}
@@ -0,0 +1,91 @@
+package flagfig
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+)
+
+func TestPrintDefaults(t *testing.T) {
+	out := &bytes.Buffer{}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetOutput(out)
+	f.AddConfigFile("config", "configuration file")
+	f.String("httpaddr", ":8080", "MYAPP_HTTP_ADDR", "http address")
+	f.Bool("v", false, "", "verbose")
+	f.StringSlice("peers", []string{"a", "b"}, "", "peers", FromSources(SourceFlag))
+	f.String("password", "hunter2", "MYAPP_PASSWORD", "the `secret`", Sensitive())
+	f.PrintDefaults()
+	expected := "  -config string\n" +
+		"    \tconfiguration file\n" +
+		"  -httpaddr string\n" +
+		"    \thttp address (default \":8080\") [env MYAPP_HTTP_ADDR] [config httpaddr]\n" +
+		"  -password secret\n" +
+		"    \tthe secret (default ****) [env MYAPP_PASSWORD] [config password]\n" +
+		"  -peers strings\n" +
+		"    \tpeers (default a,b)\n" +
+		"  -v\tverbose [config v]\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestHelpShowsEnv(t *testing.T) {
+	out := &bytes.Buffer{}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetOutput(out)
+	f.AutomaticEnv("MYAPP")
+	f.Int("port", 0, "", "port")
+	err := f.Parse([]string{"-h"})
+	if err != flag.ErrHelp {
+		t.Fatal("expected flag.ErrHelp, got ", err)
+	}
+	expected := "Usage of test:\n  -port int\n    \tport [env MYAPP_PORT]\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestPrintDefaultsGroups(t *testing.T) {
+	out := &bytes.Buffer{}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetOutput(out)
+	f.String("tls-cert", "", "", "certificate", WithGroup("TLS"))
+	f.String("db-host", "", "", "database host", WithGroup("Database"))
+	f.String("tls-key", "", "", "key", WithGroup("TLS"))
+	f.Bool("v", false, "", "verbose")
+	f.PrintDefaults()
+	expected := "  -v\tverbose\n" +
+		"\nTLS:\n" +
+		"  -tls-cert string\n    \tcertificate\n" +
+		"  -tls-key string\n    \tkey\n" +
+		"\nDatabase:\n" +
+		"  -db-host string\n    \tdatabase host\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestSetSortFlags(t *testing.T) {
+	out := &bytes.Buffer{}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetOutput(out)
+	f.SetSortFlags(false)
+	f.String("tls-key", "", "", "key", WithGroup("TLS"))
+	f.String("host", "", "", "host")
+	f.String("tls-cert", "", "", "certificate", WithGroup("TLS"))
+	f.AddValidateFlag("validate", "validate the configuration")
+	f.FlagSet.Bool("direct", false, "defined on the flag.FlagSet")
+	f.Int("port", 0, "", "port")
+	f.PrintDefaults()
+	expected := "  -host string\n    \thost\n" +
+		"  -validate\n    \tvalidate the configuration\n" +
+		"  -port int\n    \tport\n" +
+		"  -direct\n    \tdefined on the flag.FlagSet\n" +
+		"\nTLS:\n" +
+		"  -tls-key string\n    \tkey\n" +
+		"  -tls-cert string\n    \tcertificate\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
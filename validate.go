@@ -0,0 +1,389 @@
+package flagfig
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Required marks the flag as required, see MarkRequired
+func Required() FlagOption {
+	return func(f *FlagfigSet, name string) {
+		f.required[name] = true
+	}
+}
+
+// MarkRequired marks a CommandLine flag as required
+func MarkRequired(name string) {
+	CommandLine.MarkRequired(name)
+}
+
+// MarkRequired marks the flag as required: Parse fails unless the command line, the environment or a configuration
+// file provides a value for it. The default value does not count. All of the missing flags are listed in the error
+func (f *FlagfigSet) MarkRequired(name string) {
+	f.required[name] = true
+}
+
+// Min sets the smallest value allowed for a numeric flag: Int, Int64, Uint, Uint64 or Float64. It panics if the flag
+// is not numeric
+func Min(min float64) FlagOption {
+	return func(f *FlagfigSet, name string) {
+		f.mustBeNumeric(name, "Min")
+		f.minimums[name] = min
+	}
+}
+
+// Max sets the largest value allowed for a numeric flag: Int, Int64, Uint, Uint64 or Float64. It panics if the flag
+// is not numeric
+func Max(max float64) FlagOption {
+	return func(f *FlagfigSet, name string) {
+		f.mustBeNumeric(name, "Max")
+		f.maximums[name] = max
+	}
+}
+
+// MinDuration sets the shortest value allowed for a Duration flag
+func MinDuration(min time.Duration) FlagOption {
+	return Min(float64(min))
+}
+
+// MaxDuration sets the longest value allowed for a Duration flag
+func MaxDuration(max time.Duration) FlagOption {
+	return Max(float64(max))
+}
+
+// mustBeNumeric panics unless the flag has a number for a value, as a range on anything else would never be checked
+func (f *FlagfigSet) mustBeNumeric(name, option string) {
+	if getter, ok := f.FlagSet.Lookup(name).Value.(flag.Getter); ok {
+		switch getter.Get().(type) {
+		case int, int64, uint, uint64, float64, time.Duration:
+			return
+		}
+	}
+	panic(fmt.Sprintf("flagfig: %s needs a numeric flag, but -%s is not", option, name))
+}
+
+// checkRange reports a problem if the flag's value is outside of its Min and Max. Integers are compared as integers,
+// as float64 cannot hold every one of them above 2^53
+func (f *FlagfigSet) checkRange(fl *flag.Flag) (problem string) {
+	min, hasMin := f.minimums[fl.Name]
+	max, hasMax := f.maximums[fl.Name]
+	if !hasMin && !hasMax {
+		return ""
+	}
+	getter, ok := fl.Value.(flag.Getter)
+	if !ok {
+		return ""
+	}
+	var below, above bool
+	switch n := getter.Get().(type) {
+	case int:
+		below, above = intOutside(int64(n), min, max)
+	case int64:
+		below, above = intOutside(n, min, max)
+	case time.Duration:
+		below, above = intOutside(int64(n), min, max)
+	case uint:
+		below, above = uintOutside(uint64(n), min, max)
+	case uint64:
+		below, above = uintOutside(n, min, max)
+	case float64:
+		below, above = n < min, n > max
+	default:
+		return ""
+	}
+	format := func(n float64) string {
+		switch f.flagTypes[fl.Name] {
+		case durationType:
+			return time.Duration(n).String()
+		case floatType:
+			return fmt.Sprint(n)
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	if hasMin && below {
+		return fmt.Sprintf("-%s is %s from %s, but must be at least %s", fl.Name, f.displayValue(fl), f.describeOrigin(fl.Name), format(min))
+	}
+	if hasMax && above {
+		return fmt.Sprintf("-%s is %s from %s, but must be at most %s", fl.Name, f.displayValue(fl), f.describeOrigin(fl.Name), format(max))
+	}
+	return ""
+}
+
+// twoTo63 and twoTo64 are the first float64 values past the int64 and uint64 ranges
+const (
+	twoTo63 = float64(1 << 63)
+	twoTo64 = float64(1<<63) * 2
+)
+
+// intOutside is whether v is below min or above max. The bounds are rounded toward the range, as v is an integer
+func intOutside(v int64, min, max float64) (below, above bool) {
+	switch {
+	case min >= twoTo63:
+		below = true
+	case min > -twoTo63:
+		below = v < int64(math.Ceil(min))
+	}
+	switch {
+	case max < -twoTo63:
+		above = true
+	case max < twoTo63:
+		above = v > int64(math.Floor(max))
+	}
+	return
+}
+
+// uintOutside is intOutside for unsigned integers
+func uintOutside(v uint64, min, max float64) (below, above bool) {
+	switch {
+	case min >= twoTo64:
+		below = true
+	case min > 0:
+		below = v < uint64(math.Ceil(min))
+	}
+	switch {
+	case max < 0:
+		above = true
+	case max < twoTo64:
+		above = v > uint64(math.Floor(max))
+	}
+	return
+}
+
+// NotEmpty requires the flag's resolved value to be something other than blank. Unlike Required, the default counts,
+// but a source setting the flag to "" or to only spaces does not:
+//
+//	dsn := flags.String("dsn", "", "MYAPP_DSN", "database connection string", flagfig.NotEmpty())
+//
+// It is meant for String flags, but also works for StringSlice and StringMap, which must then have at least one item
+func NotEmpty() FlagOption {
+	return func(f *FlagfigSet, name string) {
+		f.notEmpty[name] = true
+	}
+}
+
+// checkNotEmpty reports a problem if the flag is NotEmpty but its value is blank
+func (f *FlagfigSet) checkNotEmpty(fl *flag.Flag) (problem string) {
+	if !f.notEmpty[fl.Name] || len(strings.TrimSpace(fl.Value.String())) != 0 {
+		return ""
+	}
+	if !f.IsSet(fl.Name) {
+		return fmt.Sprintf("-%s may not be blank", fl.Name)
+	}
+	return fmt.Sprintf("-%s is blank from %s, but may not be", fl.Name, f.describeOrigin(fl.Name))
+}
+
+// pattern is a regular expression string values must match, and a description of it for people
+type pattern struct {
+	re          *regexp.Regexp
+	description string
+}
+
+// Pattern requires a String flag's value, or each item of a StringSlice, to match re. The description explains the
+// expected format in the error message, such as "a lowercase name, like my-app":
+//
+//	flagfig.Pattern(regexp.MustCompile(`^[a-z][a-z0-9-]*$`), "a lowercase name, like my-app")
+//
+// A value that was never set is still checked, so make sure the default matches, or is accepted by re.
+func Pattern(re *regexp.Regexp, description string) FlagOption {
+	return func(f *FlagfigSet, name string) {
+		f.patterns[name] = pattern{re: re, description: description}
+	}
+}
+
+// checkPattern reports a problem if the flag's value does not match its Pattern
+func (f *FlagfigSet) checkPattern(fl *flag.Flag) (problem string) {
+	p, ok := f.patterns[fl.Name]
+	if !ok {
+		return ""
+	}
+	values := []string{fl.Value.String()}
+	if list, ok := fl.Value.(*stringSliceValue); ok {
+		values = *list.p
+	}
+	for _, v := range values {
+		if !p.re.MatchString(v) {
+			return fmt.Sprintf("-%s is %q from %s, but must be %s", fl.Name, f.redact(fl.Name, v), f.describeOrigin(fl.Name), p.description)
+		}
+	}
+	return ""
+}
+
+// Enum limits a String flag, or each item of a StringSlice, to the listed values. The values are also offered by the
+// generated shell completions
+func Enum(values ...string) FlagOption {
+	return func(f *FlagfigSet, name string) {
+		f.enums[name] = values
+	}
+}
+
+// checkEnum reports a problem if the flag's value is not one of its Enum values
+func (f *FlagfigSet) checkEnum(fl *flag.Flag) (problem string) {
+	allowed, ok := f.enums[fl.Name]
+	if !ok {
+		return ""
+	}
+	values := []string{fl.Value.String()}
+	if list, ok := fl.Value.(*stringSliceValue); ok {
+		values = *list.p
+	}
+	for _, v := range values {
+		found := false
+		for _, a := range allowed {
+			found = found || a == v
+		}
+		if !found {
+			return fmt.Sprintf("-%s is %q from %s, but must be one of %s", fl.Name, f.redact(fl.Name, v), f.describeOrigin(fl.Name), strings.Join(allowed, ", "))
+		}
+	}
+	return ""
+}
+
+// flagGroup is a set of flags that are validated together, such as MarkRequiredTogether
+type flagGroup struct {
+	names []string
+	check func(f *FlagfigSet, names []string) (problem string)
+}
+
+// MarkRequiredTogether marks CommandLine flags as required together
+func MarkRequiredTogether(names ...string) {
+	CommandLine.MarkRequiredTogether(names...)
+}
+
+// MarkRequiredTogether requires that either all or none of the flags are set. This catches partial configuration,
+// such as a TLS certificate without its key, at startup:
+//
+//	flags.MarkRequiredTogether("tls-cert", "tls-key")
+func (f *FlagfigSet) MarkRequiredTogether(names ...string) {
+	f.groups = append(f.groups, flagGroup{names: names, check: checkRequiredTogether})
+}
+
+func checkRequiredTogether(f *FlagfigSet, names []string) (problem string) {
+	set, missing := make([]string, 0), make([]string, 0)
+	for _, name := range names {
+		if f.IsSet(name) {
+			set = append(set, "-"+name+" by "+f.describeOrigin(name))
+		} else {
+			missing = append(missing, "-"+name)
+		}
+	}
+	if len(set) == 0 || len(missing) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("flags %s must be set together, missing: %s, set: %s", strings.Join(dashed(names), ", "),
+		strings.Join(missing, ", "), strings.Join(set, ", "))
+}
+
+// MarkOneRequired marks CommandLine flags as a group where at least one is required
+func MarkOneRequired(names ...string) {
+	CommandLine.MarkOneRequired(names...)
+}
+
+// MarkOneRequired requires that at least one of the flags is set, for commands that accept their input in more than
+// one way:
+//
+//	flags.MarkOneRequired("config-url", "config-file")
+func (f *FlagfigSet) MarkOneRequired(names ...string) {
+	f.groups = append(f.groups, flagGroup{names: names, check: checkOneRequired})
+}
+
+func checkOneRequired(f *FlagfigSet, names []string) (problem string) {
+	for _, name := range names {
+		if f.IsSet(name) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("at least one of %s is required", strings.Join(dashed(names), ", "))
+}
+
+// MarkRequiredIf marks a CommandLine flag as conditionally required
+func MarkRequiredIf(name, other, value string) {
+	CommandLine.MarkRequiredIf(name, other, value)
+}
+
+// MarkRequiredIf requires the flag name to be set whenever the flag other has the given value, as written on the
+// command line. For example, the key is only needed when TLS is on:
+//
+//	flags.MarkRequiredIf("tls-key", "tls", "true")
+func (f *FlagfigSet) MarkRequiredIf(name, other, value string) {
+	f.groups = append(f.groups, flagGroup{
+		names: []string{name, other},
+		check: func(f *FlagfigSet, names []string) (problem string) {
+			fl := f.FlagSet.Lookup(other)
+			if fl == nil || fl.Value.String() != value || f.IsSet(name) {
+				return ""
+			}
+			return fmt.Sprintf("-%s is required when -%s is %s, as set by %s", name, other, f.redact(other, value), f.describeOrigin(other))
+		},
+	})
+}
+
+// dashed puts a dash in front of each flag name, for messages
+func dashed(names []string) []string {
+	out := make([]string, len(names))
+	for i, name := range names {
+		out[i] = "-" + name
+	}
+	return out
+}
+
+// validate checks the resolved values against the rules declared for the flags
+func (f *FlagfigSet) validate() (err error) {
+	problems := make([]string, 0)
+	missing := make([]string, 0)
+	for name := range f.required {
+		if !f.IsSet(name) {
+			missing = append(missing, "-"+name)
+		}
+	}
+	if len(missing) != 0 {
+		sort.Strings(missing)
+		problems = append(problems, fmt.Sprintf("missing required flags: %s", strings.Join(missing, ", ")))
+	}
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		for _, problem := range []string{f.checkNotEmpty(fl), f.checkRange(fl), f.checkPattern(fl), f.checkEnum(fl)} {
+			if len(problem) != 0 {
+				problems = append(problems, problem)
+			}
+		}
+	})
+	for _, group := range f.groups {
+		if problem := group.check(f, group.names); len(problem) != 0 {
+			problems = append(problems, problem)
+		}
+	}
+	if len(problems) != 0 {
+		return &ValidationError{Problems: problems, Missing: missing}
+	}
+	return nil
+}
+
+// ValidationError lists every problem found while validating the resolved flag values, so they can all be fixed at once
+type ValidationError struct {
+	Problems []string
+	// Missing lists the Required flags that were not set, with their dashes, as in the problem about them
+	Missing []string
+}
+
+// Is makes errors.Is(err, ErrMissingRequired) true when Required flags were not set
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrMissingRequired && len(e.Missing) != 0
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return e.Problems[0]
+	}
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("%d problems with the configuration:", len(e.Problems)))
+	for _, problem := range e.Problems {
+		sb.WriteString("\n  ")
+		sb.WriteString(problem)
+	}
+	return sb.String()
+}
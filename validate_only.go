@@ -0,0 +1,75 @@
+package flagfig
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrValidated is returned by Parse when only validation was requested, with AddValidateFlag or SetValidateOnly, and
+// the configuration is valid. Like flag.ErrHelp, it means the program should stop without doing any work
+var ErrValidated = errors.New("flagfig: configuration validated")
+
+// AddValidateFlag adds a validation flag to the CommandLine
+func AddValidateFlag(name, usage string) *bool {
+	return CommandLine.AddValidateFlag(name, usage)
+}
+
+// AddValidateFlag adds a boolean flag, such as -validate-config, that turns on validate-only mode (see SetValidateOnly)
+// when given on the command line. This lets CI check a deployment's configuration with the real binary:
+//
+//	myapp -validate-config -config=deploy/production.json
+func (f *FlagfigSet) AddValidateFlag(name, usage string) *bool {
+	p := new(bool)
+	f.validateFlag = p
+	f.internalFlags[name] = true
+	f.order = append(f.order, name)
+	f.FlagSet.BoolVar(p, name, false, usage)
+	return p
+}
+
+// SetValidateOnly turns validate-only mode on for the CommandLine
+func SetValidateOnly(validateOnly bool) {
+	CommandLine.SetValidateOnly(validateOnly)
+}
+
+// SetValidateOnly turns on validate-only mode. Parse resolves and validates every flag as usual, and if that fails,
+// returns the error as usual. If the configuration is valid, it prints the effective configuration, with Sensitive
+// values redacted, to Output() and stops, following the error handling of the set: ExitOnError exits with status 0,
+// PanicOnError panics with ErrValidated, and ContinueOnError returns ErrValidated.
+func (f *FlagfigSet) SetValidateOnly(validateOnly bool) {
+	f.validateOnly = validateOnly
+}
+
+func (f *FlagfigSet) validateOnlyRequested() bool {
+	return f.validateOnly || (f.validateFlag != nil && *f.validateFlag)
+}
+
+// finishValidateOnly prints the effective configuration and stops, as described by SetValidateOnly
+func (f *FlagfigSet) finishValidateOnly() error {
+	_, _ = fmt.Fprintln(f.Output(), "configuration is valid:")
+	f.writeEffective(f.Output())
+	return f.stop(ErrValidated)
+}
+
+// stop ends a run that only printed information, following the error handling of the set: ExitOnError exits with
+// status 0, PanicOnError panics with reason, and ContinueOnError returns reason
+func (f *FlagfigSet) stop(reason error) error {
+	switch f.ErrorHandling() {
+	case flag.ExitOnError:
+		os.Exit(0)
+	case flag.PanicOnError:
+		panic(reason)
+	}
+	return reason
+}
+
+// writeEffective writes each flag's resolved value, with Sensitive values redacted, and where it came from
+func (f *FlagfigSet) writeEffective(w io.Writer) {
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		source, detail := f.Origin(fl.Name)
+		_, _ = fmt.Fprintf(w, "  -%s=%s (%s%s)\n", fl.Name, f.displayValue(fl), source, traceDetail(detail))
+	})
+}
@@ -0,0 +1,67 @@
+package flagfig
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAddValidateFlag(t *testing.T) {
+	_ = os.Setenv("ENV_VALIDATE_PASSWORD", "hunter2")
+	defer func() { _ = os.Unsetenv("ENV_VALIDATE_PASSWORD") }()
+	out := &bytes.Buffer{}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetOutput(out)
+	f.AddValidateFlag("validate-config", "validate the configuration and exit")
+	f.String("host", "localhost", "", "host")
+	f.String("password", "", "ENV_VALIDATE_PASSWORD", "password", Sensitive())
+	err := f.Parse([]string{"-validate-config"})
+	if err != ErrValidated {
+		t.Fatal("expected ErrValidated, got ", err)
+	}
+	expected := "configuration is valid:\n" +
+		"  -host=localhost (default)\n" +
+		"  -password=**** (env (ENV_VALIDATE_PASSWORD))\n" +
+		"  -validate-config=true (flag)\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestValidateOnlyReportsProblems(t *testing.T) {
+	out := &bytes.Buffer{}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetOutput(out)
+	f.SetValidateOnly(true)
+	f.String("host", "", "", "host", Required())
+	err := f.Parse([]string{})
+	if err == nil || err == ErrValidated {
+		t.Fatal("expected the missing flag to be reported, got ", err)
+	}
+	if strings.Contains(out.String(), "configuration is valid") {
+		t.Error("the configuration should not be printed when it is invalid, got ", out.String())
+	}
+}
+
+func TestValidateFlagNotSetByConfigFile(t *testing.T) {
+	tmpFileName, tfremove := testTempFile(t)
+	defer tfremove()
+	if err := ioutil.WriteFile(tmpFileName, []byte(`{"validate-config":true,"host":"example.com"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	f.AddConfigFile("config", "config file")
+	validate := f.AddValidateFlag("validate-config", "validate the configuration and exit")
+	f.String("host", "localhost", "", "host")
+	err := f.Parse([]string{"-config", tmpFileName})
+	if err == ErrValidated || *validate {
+		t.Fatal("a configuration file should not turn on validate-only mode")
+	}
+	if err == nil || !strings.Contains(err.Error(), "-validate-config may not be set by configuration file") {
+		t.Error("expected the key to be rejected, got ", err)
+	}
+}
@@ -0,0 +1,269 @@
+package flagfig
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestRequired(t *testing.T) {
+	_ = os.Setenv("ENV_REQUIRED_HOST", "localhost")
+	defer func() { _ = os.Unsetenv("ENV_REQUIRED_HOST") }()
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.String("host", "", "ENV_REQUIRED_HOST", "host", Required())
+	f.Int("port", 8080, "", "port", Required())
+	f.String("user", "", "", "user")
+	f.String("password", "", "", "password")
+	f.MarkRequired("user")
+	f.MarkRequired("password")
+	err := f.Parse([]string{"-password=secret"})
+	if err == nil {
+		t.Fatal("expected an error for the missing flags")
+	}
+	expected := "missing required flags: -port, -user"
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err)
+	}
+}
+
+func TestMinMax(t *testing.T) {
+	_ = os.Setenv("ENV_RANGE_PORT", "70000")
+	defer func() { _ = os.Unsetenv("ENV_RANGE_PORT") }()
+	cases := map[string]struct {
+		args     []string
+		expected string
+	}{
+		"env too large": {
+			args:     []string{},
+			expected: "-port is 70000 from environment variable ENV_RANGE_PORT, but must be at most 65535",
+		},
+		"duration too short": {
+			args:     []string{"-port=80", "-timeout=1ms"},
+			expected: "-timeout is 1ms from the command line, but must be at least 1s",
+		},
+		"float too small": {
+			args:     []string{"-port=80", "-ratio=-0.5"},
+			expected: "-ratio is -0.5 from the command line, but must be at least 0",
+		},
+		"in range": {
+			args: []string{"-port=80", "-timeout=1m", "-ratio=1"},
+		},
+	}
+	for caseName, c := range cases {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.Int("port", 8080, "ENV_RANGE_PORT", "port", Min(1), Max(65535))
+		f.Duration("timeout", time.Minute, "", "timeout", MinDuration(time.Second), MaxDuration(time.Hour))
+		f.Float64("ratio", 0.5, "", "ratio", Min(0), Max(1))
+		err := f.Parse(c.args)
+		if len(c.expected) == 0 {
+			if err != nil {
+				t.Errorf("case %s: unexpected error: %s", caseName, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != c.expected {
+			t.Errorf("case %s: expected %q, got %v", caseName, c.expected, err)
+		}
+	}
+}
+
+func TestMinMaxLargeIntegers(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.Int64("id", 0, "", "id", Max(1<<53))
+	f.Uint64("size", 0, "", "size", Min(1<<53))
+	// Both are 2^53 as float64, so only an integer comparison finds them out of range
+	err := f.Parse([]string{"-id=9007199254740993", "-size=9007199254740991"})
+	expected := "2 problems with the configuration:\n" +
+		"  -id is 9007199254740993 from the command line, but must be at most 9007199254740992\n" +
+		"  -size is 9007199254740991 from the command line, but must be at least 9007199254740992"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}
+
+func TestMinMaxNotNumeric(t *testing.T) {
+	cases := map[string]FlagOption{
+		"Min": Min(1),
+		"Max": Max(1),
+	}
+	for caseName, opt := range cases {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("case %s: expected a panic for a String flag", caseName)
+				}
+			}()
+			f := NewFlagfigSet("test", flag.ContinueOnError)
+			f.String("host", "", "", "host", opt)
+		}()
+	}
+}
+
+func TestPattern(t *testing.T) {
+	name := regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.String("app", "my-app", "", "app", Pattern(name, "a lowercase name, like my-app"))
+	f.StringSlice("peers", nil, "", "peers", Pattern(name, "a lowercase host name"))
+	if err := f.Parse([]string{"-peers=a,b-2"}); err != nil {
+		t.Error("unexpected error: ", err)
+	}
+
+	f = NewFlagfigSet("test", flag.ContinueOnError)
+	f.String("app", "my-app", "", "app", Pattern(name, "a lowercase name, like my-app"))
+	err := f.Parse([]string{"-app=My App"})
+	expected := `-app is "My App" from the command line, but must be a lowercase name, like my-app`
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}
+
+func TestNotEmpty(t *testing.T) {
+	cases := map[string]struct {
+		args     []string
+		expected string
+	}{
+		"default is blank": {
+			args:     []string{},
+			expected: "-dsn may not be blank",
+		},
+		"set to spaces": {
+			args:     []string{"-dsn=  "},
+			expected: "-dsn is blank from the command line, but may not be",
+		},
+		"set": {
+			args: []string{"-dsn=postgres://db"},
+		},
+	}
+	for caseName, c := range cases {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.String("dsn", "", "", "dsn", NotEmpty())
+		err := f.Parse(c.args)
+		if len(c.expected) == 0 {
+			if err != nil {
+				t.Errorf("case %s: unexpected error: %s", caseName, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != c.expected {
+			t.Errorf("case %s: expected %q, got %v", caseName, c.expected, err)
+		}
+	}
+}
+
+func TestEnum(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.String("level", "info", "", "log level", Enum("debug", "info"))
+	if err := f.Parse([]string{"-level=debug"}); err != nil {
+		t.Error("unexpected error: ", err)
+	}
+	f = NewFlagfigSet("test", flag.ContinueOnError)
+	f.String("level", "info", "", "log level", Enum("debug", "info"))
+	err := f.Parse([]string{"-level=loud"})
+	expected := `-level is "loud" from the command line, but must be one of debug, info`
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}
+
+func TestMarkRequiredTogether(t *testing.T) {
+	cases := map[string]struct {
+		args     []string
+		expected string
+	}{
+		"none": {args: []string{}},
+		"all":  {args: []string{"-tls-cert=c", "-tls-key=k"}},
+		"cert only": {
+			args:     []string{"-tls-cert=c"},
+			expected: "flags -tls-cert, -tls-key must be set together, missing: -tls-key, set: -tls-cert by the command line",
+		},
+	}
+	for caseName, c := range cases {
+		f := NewFlagfigSet("test", flag.ContinueOnError)
+		f.String("tls-cert", "", "", "cert")
+		f.String("tls-key", "", "", "key")
+		f.MarkRequiredTogether("tls-cert", "tls-key")
+		err := f.Parse(c.args)
+		if len(c.expected) == 0 {
+			if err != nil {
+				t.Errorf("case %s: unexpected error: %s", caseName, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != c.expected {
+			t.Errorf("case %s: expected %q, got %v", caseName, c.expected, err)
+		}
+	}
+}
+
+func TestMarkOneRequired(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.String("config-url", "", "", "url")
+	f.String("config-file", "", "", "file")
+	f.MarkOneRequired("config-url", "config-file")
+	err := f.Parse([]string{})
+	expected := "at least one of -config-url, -config-file is required"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+
+	f = NewFlagfigSet("test", flag.ContinueOnError)
+	f.String("config-url", "", "", "url")
+	f.String("config-file", "", "", "file")
+	f.MarkOneRequired("config-url", "config-file")
+	if err = f.Parse([]string{"-config-file=app.json"}); err != nil {
+		t.Error("unexpected error: ", err)
+	}
+}
+
+func TestMarkRequiredIf(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.Bool("tls", false, "", "tls")
+	f.String("tls-key", "", "", "key")
+	f.String("tls-cert", "", "", "cert")
+	f.MarkRequiredIf("tls-key", "tls", "true")
+	f.MarkRequiredIf("tls-cert", "tls", "true")
+	if err := f.Parse([]string{}); err != nil {
+		t.Error("tls is off, so nothing is required, got ", err)
+	}
+
+	f = NewFlagfigSet("test", flag.ContinueOnError)
+	f.Bool("tls", false, "", "tls")
+	f.String("tls-key", "", "", "key")
+	f.String("tls-cert", "", "", "cert")
+	f.MarkRequiredIf("tls-key", "tls", "true")
+	f.MarkRequiredIf("tls-cert", "tls", "true")
+	err := f.Parse([]string{"-tls"})
+	validationErr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatal("expected a ValidationError, got ", err)
+	}
+	expected := []string{"-tls-key is required when -tls is true, as set by the command line", "-tls-cert is required when -tls is true, as set by the command line"}
+	if !reflect.DeepEqual(validationErr.Problems, expected) {
+		t.Errorf("expected %q, got %q", expected, validationErr.Problems)
+	}
+	expectedMessage := "2 problems with the configuration:\n  -tls-key is required when -tls is true, as set by the command line\n  -tls-cert is required when -tls is true, as set by the command line"
+	if err.Error() != expectedMessage {
+		t.Errorf("expected %q, got %q", expectedMessage, err)
+	}
+}
+
+func TestParseCommandLineMissingRequired(t *testing.T) {
+	defer func(saved *FlagfigSet, args []string) { CommandLine, os.Args = saved, args }(CommandLine, os.Args)
+	CommandLine = NewFlagfigSet("test", flag.PanicOnError)
+	CommandLine.SetOutput(ioutil.Discard)
+	os.Args = []string{"test"}
+	String("dsn", "", "", "dsn", Required())
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, ErrMissingRequired) {
+			t.Error("expected Parse to panic with the missing flag, got ", err)
+		}
+	}()
+	Parse()
+	t.Error("expected Parse to panic")
+}
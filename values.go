@@ -0,0 +1,337 @@
+package flagfig
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// stringSliceValue is a flag.Value holding a list of strings.
+// On the command line, the list is given as comma-separated values: -peers=a,b,c
+// or by repeating the flag, which adds to the list: -peers=a -peers=b,c
+// Environment variables and configuration files may also use a JSON array: ["a","b","c"]
+// If the flag's environment variable is not set, numbered variables are tried instead: PEERS_0=a PEERS_1=b ...
+type stringSliceValue struct {
+	p        *[]string
+	defaults []string
+	// merge makes Set append to the items set earlier in the same Collate, rather than replace them
+	merge bool
+	// set is true once Set has been called since the last reset
+	set bool
+	// json makes the next Set decode a JSON array, see jsonSettable
+	json bool
+}
+
+func newStringSliceValue(val []string, p *[]string) *stringSliceValue {
+	*p = append([]string(nil), val...)
+	return &stringSliceValue{p: p, defaults: append([]string(nil), val...)}
+}
+
+func (s *stringSliceValue) Set(val string) error {
+	var list []string
+	if s.json {
+		s.json = false
+		err := json.Unmarshal([]byte(val), &list)
+		if err != nil {
+			return err
+		}
+	} else {
+		list = splitList(val, ",")
+	}
+	if s.merge && s.set {
+		*s.p = append(*s.p, list...)
+	} else {
+		*s.p = list
+	}
+	s.set = true
+	return nil
+}
+
+func (s *stringSliceValue) Get() interface{} { return *s.p }
+
+func (s *stringSliceValue) String() string {
+	if s == nil || s.p == nil {
+		return ""
+	}
+	return strings.Join(*s.p, ",")
+}
+
+func (s *stringSliceValue) expectJSON() { s.json = true }
+
+func (s *stringSliceValue) setMerge(merge bool) { s.merge = merge }
+
+func (s *stringSliceValue) reset() {
+	*s.p = append([]string(nil), s.defaults...)
+	s.set = false
+}
+
+func (s *stringSliceValue) unset() { s.set = false }
+
+func (s *stringSliceValue) keepAsDefault() {
+	s.defaults = append([]string(nil), *s.p...)
+	s.set = false
+}
+
+// stringMapValue is a flag.Value holding string keys and values.
+// On the command line, the map is given as comma-separated key=value pairs: -labels=a=1,b=2
+// or by repeating the flag, which adds to the map: -labels=a=1 -labels=b=2
+// Environment variables and configuration files may also use a JSON object: {"a":"1","b":"2"}. Numbers and bools in
+// the object are kept as they are written, so {"a":1,"b":true} is the same as a=1,b=true
+type stringMapValue struct {
+	p        *map[string]string
+	defaults map[string]string
+	// merge makes Set add to the keys set earlier in the same Collate, rather than replace them
+	merge bool
+	// set is true once Set has been called since the last reset
+	set bool
+	// json makes the next Set decode a JSON object, see jsonSettable
+	json bool
+}
+
+func newStringMapValue(val map[string]string, p *map[string]string) *stringMapValue {
+	*p = copyStringMap(val)
+	return &stringMapValue{p: p, defaults: copyStringMap(val)}
+}
+
+func (m *stringMapValue) Set(val string) error {
+	out := make(map[string]string)
+	if m.json {
+		m.json = false
+		var raw map[string]json.RawMessage
+		err := json.Unmarshal([]byte(val), &raw)
+		if err != nil {
+			return err
+		}
+		for k, v := range raw {
+			out[k], err = jsonScalarText(v)
+			if err != nil {
+				return fmt.Errorf("value of %q %v", k, err)
+			}
+		}
+	} else {
+		for _, pair := range splitList(val, ",") {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) == 2 {
+				out[kv[0]] = kv[1]
+			} else {
+				out[kv[0]] = ""
+			}
+		}
+	}
+	if m.merge && m.set {
+		for k, v := range out {
+			(*m.p)[k] = v
+		}
+	} else {
+		*m.p = out
+	}
+	m.set = true
+	return nil
+}
+
+func (m *stringMapValue) expectJSON() { m.json = true }
+
+// jsonScalarText is the text of a JSON string, number or bool
+func jsonScalarText(raw json.RawMessage) (text string, err error) {
+	if len(raw) != 0 && raw[0] == '"' {
+		err = json.Unmarshal(raw, &text)
+		return
+	}
+	var v interface{}
+	if err = json.Unmarshal(raw, &v); err != nil {
+		return "", err
+	}
+	switch v.(type) {
+	case bool, float64:
+		return string(raw), nil
+	}
+	return "", errors.New("must be a string, number or bool")
+}
+
+func (m *stringMapValue) Get() interface{} { return *m.p }
+
+func (m *stringMapValue) String() string {
+	if m == nil || m.p == nil {
+		return ""
+	}
+	keys := make([]string, 0, len(*m.p))
+	for k := range *m.p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+(*m.p)[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (m *stringMapValue) setMerge(merge bool) { m.merge = merge }
+
+func (m *stringMapValue) reset() {
+	*m.p = copyStringMap(m.defaults)
+	m.set = false
+}
+
+func (m *stringMapValue) unset() { m.set = false }
+
+func (m *stringMapValue) keepAsDefault() {
+	m.defaults = copyStringMap(*m.p)
+	m.set = false
+}
+
+func copyStringMap(val map[string]string) map[string]string {
+	out := make(map[string]string, len(val))
+	for k, v := range val {
+		out[k] = v
+	}
+	return out
+}
+
+// mergeable values can combine the values given by several sources, see Merge
+type mergeable interface {
+	flag.Value
+	setMerge(merge bool)
+	reset()
+	// unset forgets that Set was called, so the next call replaces the value even when merging
+	unset()
+}
+
+// jsonValue is a flag.Value that decodes a JSON document into any Go value, usually a struct
+type jsonValue struct {
+	target interface{}
+}
+
+func (j *jsonValue) Set(val string) error {
+	// Start from the zero value so that a new document replaces, rather than merges with, the old one
+	ptr := reflect.New(reflect.TypeOf(j.target).Elem())
+	err := json.Unmarshal([]byte(val), ptr.Interface())
+	if err != nil {
+		return err
+	}
+	reflect.ValueOf(j.target).Elem().Set(ptr.Elem())
+	return nil
+}
+
+func (j *jsonValue) Get() interface{} {
+	return reflect.ValueOf(j.target).Elem().Interface()
+}
+
+func (j *jsonValue) String() string {
+	if j == nil || j.target == nil {
+		return ""
+	}
+	raw, err := json.Marshal(j.target)
+	if err != nil {
+		return ""
+	}
+	return string(raw)
+}
+
+// jsonSettable is a flag.Value that takes plain text on the command line, but may be given a JSON document by the
+// environment and configuration files. expectJSON makes its next Set decode one, so that a command line value such as
+// [::1]:8080 is never mistaken for JSON
+type jsonSettable interface {
+	expectJSON()
+}
+
+// looksLikeJSON is true if the value appears to be a JSON array or object rather than a plain value
+func looksLikeJSON(val string) bool {
+	val = strings.TrimSpace(val)
+	return strings.HasPrefix(val, "[") || strings.HasPrefix(val, "{")
+}
+
+// splitList splits val on sep, trimming the space around each item. A blank value is an empty list
+func splitList(val, sep string) []string {
+	if len(strings.TrimSpace(val)) == 0 {
+		return []string{}
+	}
+	items := strings.Split(val, sep)
+	for i, item := range items {
+		items[i] = strings.TrimSpace(item)
+	}
+	return items
+}
+
+// isComplex is true for flags holding lists, maps or JSON documents
+func (f *FlagfigSet) isComplex(name string) bool {
+	switch f.flagTypes[name] {
+	case stringSliceType, stringMapType, jsonType:
+		return true
+	}
+	return false
+}
+
+func StringSlice(name string, defaultValue []string, envName, usage string, opts ...FlagOption) *[]string {
+	return CommandLine.StringSlice(name, defaultValue, envName, usage, opts...)
+}
+
+// StringSlice defines a flag holding a list of strings. See stringSliceValue for the accepted formats
+func (f *FlagfigSet) StringSlice(name string, defaultValue []string, envName, usage string, opts ...FlagOption) *[]string {
+	return f.StringSliceEnv(name, defaultValue, envOptFromName(envName), usage, opts...)
+}
+
+func StringSliceEnv(name string, defaultValue []string, env EnvOpt, usage string, opts ...FlagOption) *[]string {
+	return CommandLine.StringSliceEnv(name, defaultValue, env, usage, opts...)
+}
+
+// StringSliceEnv is the same as StringSlice, but the environment handling is described with an EnvOpt
+func (f *FlagfigSet) StringSliceEnv(name string, defaultValue []string, env EnvOpt, usage string, opts ...FlagOption) *[]string {
+	p := new([]string)
+	name = f.register(name, stringSliceType, env)
+	f.FlagSet.Var(newStringSliceValue(defaultValue, p), name, usage)
+	f.applyOptions(name, opts)
+	return p
+}
+
+func StringMap(name string, defaultValue map[string]string, envName, usage string, opts ...FlagOption) *map[string]string {
+	return CommandLine.StringMap(name, defaultValue, envName, usage, opts...)
+}
+
+// StringMap defines a flag holding string keys and values. See stringMapValue for the accepted formats
+func (f *FlagfigSet) StringMap(name string, defaultValue map[string]string, envName, usage string, opts ...FlagOption) *map[string]string {
+	return f.StringMapEnv(name, defaultValue, envOptFromName(envName), usage, opts...)
+}
+
+func StringMapEnv(name string, defaultValue map[string]string, env EnvOpt, usage string, opts ...FlagOption) *map[string]string {
+	return CommandLine.StringMapEnv(name, defaultValue, env, usage, opts...)
+}
+
+// StringMapEnv is the same as StringMap, but the environment handling is described with an EnvOpt
+func (f *FlagfigSet) StringMapEnv(name string, defaultValue map[string]string, env EnvOpt, usage string, opts ...FlagOption) *map[string]string {
+	p := new(map[string]string)
+	name = f.register(name, stringMapType, env)
+	f.FlagSet.Var(newStringMapValue(defaultValue, p), name, usage)
+	f.applyOptions(name, opts)
+	return p
+}
+
+func JSONVar(p interface{}, name, envName, usage string, opts ...FlagOption) {
+	CommandLine.JSONVar(p, name, envName, usage, opts...)
+}
+
+// JSONVar defines a flag whose value is a JSON document decoded into p, which must be a pointer. Whatever p holds
+// when JSONVar is called is the default. This is handy for struct-typed settings:
+//
+//	limits := &Limits{Rate: 10}
+//	flags.JSONVar(limits, "limits", "MYAPP_LIMITS", "rate limits")
+//
+// Which can then be set with: -limits='{"Rate":20}', MYAPP_LIMITS='{"Rate":20}' or a nested object in a configuration file
+func (f *FlagfigSet) JSONVar(p interface{}, name, envName, usage string, opts ...FlagOption) {
+	f.JSONVarEnv(p, name, envOptFromName(envName), usage, opts...)
+}
+
+func JSONVarEnv(p interface{}, name string, env EnvOpt, usage string, opts ...FlagOption) {
+	CommandLine.JSONVarEnv(p, name, env, usage, opts...)
+}
+
+// JSONVarEnv is the same as JSONVar, but the environment handling is described with an EnvOpt
+func (f *FlagfigSet) JSONVarEnv(p interface{}, name string, env EnvOpt, usage string, opts ...FlagOption) {
+	name = f.register(name, jsonType, env)
+	f.FlagSet.Var(&jsonValue{target: p}, name, usage)
+	f.applyOptions(name, opts)
+}
@@ -0,0 +1,128 @@
+package flagfig
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+type testLimits struct {
+	Rate  int
+	Burst int
+}
+
+func TestComplexFlagsFromCommandLine(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	peers := f.StringSlice("peers", []string{"default"}, "", "peers")
+	labels := f.StringMap("labels", nil, "", "labels")
+	limits := &testLimits{Rate: 10}
+	f.JSONVar(limits, "limits", "", "limits")
+	err := f.Parse([]string{"-peers=a, b", "-labels=x=1,y=2", `-limits={"Burst":5}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*peers, []string{"a", "b"}) {
+		t.Error("peers should be [a b], is ", *peers)
+	}
+	if !reflect.DeepEqual(*labels, map[string]string{"x": "1", "y": "2"}) {
+		t.Error("labels should be x=1,y=2, is ", *labels)
+	}
+	if *limits != (testLimits{Burst: 5}) {
+		t.Error("limits should be replaced by the document, is ", *limits)
+	}
+}
+
+func TestComplexFlagsCommandLineIsNotJSON(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	peers := f.StringSlice("peers", nil, "", "peers")
+	labels := f.StringMap("labels", nil, "", "labels")
+	err := f.Parse([]string{"-peers=[::1]:8080,[::2]:9090", "-labels={team}=a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*peers, []string{"[::1]:8080", "[::2]:9090"}) {
+		t.Error("peers should be split on commas, is ", *peers)
+	}
+	if !reflect.DeepEqual(*labels, map[string]string{"{team}": "a"}) {
+		t.Error("labels should be {team}=a, is ", *labels)
+	}
+}
+
+func TestComplexFlagsRepeated(t *testing.T) {
+	_ = os.Setenv("ENV_REPEATED_HEADERS", "from-env")
+	defer func() { _ = os.Unsetenv("ENV_REPEATED_HEADERS") }()
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	headers := f.StringSlice("header", []string{"default"}, "", "headers", Short("H"))
+	labels := f.StringMap("label", map[string]string{"default": "1"}, "", "labels")
+	envHeaders := f.StringSlice("env-header", nil, "ENV_REPEATED_HEADERS", "headers")
+	err := f.Parse([]string{"-header=a", "-H", "b,c", "-label=x=1", "-label=y=2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*headers, []string{"a", "b", "c"}) {
+		t.Error("headers should be [a b c], is ", *headers)
+	}
+	if !reflect.DeepEqual(*labels, map[string]string{"x": "1", "y": "2"}) {
+		t.Error("labels should be x=1,y=2, is ", *labels)
+	}
+	if !reflect.DeepEqual(*envHeaders, []string{"from-env"}) {
+		t.Error("env-header should be [from-env], is ", *envHeaders)
+	}
+}
+
+func TestComplexFlagsFromEnvJSON(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	peers := f.StringSlice("peers", nil, "ENV_PEERS", "peers")
+	labels := f.StringMap("labels", nil, "ENV_LABELS", "labels")
+	limits := &testLimits{}
+	f.JSONVar(limits, "limits", "ENV_LIMITS", "limits")
+	_ = os.Setenv("ENV_PEERS", `["a,1","b"]`)
+	_ = os.Setenv("ENV_LABELS", `{"a":"b"}`)
+	_ = os.Setenv("ENV_LIMITS", `{"Rate":3,"Burst":4}`)
+	defer func() {
+		_ = os.Unsetenv("ENV_PEERS")
+		_ = os.Unsetenv("ENV_LABELS")
+		_ = os.Unsetenv("ENV_LIMITS")
+	}()
+	if err := f.Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*peers, []string{"a,1", "b"}) {
+		t.Error("peers should be [a,1 b], is ", *peers)
+	}
+	if !reflect.DeepEqual(*labels, map[string]string{"a": "b"}) {
+		t.Error("labels should be a=b, is ", *labels)
+	}
+	if *limits != (testLimits{Rate: 3, Burst: 4}) {
+		t.Error("limits should be decoded from the environment, is ", *limits)
+	}
+}
+
+func TestComplexFlagsFromConfigFile(t *testing.T) {
+	tmpFileName, tfremove := testTempFile(t)
+	defer tfremove()
+	content := `{"peers":["a","b"],"labels":{"a":"b"},"limits":{"Rate":7}}`
+	if err := ioutil.WriteFile(tmpFileName, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.AddConfigFile("config", "config file")
+	peers := f.StringSlice("peers", nil, "", "peers")
+	labels := f.StringMap("labels", nil, "", "labels")
+	limits := &testLimits{}
+	f.JSONVar(limits, "limits", "", "limits")
+	if err := f.Parse([]string{"-config", tmpFileName}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*peers, []string{"a", "b"}) {
+		t.Error("peers should be [a b], is ", *peers)
+	}
+	if !reflect.DeepEqual(*labels, map[string]string{"a": "b"}) {
+		t.Error("labels should be a=b, is ", *labels)
+	}
+	if *limits != (testLimits{Rate: 7}) {
+		t.Error("limits should be decoded from the file, is ", *limits)
+	}
+}
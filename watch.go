@@ -0,0 +1,209 @@
+package flagfig
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+)
+
+// defaultWatchInterval is how often Watch looks for changes to the configuration files, unless SetWatchInterval says
+// otherwise
+const defaultWatchInterval = time.Second
+
+// fileStamp is what Watch compares to tell that a configuration file changed
+type fileStamp struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+func SetWatchInterval(interval, jitter time.Duration) {
+	CommandLine.SetWatchInterval(interval, jitter)
+}
+
+// SetWatchInterval sets how often Watch looks for changes, which is every second by default. Each wait is made longer
+// by a random amount, up to jitter, so that a fleet of processes sharing a configuration does not all reload at the
+// same moment
+func (f *FlagfigSet) SetWatchInterval(interval, jitter time.Duration) {
+	f.watchInterval, f.watchJitter = interval, jitter
+}
+
+// nextWatch is how long Watch waits before looking again
+func (f *FlagfigSet) nextWatch() time.Duration {
+	interval := f.watchInterval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	if f.watchJitter > 0 {
+		interval += time.Duration(rand.Int63n(int64(f.watchJitter)))
+	}
+	return interval
+}
+
+func Watch(ctx context.Context) error {
+	return CommandLine.Watch(ctx)
+}
+
+// Watch looks at the configuration files every second, see SetWatchInterval, and calls Reload when one of them is written, created or
+// removed, so that long-running services can pick up configuration changes without a restart. It blocks until ctx is
+// done, and then returns ctx.Err(), so run it in its own goroutine once Parse succeeds:
+//
+//	flags.OnParsed(func(f *flagfig.FlagfigSet) error {
+//		server.SetLimit(*limit)
+//		return nil
+//	})
+//	err := flags.Parse(os.Args[1:])
+//	...
+//	go flags.Watch(ctx)
+//
+// Values change in the goroutine running Watch, so read them from a Snapshot, or from OnParsed hooks, which run after
+// every successful reload, rather than through the pointers returned when the flags were defined. Failed reloads are
+// written to the Logger, see SetLogger, and leave the values as they were. Successful ones write each Change there,
+// and pass them to the OnReload hooks
+func (f *FlagfigSet) Watch(ctx context.Context) error {
+	stamps := f.configStamps()
+	timer := time.NewTimer(f.nextWatch())
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+		timer.Reset(f.nextWatch())
+		latest := f.configStamps()
+		if sameStamps(stamps, latest) {
+			continue
+		}
+		stamps = latest
+		changes, err := f.reload()
+		if err != nil {
+			f.logf("Unable to reload the configuration because: %s", err)
+		}
+		for _, c := range changes {
+			f.logf("Reloaded: %s", c)
+		}
+	}
+}
+
+func Reload() error {
+	return CommandLine.Reload()
+}
+
+// Reload resolves the flags again, as Collate does after Parse, to pick up changes to the configuration files and the
+// environment. Values given on the command line still win, and flags no longer set by any source return to their
+// defaults. If the new configuration is invalid, the error is returned and the flags keep their previous values.
+// Otherwise, the OnReload hooks are told what changed
+func (f *FlagfigSet) Reload() (err error) {
+	_, err = f.reload()
+	return
+}
+
+// reload is Reload, returning what changed
+func (f *FlagfigSet) reload() (changes []Change, err error) {
+	before, err := f.resolveAgain()
+	if err != nil {
+		return nil, err
+	}
+	return f.reportChanges(before), nil
+}
+
+// resolveAgain does the work of Reload, returning the Snapshot from before
+func (f *FlagfigSet) resolveAgain() (before *Snapshot, err error) {
+	for _, layer := range f.configLayers {
+		if layer.path == nil || len(*layer.path) == 0 || layer.missing != RequireFile {
+			continue
+		}
+		// A file being replaced may be missing for a moment, and Collate cannot do without it
+		if err = f.configReadable(*layer.path); err != nil {
+			return nil, fmt.Errorf("configuration file %s is not readable: %v", layer.describe(), err)
+		}
+	}
+	f.reloadMu.Lock()
+	defer f.reloadMu.Unlock()
+	before = f.Snapshot()
+	restore := f.saveValues()
+	f.reloadFlags = make(map[string]bool)
+	for name, o := range f.origins {
+		if o.source == SourceFlag {
+			f.reloadFlags[name] = true
+		}
+	}
+	defer func() { f.reloadFlags = nil }()
+	f.resetUnvisited()
+	err = f.Collate()
+	if err != nil {
+		restore()
+	}
+	return
+}
+
+// saveValues records the values of the flags and where they came from, and returns a function that puts them back
+func (f *FlagfigSet) saveValues() (restore func()) {
+	values := make(map[string]string)
+	origins := make(map[string]origin, len(f.origins))
+	for name, o := range f.origins {
+		origins[name] = o
+	}
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		values[fl.Name] = valueText(fl)
+	})
+	return func() {
+		f.FlagSet.VisitAll(func(fl *flag.Flag) {
+			if m, ok := fl.Value.(mergeable); ok {
+				m.unset()
+			}
+			_ = setValueText(fl, fl, values[fl.Name])
+		})
+		f.origins = origins
+	}
+}
+
+// resetUnvisited returns the flags not given on the command line to their defaults, so that values taken out of the
+// configuration files do not linger. Flagfig's own flags, and the flags inherited from parent commands, are left alone
+func (f *FlagfigSet) resetUnvisited() {
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		if f.reloadFlags[fl.Name] || f.internalFlags[fl.Name] {
+			return
+		}
+		if m, ok := fl.Value.(mergeable); ok {
+			m.reset()
+			return
+		}
+		_ = fl.Value.Set(fl.DefValue)
+	})
+}
+
+// configStamps describes the configuration files currently named by the layers and AddConfigFile flags
+func (f *FlagfigSet) configStamps() map[string]fileStamp {
+	stamps := make(map[string]fileStamp)
+	for _, layer := range f.configLayers {
+		if layer.path == nil || len(*layer.path) == 0 {
+			continue
+		}
+		info, err := os.Stat(*layer.path)
+		if err != nil {
+			stamps[*layer.path] = fileStamp{}
+			continue
+		}
+		stamps[*layer.path] = fileStamp{exists: true, modTime: info.ModTime(), size: info.Size()}
+	}
+	return stamps
+}
+
+// sameStamps is true if no file was added, removed or changed
+func sameStamps(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, stamp := range a {
+		other, ok := b[path]
+		if !ok || other.exists != stamp.exists || !other.modTime.Equal(stamp.modTime) || other.size != stamp.size {
+			return false
+		}
+	}
+	return true
+}
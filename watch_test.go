@@ -0,0 +1,136 @@
+package flagfig
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	path, cleanup := testTempFile(t)
+	defer cleanup()
+	write := func(content string) {
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{"host": "a.example.com", "port": 80, "peers": ["a"]}`)
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.AddConfigLayer("base", path)
+	host := f.String("host", "localhost", "", "host")
+	port := f.Int("port", 8080, "", "port", Max(65535))
+	limit := f.Int("limit", 10, "", "limit")
+	peers := f.StringSlice("peers", nil, "", "peers", Merge(MergeAppend))
+	if err := f.Parse([]string{"-limit=5", "-peers=b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		content  string
+		expected string
+		host     string
+		port     int
+		peers    string
+	}{
+		"changed": {
+			content: `{"host": "b.example.com", "limit": 100, "peers": ["c"]}`,
+			host:    "b.example.com",
+			port:    8080,
+			peers:   "c,b",
+		},
+		"invalid": {
+			content:  `{"host": "c.example.com", "port": 70000}`,
+			expected: "-port is 70000 from configuration file base (" + path + "), key \"port\", but must be at most 65535",
+			host:     "b.example.com",
+			port:     8080,
+			peers:    "c,b",
+		},
+	}
+	for _, caseName := range []string{"changed", "invalid"} {
+		c := cases[caseName]
+		write(c.content)
+		err := f.Reload()
+		if len(c.expected) == 0 && err != nil {
+			t.Errorf("case %s: unexpected error: %s", caseName, err)
+		}
+		if len(c.expected) != 0 && (err == nil || err.Error() != c.expected) {
+			t.Errorf("case %s: expected %q, got %v", caseName, c.expected, err)
+		}
+		if *host != c.host || *port != c.port || *limit != 5 || strings.Join(*peers, ",") != c.peers {
+			t.Errorf("case %s: expected %s, %d, 5 and %s, got %s, %d, %d and %v", caseName, c.host, c.port, c.peers,
+				*host, *port, *limit, *peers)
+		}
+	}
+	if source, _ := f.Origin("port"); source != SourceDefault {
+		t.Error("expected -port to be back to its default, got ", source)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path, cleanup := testTempFile(t)
+	defer cleanup()
+	if err := ioutil.WriteFile(path, []byte(`{"host": "a.example.com"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	f.AddConfigLayer("base", path)
+	f.SetWatchInterval(10*time.Millisecond, 5*time.Millisecond)
+	logged := &strings.Builder{}
+	f.SetLogger(log.New(logged, "", 0))
+	host := f.String("host", "localhost", "", "host")
+	reloaded := make(chan string, 1)
+	f.OnParsed(func(f *FlagfigSet) error {
+		select {
+		case reloaded <- *host:
+		default:
+		}
+		return nil
+	})
+	if err := f.Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	<-reloaded
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- f.Watch(ctx) }()
+	time.Sleep(20 * time.Millisecond)
+	if err := ioutil.WriteFile(path, []byte(`{"host": "b.example.com"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// The file is the same size, and some file systems only keep whole seconds, so move its time along
+	_ = os.Chtimes(path, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	select {
+	case got := <-reloaded:
+		if got != "b.example.com" {
+			t.Error("expected the new host, got ", got)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected Watch to reload the configuration")
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Error("expected Watch to stop with the context, got ", err)
+	}
+	expected := "Reloaded: -host changed from a.example.com to b.example.com (file (base (" + path + ")))\n"
+	if logged.String() != expected {
+		t.Errorf("expected the change to be logged as %q, got %q", expected, logged.String())
+	}
+}
+
+func TestSetWatchInterval(t *testing.T) {
+	f := NewFlagfigSet("test", flag.ContinueOnError)
+	if d := f.nextWatch(); d != time.Second {
+		t.Error("expected the default of a second, got ", d)
+	}
+	f.SetWatchInterval(time.Minute, 10*time.Second)
+	for i := 0; i < 100; i++ {
+		if d := f.nextWatch(); d < time.Minute || d >= time.Minute+10*time.Second {
+			t.Fatal("expected a minute plus up to 10s, got ", d)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package flagfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// WriteOption changes what WriteConfigAs writes
+type WriteOption func(o *writeOptions)
+
+type writeOptions struct {
+	changedOnly bool
+}
+
+// OnlyChanged makes WriteConfigAs leave out the flags that have their default values, so the file only holds what
+// was chosen, and later changes to the defaults still apply
+func OnlyChanged() WriteOption {
+	return func(o *writeOptions) {
+		o.changedOnly = true
+	}
+}
+
+func WriteConfigAs(path string, format ConfigFormat, opts ...WriteOption) error {
+	return CommandLine.WriteConfigAs(path, format, opts...)
+}
+
+// WriteConfigAs saves the resolved values of the flags to the configuration file at path, replacing it, so a program
+// can be set up once, with flags or by Prompt, and use the file from then on:
+//
+//	if err := flags.Parse(os.Args[1:]); err != nil {
+//		...
+//	}
+//	if *save {
+//		err = flags.WriteConfigAs(*configFile, flagfig.ConfigYAML, flagfig.OnlyChanged())
+//	}
+//
+// The file is written as DumpYAML describes, or as a JSON object of the same values, and is only readable by its
+// owner. Sensitive values are left out, unless shown by UnsafeRevealSensitive. The file is written aside and renamed
+// into place, so a failure never leaves half of it behind
+func (f *FlagfigSet) WriteConfigAs(path string, format ConfigFormat, opts ...WriteOption) (err error) {
+	o := writeOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	buf := &bytes.Buffer{}
+	if format == ConfigYAML {
+		err = f.writeYAMLConfig(buf, o.changedOnly)
+	} else {
+		err = f.writeJSONConfig(buf, o.changedOnly)
+	}
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err = ioutil.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+	}
+	return err
+}
+
+// writeJSONConfig writes the values DumpYAML would as a JSON configuration file
+func (f *FlagfigSet) writeJSONConfig(w io.Writer, changedOnly bool) error {
+	values := make(map[string]interface{})
+	f.FlagSet.VisitAll(func(fl *flag.Flag) {
+		if f.dumpable(fl.Name, SourceFile) && !f.redacted(fl.Name) && (!changedOnly || changed(fl)) {
+			values[fl.Name] = f.effectiveValue(fl)
+		}
+	})
+	raw, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", raw)
+	return err
+}
@@ -0,0 +1,81 @@
+package flagfig
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+)
+
+func TestWriteConfigAs(t *testing.T) {
+	cases := map[string]struct {
+		format   ConfigFormat
+		opts     []WriteOption
+		expected string
+	}{
+		"json": {
+			format: ConfigJSON,
+			expected: `{
+  "host": "localhost",
+  "peers": [
+    "a",
+    "b"
+  ],
+  "port": 9090
+}
+`,
+		},
+		"yaml": {
+			format: ConfigYAML,
+			expected: `host: "localhost"
+peers: ["a","b"]
+port: 9090
+# token is sensitive, and left out
+`,
+		},
+		"only changed": {
+			format: ConfigYAML,
+			opts:   []WriteOption{OnlyChanged()},
+			expected: `peers: ["a","b"]
+port: 9090
+# token is sensitive, and left out
+`,
+		},
+	}
+	for caseName, c := range cases {
+		path, cleanup := testTempFile(t)
+		define := func() *FlagfigSet {
+			f := NewFlagfigSet("test", flag.ContinueOnError)
+			f.AddConfigFile("config", "configuration file")
+			f.String("host", "localhost", "", "host")
+			f.Int("port", 8080, "", "port")
+			f.StringSlice("peers", nil, "", "peers")
+			f.String("token", "", "", "token", Sensitive())
+			return f
+		}
+		f := define()
+		if err := f.Parse([]string{"-port=9090", "-peers=a,b", "-token=secret"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.WriteConfigAs(path, c.format, c.opts...); err != nil {
+			t.Fatalf("case %s: %v", caseName, err)
+		}
+		dat, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(dat) != c.expected {
+			t.Errorf("case %s: expected %s, got %s", caseName, c.expected, string(dat))
+		}
+		if c.format == ConfigJSON {
+			again := define()
+			if err = again.Parse([]string{"-config", path}); err != nil {
+				t.Fatalf("case %s: %v", caseName, err)
+			}
+			if again.Lookup("port").Value.String() != "9090" || again.Lookup("peers").Value.String() != "a,b" {
+				t.Errorf("case %s: expected the file to read back, got -port=%s -peers=%s", caseName,
+					again.Lookup("port").Value, again.Lookup("peers").Value)
+			}
+		}
+		cleanup()
+	}
+}
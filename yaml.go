@@ -0,0 +1,455 @@
+package flagfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// parseYAML reads the subset of YAML that configuration files need: nested mappings and sequences, in block or flow
+// style, plain and quoted scalars, literal and folded block scalars, and comments. Anchors, aliases, tags and
+// multi-line flow collections are not supported. Numbers are returned as json.Number, so their text is kept, and the
+// other values are the same types encoding/json decodes to
+func parseYAML(data []byte) (doc map[string]interface{}, err error) {
+	p := &yamlParser{}
+	err = p.split(string(data))
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlank()
+	if p.pos == len(p.lines) {
+		return map[string]interface{}{}, nil
+	}
+	v, err := p.parseBlock(p.lines[p.pos].indent)
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlank()
+	if p.pos != len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].number)
+	}
+	doc, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("the document must be a mapping of keys to values")
+	}
+	return doc, nil
+}
+
+// yamlLine is one line of a YAML document
+type yamlLine struct {
+	number int
+	indent int
+	// text is the line without its indentation, trailing space or comment. It is blank for blank and comment lines
+	text string
+	// raw is the whole line, which block scalars use as is
+	raw string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// split breaks the document into lines
+func (p *yamlParser) split(data string) error {
+	for i, raw := range strings.Split(strings.Replace(data, "\r\n", "\n", -1), "\n") {
+		trimmed := strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(trimmed, "\t") {
+			return fmt.Errorf("line %d: tabs may not be used for indentation", i+1)
+		}
+		text := strings.TrimSpace(stripYAMLComment(trimmed))
+		if text == "---" || text == "..." {
+			text = ""
+		}
+		p.lines = append(p.lines, yamlLine{number: i + 1, indent: len(raw) - len(trimmed), text: text, raw: raw})
+	}
+	return nil
+}
+
+// stripYAMLComment removes a # comment, which starts a line or follows a space, and is not in quotes
+func stripYAMLComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" \t[{,:", s[i-1]) >= 0):
+			quote = c
+		case quote == 0 && c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+func (p *yamlParser) skipBlank() {
+	for p.pos < len(p.lines) && len(p.lines[p.pos].text) == 0 {
+		p.pos++
+	}
+}
+
+// isSequenceItem is true for lines that start with "- "
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// parseBlock parses the mapping or sequence that starts at the current line
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+	if isSequenceItem(p.lines[p.pos].text) {
+		return p.parseSequence(indent)
+	}
+	return p.parseMapping(indent)
+}
+
+func (p *yamlParser) parseMapping(indent int) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	for {
+		p.skipBlank()
+		if p.pos == len(p.lines) || p.lines[p.pos].indent < indent {
+			return m, nil
+		}
+		line := p.lines[p.pos]
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.number)
+		}
+		if isSequenceItem(line.text) {
+			return nil, fmt.Errorf("line %d: expected a key, not a list item", line.number)
+		}
+		key, rest, ok, err := splitYAMLKey(line.text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line.number, err)
+		}
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value", line.number)
+		}
+		if _, dup := m[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line.number, key)
+		}
+		p.pos++
+		v, err := p.parseValue(rest, indent, line.number)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+}
+
+func (p *yamlParser) parseSequence(indent int) ([]interface{}, error) {
+	list := make([]interface{}, 0)
+	for {
+		p.skipBlank()
+		if p.pos == len(p.lines) || p.lines[p.pos].indent < indent {
+			return list, nil
+		}
+		line := p.lines[p.pos]
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.number)
+		}
+		if !isSequenceItem(line.text) {
+			// A mapping may continue after a list written at the same indentation as its key
+			return list, nil
+		}
+		rest := strings.TrimLeft(line.text[1:], " ")
+		if _, _, ok, _ := splitYAMLKey(rest); ok {
+			// "- key: value" starts a mapping, indented to where its key is
+			p.lines[p.pos].indent = indent + len(line.text) - len(rest)
+			p.lines[p.pos].text = rest
+			v, err := p.parseMapping(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+			continue
+		}
+		p.pos++
+		v, err := p.parseValue(rest, indent, line.number)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+}
+
+// parseValue parses what follows a key or a list item's dash. If that is blank, the value is the block indented
+// below it, if any
+func (p *yamlParser) parseValue(rest string, indent, number int) (interface{}, error) {
+	switch {
+	case len(rest) == 0:
+		p.skipBlank()
+		if p.pos == len(p.lines) {
+			return nil, nil
+		}
+		next := p.lines[p.pos]
+		if next.indent > indent || (next.indent == indent && isSequenceItem(next.text)) {
+			return p.parseBlock(next.indent)
+		}
+		return nil, nil
+	case rest[0] == '|' || rest[0] == '>':
+		return p.parseBlockScalar(rest, indent, number)
+	case rest[0] == '[' || rest[0] == '{':
+		v, n, err := parseYAMLFlow(rest)
+		if err == nil && len(strings.TrimSpace(rest[n:])) != 0 {
+			err = fmt.Errorf("unexpected %q after the collection, flow collections must be on one line", rest[n:])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", number, err)
+		}
+		return v, nil
+	case rest[0] == '&' || rest[0] == '*' || rest[0] == '!':
+		return nil, fmt.Errorf("line %d: anchors, aliases and tags are not supported", number)
+	}
+	v, err := parseYAMLScalar(rest)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %v", number, err)
+	}
+	return v, nil
+}
+
+// parseBlockScalar reads a literal (|) or folded (>) block scalar, with an optional chomping indicator (- or +)
+func (p *yamlParser) parseBlockScalar(header string, indent, number int) (interface{}, error) {
+	chomp := strings.TrimLeft(header[1:], "123456789")
+	if len(chomp) > 1 || (len(chomp) == 1 && chomp != "-" && chomp != "+") {
+		return nil, fmt.Errorf("line %d: unsupported block scalar header %q", number, header)
+	}
+	lines := make([]string, 0)
+	blockIndent := -1
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if len(strings.TrimSpace(line.raw)) == 0 {
+			lines = append(lines, "")
+			p.pos++
+			continue
+		}
+		if line.indent <= indent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = line.indent
+		}
+		if line.indent < blockIndent {
+			return nil, fmt.Errorf("line %d: block scalar lines must be indented at least as much as the first", line.number)
+		}
+		lines = append(lines, strings.TrimRight(line.raw[blockIndent:], " "))
+		p.pos++
+	}
+	// Trailing blank lines belong to what follows, unless the block keeps them
+	trailing := 0
+	for len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+	// Give the lines back, so that line numbers in later errors are right
+	p.pos -= trailing
+	var text string
+	if header[0] == '|' {
+		text = strings.Join(lines, "\n")
+	} else {
+		sb := strings.Builder{}
+		for i, line := range lines {
+			switch {
+			case i == 0:
+			case len(line) == 0 || len(lines[i-1]) == 0:
+				sb.WriteString("\n")
+			default:
+				sb.WriteString(" ")
+			}
+			sb.WriteString(line)
+		}
+		text = sb.String()
+	}
+	switch {
+	case len(lines) == 0:
+	case chomp == "-":
+	case chomp == "+":
+		text += strings.Repeat("\n", trailing+1)
+	default:
+		text += "\n"
+	}
+	return text, nil
+}
+
+// splitYAMLKey splits "key: value" into its key and value. ok is false if text is not a key and value
+func splitYAMLKey(text string) (key, rest string, ok bool, err error) {
+	if len(text) == 0 || text[0] == '[' || text[0] == '{' {
+		return "", "", false, nil
+	}
+	if text[0] == '"' || text[0] == '\'' {
+		n, err := scanYAMLQuoted(text)
+		if err != nil {
+			return "", "", false, err
+		}
+		after := strings.TrimLeft(text[n:], " ")
+		if !strings.HasPrefix(after, ":") || (len(after) > 1 && after[1] != ' ') {
+			return "", "", false, nil
+		}
+		v, err := parseYAMLScalar(text[:n])
+		if err != nil {
+			return "", "", false, err
+		}
+		return v.(string), strings.TrimSpace(after[1:]), true, nil
+	}
+	i := strings.Index(text, ": ")
+	if i < 0 && strings.HasSuffix(text, ":") {
+		i = len(text) - 1
+	}
+	if i < 0 {
+		return "", "", false, nil
+	}
+	return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true, nil
+}
+
+// scanYAMLQuoted is the length of the quoted string that starts s
+func scanYAMLQuoted(s string) (n int, err error) {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case quote == '\'' && s[i] == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == quote:
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated quoted string %s", s)
+}
+
+// yamlNumber matches the integers and floats of the YAML core schema
+var yamlNumber = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+
+// parseYAMLScalar parses a single value: a quoted string, null, a boolean, a number, or any other text as a string
+func parseYAMLScalar(s string) (interface{}, error) {
+	s = strings.TrimSpace(s)
+	if len(s) != 0 && (s[0] == '"' || s[0] == '\'') {
+		n, err := scanYAMLQuoted(s)
+		if err != nil {
+			return nil, err
+		}
+		if n != len(s) {
+			return nil, fmt.Errorf("unexpected %q after quoted string", s[n:])
+		}
+		if s[0] == '\'' {
+			return strings.Replace(s[1:n-1], "''", "'", -1), nil
+		}
+		var v string
+		err = json.Unmarshal([]byte(s), &v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid double quoted string %s: %v", s, err)
+		}
+		return v, nil
+	}
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	if yamlNumber.MatchString(s) {
+		return json.Number(s), nil
+	}
+	return s, nil
+}
+
+// parseYAMLFlow parses a flow collection, such as [a, b] or {a: 1}, that starts s. n is how much of s it used
+func parseYAMLFlow(s string) (v interface{}, n int, err error) {
+	i := skipYAMLSpace(s, 0)
+	if i == len(s) {
+		return nil, i, fmt.Errorf("missing value")
+	}
+	switch s[i] {
+	case '[':
+		list := make([]interface{}, 0)
+		i = skipYAMLSpace(s, i+1)
+		for i < len(s) && s[i] != ']' {
+			item, used, err := parseYAMLFlow(s[i:])
+			if err != nil {
+				return nil, 0, err
+			}
+			list = append(list, item)
+			i, err = yamlFlowNext(s, i+used, ']')
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+		if i == len(s) {
+			return nil, 0, fmt.Errorf("missing ]")
+		}
+		return list, i + 1, nil
+	case '{':
+		m := make(map[string]interface{})
+		i = skipYAMLSpace(s, i+1)
+		for i < len(s) && s[i] != '}' {
+			keyLen := 0
+			if s[i] == '"' || s[i] == '\'' {
+				keyLen, err = scanYAMLQuoted(s[i:])
+				if err != nil {
+					return nil, 0, err
+				}
+			}
+			colon := strings.IndexByte(s[i+keyLen:], ':')
+			if colon < 0 {
+				return nil, 0, fmt.Errorf("expected key: value in %s", s[i:])
+			}
+			end := i + keyLen + colon
+			key, err := parseYAMLScalar(s[i:end])
+			if err != nil {
+				return nil, 0, err
+			}
+			item, used, err := parseYAMLFlow(s[end+1:])
+			if err != nil {
+				return nil, 0, err
+			}
+			name := fmt.Sprint(key)
+			if _, dup := m[name]; dup {
+				return nil, 0, fmt.Errorf("duplicate key %q", name)
+			}
+			m[name] = item
+			i, err = yamlFlowNext(s, end+1+used, '}')
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+		if i == len(s) {
+			return nil, 0, fmt.Errorf("missing }")
+		}
+		return m, i + 1, nil
+	case '"', '\'':
+		n, err := scanYAMLQuoted(s[i:])
+		if err != nil {
+			return nil, 0, err
+		}
+		v, err := parseYAMLScalar(s[i : i+n])
+		return v, i + n, err
+	}
+	end := i
+	for end < len(s) && strings.IndexByte(",]}", s[end]) < 0 {
+		end++
+	}
+	v, err = parseYAMLScalar(s[i:end])
+	return v, end, err
+}
+
+// yamlFlowNext moves past the comma after an item of a flow collection, stopping at the closing bracket
+func yamlFlowNext(s string, i int, closing byte) (int, error) {
+	i = skipYAMLSpace(s, i)
+	if i < len(s) && s[i] == ',' {
+		return skipYAMLSpace(s, i+1), nil
+	}
+	if i < len(s) && s[i] == closing {
+		return i, nil
+	}
+	return 0, fmt.Errorf("expected , or %c in %s", closing, s)
+}
+
+func skipYAMLSpace(s string, i int) int {
+	for i < len(s) && s[i] == ' ' {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,90 @@
+package flagfig
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	doc, err := parseYAML([]byte(`---
+# a comment
+name: my app # trailing comment
+quoted: "a # b"
+single: 'it''s'
+port: 8080
+ratio: 0.5
+version: 1.10
+enabled: true
+missing:
+peers:
+  - a
+  - "b"
+labels:
+  zone: eu
+  rack: "1"
+flow: [x, 'y', {k: v}]
+servers:
+- host: one
+  port: 1
+- host: two
+motd: |
+  hello
+    world
+folded: >-
+  one
+  two
+`))
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	expected := map[string]interface{}{
+		"name":    "my app",
+		"quoted":  "a # b",
+		"single":  "it's",
+		"port":    json.Number("8080"),
+		"ratio":   json.Number("0.5"),
+		"version": json.Number("1.10"),
+		"enabled": true,
+		"missing": nil,
+		"peers":   []interface{}{"a", "b"},
+		"labels":  map[string]interface{}{"zone": "eu", "rack": "1"},
+		"flow":    []interface{}{"x", "y", map[string]interface{}{"k": "v"}},
+		"servers": []interface{}{
+			map[string]interface{}{"host": "one", "port": json.Number("1")},
+			map[string]interface{}{"host": "two"},
+		},
+		"motd":   "hello\n  world\n",
+		"folded": "one two",
+	}
+	if !reflect.DeepEqual(doc, expected) {
+		t.Errorf("expected:\n%#v\ngot:\n%#v", expected, doc)
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	cases := map[string]string{
+		"tab indentation":    "a:\n\tb: 1\n",
+		"bad indentation":    "a: 1\n  b: 2\n",
+		"not a mapping":      "- a\n- b\n",
+		"unterminated":       "a: \"b\n",
+		"multi-line flow":    "a: [1,\n  2]\n",
+		"aliases":            "a: *b\n",
+		"missing separator":  "a b\n",
+		"duplicate key":      "a: 1\nb: 2\na: 3\n",
+		"duplicate flow key": "a: {b: 1, b: 2}\n",
+	}
+	for caseName, doc := range cases {
+		if _, err := parseYAML([]byte(doc)); err == nil {
+			t.Errorf("case %s: expected an error", caseName)
+		}
+	}
+}
+
+func TestParseYAMLDuplicateKeyLine(t *testing.T) {
+	_, err := parseYAML([]byte("tls:\n  cert: a.pem\n  key: a.key\n\n  cert: b.pem\n"))
+	expected := `line 5: duplicate key "cert"`
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}